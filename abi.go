@@ -0,0 +1,150 @@
+package aether
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrIncompatibleLibrary 表示原生库与 Go 绑定的 ABI 不兼容
+var ErrIncompatibleLibrary = errors.New("aether: 原生库与 Go 绑定的 ABI 不兼容")
+
+// ErrUnknownLibraryVersion 表示原生库的版本不在 ABI 兼容表中,无法确定是否兼容
+var ErrUnknownLibraryVersion = errors.New("aether: 原生库版本不在 ABI 兼容表中")
+
+// bindingABI 为 native.go 中 C 声明(结构体布局、错误码与函数签名)的 ABI 修订号
+//
+// 修改这些声明以适配新的原生库时必须递增,并在 abiTable 中加入对应的版本范围
+const bindingABI = 1
+
+// abiTable 记录各原生库版本对应的 ABI 修订号,版本范围为 [Min, Max)
+//
+// 原生库没有导出 ABI 查询函数,因此按 Version() 查表。只有表中记录了其他修订号的
+// 版本才被视为不兼容
+var abiTable = []struct {
+	Min, Max string
+	ABI      int
+}{
+	{"0.4.0", "0.5.0", 1},
+}
+
+var (
+	abiOnce sync.Once
+	abiErr  error
+)
+
+// CheckABI 检查链接或加载的原生库是否与本绑定的 ABI 兼容
+//
+// abiTable 记录的修订号与本绑定不同时返回包装了 ErrIncompatibleLibrary 的错误;
+// 版本不在表中时返回包装了 ErrUnknownLibraryVersion 的错误。第一次创建 Engine 时
+// 会自动执行此检查:不兼容时 panic,以免错误的结构体布局破坏内存;版本未知时只在
+// 标准错误输出警告。设置 AETHER_SKIP_ABI_CHECK=1 可以跳过
+func CheckABI() error {
+	if err := loadLibrary(); err != nil {
+		return err
+	}
+	return checkABIVersion(Version())
+}
+
+// checkABIVersion 按 abiTable 检查库版本 v
+func checkABIVersion(v string) error {
+	abi, ok := abiForVersion(v)
+	if !ok {
+		return fmt.Errorf("%w: 库版本 %s,本绑定已验证 %s", ErrUnknownLibraryVersion, v, supportedVersions())
+	}
+	if abi != bindingABI {
+		return fmt.Errorf("%w: 库版本 %s 的 ABI 修订号为 %d,本绑定为 %d,请使用支持 %s 的 aether-go",
+			ErrIncompatibleLibrary, v, abi, bindingABI, supportedVersions())
+	}
+	return nil
+}
+
+// mustLoadLibrary 确保库已加载且 ABI 没有已知的不兼容,失败时 panic
+//
+// 静态链接时总是能加载;-tags aether_dynamic 构建时,需要处理加载错误的程序
+// 应在创建 Engine 之前调用 LoadLibrary。库版本与 aether.lock 不一致时只警告
+func mustLoadLibrary() {
+	if err := loadLibrary(); err != nil {
+		panic(err)
+	}
+	abiOnce.Do(func() {
+		if err := CheckLibraryVersion(); err != nil {
+			fmt.Fprintf(os.Stderr, "警告: %v\n", err)
+		}
+		if os.Getenv("AETHER_SKIP_ABI_CHECK") != "" {
+			return
+		}
+		abiErr = CheckABI()
+		if errors.Is(abiErr, ErrUnknownLibraryVersion) {
+			fmt.Fprintf(os.Stderr, "警告: %v\n", abiErr)
+			abiErr = nil
+		}
+	})
+	if abiErr != nil {
+		panic(abiErr)
+	}
+}
+
+// abiForVersion 查找原生库版本对应的 ABI 修订号
+func abiForVersion(v string) (int, bool) {
+	ver, ok := parseVersion(v)
+	if !ok {
+		return 0, false
+	}
+	for _, r := range abiTable {
+		lo, _ := parseVersion(r.Min)
+		hi, _ := parseVersion(r.Max)
+		if compareVersion(ver, lo) >= 0 && compareVersion(ver, hi) < 0 {
+			return r.ABI, true
+		}
+	}
+	return 0, false
+}
+
+// supportedVersions 返回 abiTable 中与 bindingABI 兼容的版本范围
+func supportedVersions() string {
+	var ranges []string
+	for _, r := range abiTable {
+		if r.ABI == bindingABI {
+			ranges = append(ranges, fmt.Sprintf(">= %s, < %s", r.Min, r.Max))
+		}
+	}
+	return strings.Join(ranges, "; ")
+}
+
+// parseVersion 解析 X.Y.Z 形式的版本号,忽略 v 前缀与预发布后缀
+func parseVersion(v string) ([3]int, bool) {
+	var ver [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return ver, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return ver, false
+		}
+		ver[i] = n
+	}
+	return ver, true
+}
+
+// compareVersion 比较两个版本号
+func compareVersion(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
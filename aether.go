@@ -43,122 +43,76 @@
 //	fmt.Printf("Cache hits: %d\n", stats.Hits)
 package aether
 
-/*
-#cgo darwin,arm64 LDFLAGS: -L${SRCDIR}/lib -laether -ldl -lm -lpthread
-#cgo darwin,amd64 LDFLAGS: -L${SRCDIR}/lib -laether -ldl -lm -lpthread
-#cgo linux,arm64 LDFLAGS: -L${SRCDIR}/lib -laether -ldl -lm -lpthread
-#cgo linux,amd64 LDFLAGS: -L${SRCDIR}/lib -laether -ldl -lm -lpthread
-#cgo windows,amd64 LDFLAGS: -L${SRCDIR}/lib -laether -ldl -lm -lpthread
-#cgo darwin LDFLAGS: -framework Security -framework CoreFoundation
-#include <stdlib.h>
-
-typedef struct AetherHandle AetherHandle;
-
-typedef enum AetherErrorCode {
-    Success = 0,
-    ParseError = 1,
-    RuntimeError = 2,
-    NullPointer = 3,
-    Panic = 4,
-    InvalidJSON = 5,
-    VariableNotFound = 6,
-} AetherErrorCode;
-
-typedef struct AetherLimits {
-    int max_steps;
-    int max_recursion_depth;
-    int max_duration_ms;
-} AetherLimits;
-
-typedef struct AetherCacheStats {
-    int hits;
-    int misses;
-    int size;
-} AetherCacheStats;
-
-AetherHandle* aether_new();
-AetherHandle* aether_new_with_permissions();
-int aether_eval(AetherHandle* handle, const char* code, char** result, char** error);
-const char* aether_version();
-void aether_free(AetherHandle* handle);
-void aether_free_string(char* s);
-
-// 增强的 API
-int aether_set_global(AetherHandle* handle, const char* name, const char* value_json);
-int aether_get_global(AetherHandle* handle, const char* name, char** value_json);
-void aether_reset_env(AetherHandle* handle);
-
-int aether_take_trace(AetherHandle* handle, char** trace_json);
-void aether_clear_trace(AetherHandle* handle);
-int aether_trace_records(AetherHandle* handle, char** trace_json);
-int aether_trace_stats(AetherHandle* handle, char** stats_json);
-
-void aether_set_limits(AetherHandle* handle, const AetherLimits* limits);
-void aether_get_limits(AetherHandle* handle, AetherLimits* limits);
-
-void aether_clear_cache(AetherHandle* handle);
-void aether_cache_stats(AetherHandle* handle, AetherCacheStats* stats);
-
-void aether_set_optimization(AetherHandle* handle, int constant_folding, int dead_code, int tail_recursion);
-*/
-import "C"
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"runtime"
 	"sync"
-	"unsafe"
+	"time"
+
+	"github.com/xiaozuhui/aether-go/aethertypes"
 )
 
 // Engine 表示一个线程安全的 Aether DSL 引擎
 type Engine struct {
-	handle *C.AetherHandle
+	handle *handle
 	mu     sync.RWMutex
+
+	obsMu     sync.RWMutex
+	observers []EvalObserver
+
+	// 通过 SetGlobal 设置的变量名,由 mu 保护
+	globals map[string]struct{}
+	// 缓存容量限制,未设置 CacheConfig 时为 nil,由 mu 保护
+	cache *programCache
+	// 通过 ApplyOptimization 设置的优化配置,未设置时为 nil,由 mu 保护
+	opt *Optimization
+
+	errMu        sync.Mutex
+	recentErrors []ErrorRecord
+
+	storeMu sync.Mutex
+	store   CacheStore
+	stored  map[string]struct{}
 }
 
 // Limits 控制执行约束
-type Limits struct {
-	// 最大执行步数 (-1 表示无限制)
-	MaxSteps int
-	// 最大递归深度 (-1 表示无限制)
-	MaxRecursionDepth int
-	// 最大执行时间(毫秒, -1 表示无限制)
-	MaxDurationMs int
-}
+type Limits = aethertypes.Limits
 
 // CacheStats 表示缓存统计信息
-type CacheStats struct {
-	Hits   int
-	Misses int
-	Size   int
-}
+type CacheStats = aethertypes.CacheStats
 
 // TraceStats 表示追踪统计信息
-type TraceStats struct {
-	TotalEntries int            `json:"total_entries"`
-	ByLevel      map[string]int `json:"by_level"`
-	ByCategory   map[string]int `json:"by_category"`
-	BufferSize   int            `json:"buffer_size"`
-	BufferFull   bool           `json:"buffer_full"`
-}
+type TraceStats = aethertypes.TraceStats
 
 // TraceEntry 表示单个追踪条目
-type TraceEntry struct {
-	Level     string   `json:"level"`
-	Category  string   `json:"category"`
-	Timestamp int64    `json:"timestamp"`
-	Values    []string `json:"values"`
-	Label     *string  `json:"label,omitempty"`
+type TraceEntry = aethertypes.TraceEntry
+
+// EvalEvent 描述一次 Eval 调用的结果
+type EvalEvent struct {
+	// Code 为本次执行的错误代码,成功时为 CodeSuccess
+	Code ErrorCode
+	// Duration 为本次执行的耗时
+	Duration time.Duration
+	// Err 为本次执行返回的错误
+	Err error
+	// Steps 为本次执行消耗的步数,原生库不报告步数时为 -1
+	//
+	// 当前的 C API 没有步数计数,Engine 产生的事件中总是 -1
+	Steps int
 }
 
+// EvalObserver 在每次 Eval 完成后被调用
+type EvalObserver func(EvalEvent)
+
 // New 创建一个新的 Aether 引擎实例,默认禁用 IO 权限
 //
 // 出于安全考虑,作为嵌入式 DSL 使用时,IO 操作默认是禁用的。
 // 如需启用 IO 操作,请使用 NewWithPermissions()。
 func New() *Engine {
+	mustLoadLibrary()
 	e := &Engine{
-		handle: C.aether_new(),
+		handle: openHandle(false),
 	}
 	runtime.SetFinalizer(e, (*Engine).Close)
 	return e
@@ -168,8 +122,9 @@ func New() *Engine {
 //
 // 警告: 仅当你信任要执行的脚本时才使用,因为它允许文件系统和网络操作。
 func NewWithPermissions() *Engine {
+	mustLoadLibrary()
 	e := &Engine{
-		handle: C.aether_new_with_permissions(),
+		handle: openHandle(true),
 	}
 	runtime.SetFinalizer(e, (*Engine).Close)
 	return e
@@ -181,36 +136,60 @@ func NewWithPermissions() *Engine {
 //
 // 如果代码解析失败或遇到运行时错误,则返回错误
 func (e *Engine) Eval(code string) (string, error) {
+	start := time.Now()
+	result, err := e.eval(code)
+	ev := EvalEvent{Code: CodeOf(err), Duration: time.Since(start), Err: err, Steps: -1}
+	if err != nil {
+		e.recordError(ev)
+		if ev.Code == CodeParseError {
+			e.forget(code)
+		}
+	} else {
+		e.persist(code)
+	}
+	e.notify(ev)
+	return result, err
+}
+
+// eval 在持有锁的情况下调用原生库执行代码
+func (e *Engine) eval(code string) (string, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	if e.handle == nil {
-		return "", errors.New("aether: 引擎已关闭")
+		return "", ErrClosed
 	}
+	return e.evalLocked(code)
+}
 
-	cCode := C.CString(code)
-	defer C.free(unsafe.Pointer(cCode))
+// evalLocked 执行代码并执行缓存限制,调用方必须持有 e.mu 的写锁
+func (e *Engine) evalLocked(code string) (string, error) {
+	if e.cache != nil && e.cache.admit(code, time.Now()) {
+		e.rebuildCacheLocked(code)
+	}
+	return e.handle.eval(code)
+}
 
-	var result *C.char
-	var errorMsg *C.char
+// AddEvalObserver 注册一个在每次 Eval 完成后调用的观察者
+//
+// 观察者在引擎锁之外同步调用,可以安全地回调引擎的其他方法。
+// 此方法是线程安全的
+func (e *Engine) AddEvalObserver(fn EvalObserver) {
+	e.obsMu.Lock()
+	defer e.obsMu.Unlock()
 
-	status := C.aether_eval(e.handle, cCode, &result, &errorMsg)
+	e.observers = append(e.observers, fn)
+}
 
-	if status != C.Success {
-		if errorMsg != nil {
-			defer C.aether_free_string(errorMsg)
-			errStr := C.GoString(errorMsg)
-			return "", fmt.Errorf("aether: %s", errStr)
-		}
-		return "", errors.New("aether: 未知错误")
-	}
+// notify 将执行事件分发给所有观察者
+func (e *Engine) notify(ev EvalEvent) {
+	e.obsMu.RLock()
+	observers := e.observers
+	e.obsMu.RUnlock()
 
-	if result != nil {
-		defer C.aether_free_string(result)
-		return C.GoString(result), nil
+	for _, fn := range observers {
+		fn(ev)
 	}
-
-	return "", nil
 }
 
 // SetGlobal 从 Go 代码设置全局变量
@@ -222,7 +201,7 @@ func (e *Engine) SetGlobal(name string, value interface{}) error {
 	defer e.mu.Unlock()
 
 	if e.handle == nil {
-		return errors.New("aether: 引擎已关闭")
+		return ErrClosed
 	}
 
 	jsonData, err := json.Marshal(value)
@@ -230,17 +209,16 @@ func (e *Engine) SetGlobal(name string, value interface{}) error {
 		return fmt.Errorf("无法将值序列化为 JSON: %w", err)
 	}
 
-	cName := C.CString(name)
-	defer C.free(unsafe.Pointer(cName))
-
-	cValue := C.CString(string(jsonData))
-	defer C.free(unsafe.Pointer(cValue))
-
-	status := C.aether_set_global(e.handle, cName, cValue)
-	if status != C.Success {
+	status := e.handle.setGlobal(name, string(jsonData))
+	if status != CodeSuccess {
 		return fmt.Errorf("设置全局变量 '%s' 失败 (错误代码: %d)", name, status)
 	}
 
+	if e.globals == nil {
+		e.globals = make(map[string]struct{})
+	}
+	e.globals[name] = struct{}{}
+
 	return nil
 }
 
@@ -253,21 +231,16 @@ func (e *Engine) GetGlobal(name string) (interface{}, error) {
 	defer e.mu.RUnlock()
 
 	if e.handle == nil {
-		return nil, errors.New("aether: 引擎已关闭")
+		return nil, ErrClosed
 	}
 
-	cName := C.CString(name)
-	defer C.free(unsafe.Pointer(cName))
-
-	var valueJSON *C.char
-	status := C.aether_get_global(e.handle, cName, &valueJSON)
-	if status != C.Success {
+	valueJSON, status := e.handle.getGlobal(name)
+	if status != CodeSuccess {
 		return nil, fmt.Errorf("变量未找到: %s (错误代码: %d)", name, status)
 	}
-	defer C.aether_free_string(valueJSON)
 
 	var result interface{}
-	err := json.Unmarshal([]byte(C.GoString(valueJSON)), &result)
+	err := json.Unmarshal([]byte(valueJSON), &result)
 	if err != nil {
 		return nil, fmt.Errorf("解析变量值失败: %w", err)
 	}
@@ -283,10 +256,11 @@ func (e *Engine) ResetEnv() error {
 	defer e.mu.Unlock()
 
 	if e.handle == nil {
-		return errors.New("aether: 引擎已关闭")
+		return ErrClosed
 	}
 
-	C.aether_reset_env(e.handle)
+	e.handle.resetEnv()
+	e.globals = nil
 	return nil
 }
 
@@ -298,18 +272,16 @@ func (e *Engine) TakeTrace() ([]string, error) {
 	defer e.mu.RUnlock()
 
 	if e.handle == nil {
-		return nil, errors.New("aether: 引擎已关闭")
+		return nil, ErrClosed
 	}
 
-	var traceJSON *C.char
-	status := C.aether_take_trace(e.handle, &traceJSON)
-	if status != C.Success {
+	traceJSON, status := e.handle.takeTrace()
+	if status != CodeSuccess {
 		return nil, fmt.Errorf("获取追踪失败 (错误代码: %d)", status)
 	}
-	defer C.aether_free_string(traceJSON)
 
 	var traces []string
-	err := json.Unmarshal([]byte(C.GoString(traceJSON)), &traces)
+	err := json.Unmarshal([]byte(traceJSON), &traces)
 	if err != nil {
 		return nil, fmt.Errorf("解析追踪 JSON 失败: %w", err)
 	}
@@ -325,18 +297,16 @@ func (e *Engine) TraceRecords() ([]TraceEntry, error) {
 	defer e.mu.RUnlock()
 
 	if e.handle == nil {
-		return nil, errors.New("aether: 引擎已关闭")
+		return nil, ErrClosed
 	}
 
-	var traceJSON *C.char
-	status := C.aether_trace_records(e.handle, &traceJSON)
-	if status != C.Success {
+	traceJSON, status := e.handle.traceRecords()
+	if status != CodeSuccess {
 		return nil, fmt.Errorf("获取追踪记录失败 (错误代码: %d)", status)
 	}
-	defer C.aether_free_string(traceJSON)
 
 	var entries []TraceEntry
-	err := json.Unmarshal([]byte(C.GoString(traceJSON)), &entries)
+	err := json.Unmarshal([]byte(traceJSON), &entries)
 	if err != nil {
 		return nil, fmt.Errorf("解析追踪记录 JSON 失败: %w", err)
 	}
@@ -352,18 +322,16 @@ func (e *Engine) TraceStats() (*TraceStats, error) {
 	defer e.mu.RUnlock()
 
 	if e.handle == nil {
-		return nil, errors.New("aether: 引擎已关闭")
+		return nil, ErrClosed
 	}
 
-	var statsJSON *C.char
-	status := C.aether_trace_stats(e.handle, &statsJSON)
-	if status != C.Success {
+	statsJSON, status := e.handle.traceStats()
+	if status != CodeSuccess {
 		return nil, fmt.Errorf("获取追踪统计失败 (错误代码: %d)", status)
 	}
-	defer C.aether_free_string(statsJSON)
 
 	var stats TraceStats
-	err := json.Unmarshal([]byte(C.GoString(statsJSON)), &stats)
+	err := json.Unmarshal([]byte(statsJSON), &stats)
 	if err != nil {
 		return nil, fmt.Errorf("解析追踪统计 JSON 失败: %w", err)
 	}
@@ -379,10 +347,10 @@ func (e *Engine) ClearTrace() error {
 	defer e.mu.Unlock()
 
 	if e.handle == nil {
-		return errors.New("aether: 引擎已关闭")
+		return ErrClosed
 	}
 
-	C.aether_clear_trace(e.handle)
+	e.handle.clearTrace()
 	return nil
 }
 
@@ -394,16 +362,10 @@ func (e *Engine) SetExecutionLimits(limits Limits) error {
 	defer e.mu.Unlock()
 
 	if e.handle == nil {
-		return errors.New("aether: 引擎已关闭")
-	}
-
-	cLimits := C.AetherLimits{
-		max_steps:           C.int(limits.MaxSteps),
-		max_recursion_depth: C.int(limits.MaxRecursionDepth),
-		max_duration_ms:      C.int(limits.MaxDurationMs),
+		return ErrClosed
 	}
 
-	C.aether_set_limits(e.handle, &cLimits)
+	e.handle.setLimits(limits)
 	return nil
 }
 
@@ -415,17 +377,11 @@ func (e *Engine) GetExecutionLimits() (*Limits, error) {
 	defer e.mu.RUnlock()
 
 	if e.handle == nil {
-		return nil, errors.New("aether: 引擎已关闭")
+		return nil, ErrClosed
 	}
 
-	var cLimits C.AetherLimits
-	C.aether_get_limits(e.handle, &cLimits)
-
-	return &Limits{
-		MaxSteps:          int(cLimits.max_steps),
-		MaxRecursionDepth: int(cLimits.max_recursion_depth),
-		MaxDurationMs:     int(cLimits.max_duration_ms),
-	}, nil
+	limits := e.handle.getLimits()
+	return &limits, nil
 }
 
 // ClearCache 清除 AST 缓存
@@ -436,13 +392,56 @@ func (e *Engine) ClearCache() error {
 	defer e.mu.Unlock()
 
 	if e.handle == nil {
-		return errors.New("aether: 引擎已关闭")
+		return ErrClosed
 	}
 
-	C.aether_clear_cache(e.handle)
+	e.handle.clearCache()
+	if e.cache != nil {
+		e.cache.clear()
+	}
 	return nil
 }
 
+// SetCacheConfig 设置 AST 缓存的容量与淘汰策略
+//
+// 超出容量或过期时按 cfg.Policy 淘汰条目,CacheStats 的 Evictions 与 Bytes 开始统计;
+// 传入零值 CacheConfig{} 恢复为原生库的无界缓存。两种情况下都会清空现有缓存。
+// 此方法是线程安全的
+func (e *Engine) SetCacheConfig(cfg CacheConfig) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.handle == nil {
+		return ErrClosed
+	}
+
+	e.handle.clearCache()
+	if cfg == (CacheConfig{}) {
+		e.cache = nil
+	} else {
+		e.cache = newProgramCache(cfg)
+	}
+	return nil
+}
+
+// GetCacheConfig 获取当前的缓存配置
+//
+// 此方法是线程安全的
+func (e *Engine) GetCacheConfig() (*CacheConfig, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.handle == nil {
+		return nil, ErrClosed
+	}
+
+	if e.cache == nil {
+		return &CacheConfig{}, nil
+	}
+	cfg := e.cache.cfg
+	return &cfg, nil
+}
+
 // CacheStats 返回缓存统计信息
 //
 // 此方法是线程安全的
@@ -451,50 +450,71 @@ func (e *Engine) CacheStats() (*CacheStats, error) {
 	defer e.mu.RUnlock()
 
 	if e.handle == nil {
-		return nil, errors.New("aether: 引擎已关闭")
+		return nil, ErrClosed
 	}
 
-	var cStats C.AetherCacheStats
-	C.aether_cache_stats(e.handle, &cStats)
-
-	return &CacheStats{
-		Hits:   int(cStats.hits),
-		Misses: int(cStats.misses),
-		Size:   int(cStats.size),
-	}, nil
+	stats := e.handle.cacheStats()
+	if e.cache != nil {
+		stats.Misses -= e.cache.reparsed
+		stats.Evictions = e.cache.evictions
+		stats.Bytes = e.cache.bytes
+	}
+	return &stats, nil
 }
 
 // SetOptimization 设置优化选项
 //
 // 此方法是线程安全的
 func (e *Engine) SetOptimization(constantFolding, deadCodeElimination, tailRecursion bool) error {
+	return e.ApplyOptimization(Optimization{
+		ConstantFolding:     constantFolding,
+		DeadCodeElimination: deadCodeElimination,
+		TailRecursion:       tailRecursion,
+	})
+}
+
+// ApplyOptimization 设置优化选项,可以使用 OptNone、OptDefault、OptAggressive 等预设
+//
+// 此方法是线程安全的
+func (e *Engine) ApplyOptimization(opt Optimization) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	if e.handle == nil {
-		return errors.New("aether: 引擎已关闭")
+		return ErrClosed
 	}
 
-	cf := 0
-	if constantFolding {
-		cf = 1
-	}
-	dc := 0
-	if deadCodeElimination {
-		dc = 1
-	}
-	tr := 0
-	if tailRecursion {
-		tr = 1
+	e.handle.setOptimization(opt)
+	e.opt = &opt
+	return nil
+}
+
+// GetOptimization 获取通过 ApplyOptimization 或 SetOptimization 设置的优化选项
+//
+// 原生库无法读取优化选项,未设置时返回 ErrOptimizationNotSet,此时使用原生库的默认配置。
+//
+// 此方法是线程安全的
+func (e *Engine) GetOptimization() (*Optimization, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.handle == nil {
+		return nil, ErrClosed
 	}
 
-	C.aether_set_optimization(e.handle, C.int(cf), C.int(dc), C.int(tr))
-	return nil
+	if e.opt == nil {
+		return nil, ErrOptimizationNotSet
+	}
+	opt := *e.opt
+	return &opt, nil
 }
 
 // Version 返回 Aether 引擎的版本字符串
 func Version() string {
-	return C.GoString(C.aether_version())
+	if err := loadLibrary(); err != nil {
+		panic(err)
+	}
+	return nativeVersion()
 }
 
 // Close 释放与 Aether 引擎关联的资源
@@ -506,7 +526,7 @@ func (e *Engine) Close() {
 	defer e.mu.Unlock()
 
 	if e.handle != nil {
-		C.aether_free(e.handle)
+		closeHandle(e.handle)
 		e.handle = nil
 	}
 }
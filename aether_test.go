@@ -1,8 +1,25 @@
 package aether
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"time"
+
+	"github.com/xiaozuhui/aether-go/internal/fetch"
 )
 
 // TestNew 测试引擎创建
@@ -221,6 +238,243 @@ func TestCacheStats(t *testing.T) {
 	}
 }
 
+// TestCacheConfig 测试缓存容量限制与淘汰后原生缓存与索引一致
+func TestCacheConfig(t *testing.T) {
+	engine := New()
+	defer engine.Close()
+
+	err := engine.SetCacheConfig(CacheConfig{MaxEntries: 2, Policy: LRU})
+	if err != nil {
+		t.Fatalf("SetCacheConfig 失败: %v", err)
+	}
+
+	// (3 + 3) 加入时淘汰最久未使用的 (2 + 2),(1 + 1) 被重新解析,之后仍然命中
+	for _, code := range []string{"(1 + 1)", "(2 + 2)", "(1 + 1)", "(3 + 3)", "(1 + 1)"} {
+		if _, err := engine.Eval(code); err != nil {
+			t.Fatalf("Eval 失败: %v", err)
+		}
+	}
+
+	stats, err := engine.CacheStats()
+	if err != nil {
+		t.Fatalf("CacheStats 失败: %v", err)
+	}
+	want := CacheStats{Hits: 2, Misses: 3, Size: 2, Evictions: 1, Bytes: 14}
+	if *stats != want {
+		t.Errorf("期望 %+v,得到 %+v", want, *stats)
+	}
+
+	cfg, err := engine.GetCacheConfig()
+	if err != nil || cfg.MaxEntries != 2 || cfg.Policy != LRU {
+		t.Errorf("GetCacheConfig 不正确: %+v, %v", cfg, err)
+	}
+	if limits, _ := engine.GetExecutionLimits(); limits.MaxSteps != -1 {
+		t.Errorf("重新解析后期望恢复执行限制,得到 %+v", limits)
+	}
+
+	engine.ClearCache()
+	if stats, _ := engine.CacheStats(); stats.Size != 0 || stats.Bytes != 0 {
+		t.Errorf("ClearCache 后期望空缓存,得到 %+v", stats)
+	}
+}
+
+// TestProgramCachePolicy 测试 LRU、LFU、MaxBytes 与 TTL 的淘汰
+func TestProgramCachePolicy(t *testing.T) {
+	now := time.Unix(0, 0)
+	tick := func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	has := func(c *programCache, code string) bool {
+		_, ok := c.entries[programKey(sha256.Sum256([]byte(code)))]
+		return ok
+	}
+
+	// LRU: 淘汰最久未使用的条目
+	c := newProgramCache(CacheConfig{MaxEntries: 2, Policy: LRU})
+	if c.admit("A", tick()) || c.admit("B", tick()) || c.admit("A", tick()) {
+		t.Error("未超出容量时不应淘汰")
+	}
+	if !c.admit("C", tick()) || has(c, "B") || !has(c, "A") || !has(c, "C") {
+		t.Errorf("LRU 期望淘汰 B,得到 %v", c.survivors(""))
+	}
+
+	// LFU: 淘汰使用次数最少的条目
+	c = newProgramCache(CacheConfig{MaxEntries: 2, Policy: LFU})
+	c.admit("A", tick())
+	c.admit("A", tick())
+	c.admit("B", tick())
+	if !c.admit("C", tick()) || has(c, "B") || !has(c, "A") {
+		t.Errorf("LFU 期望淘汰 B,得到 %v", c.survivors(""))
+	}
+	if c.evictions != 1 || c.bytes != 2 {
+		t.Errorf("统计不正确: evictions=%d bytes=%d", c.evictions, c.bytes)
+	}
+
+	// MaxBytes: 淘汰到不超过字节数,新条目本身保留
+	c = newProgramCache(CacheConfig{MaxBytes: 5})
+	c.admit("AA", tick())
+	c.admit("BB", tick())
+	if !c.admit("CCC", tick()) || c.bytes != 5 || has(c, "AA") {
+		t.Errorf("MaxBytes 期望淘汰 AA,得到 bytes=%d %v", c.bytes, c.survivors(""))
+	}
+
+	// TTL: 从条目加入时计时,过期的条目即使命中也会被替换
+	c = newProgramCache(CacheConfig{TTL: 2 * time.Second})
+	c.admit("A", tick())
+	if c.admit("A", now.Add(time.Second)) {
+		t.Error("未过期时不应淘汰")
+	}
+	if !c.admit("A", now.Add(3*time.Second)) || c.evictions != 1 || len(c.entries) != 1 {
+		t.Errorf("过期后期望淘汰: evictions=%d entries=%d", c.evictions, len(c.entries))
+	}
+}
+
+// TestCacheStore 测试持久化脚本缓存
+func TestCacheStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDirCacheStore(dir)
+	if err != nil {
+		t.Fatalf("NewDirCacheStore 失败: %v", err)
+	}
+
+	// 其他版本的条目应在加载时被删除
+	stale := NewCachedProgram("(9 + 9)")
+	stale.Version = "0.0.0-old"
+	if err := store.Save(stale); err != nil {
+		t.Fatalf("Save 失败: %v", err)
+	}
+	// 不是本存储创建的目录不应被删除
+	foreign := filepath.Join(dir, "user-data")
+	if err := os.MkdirAll(foreign, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := New()
+	engine.SetCacheStore(store)
+	code := "Set X 10\n(X + 20)"
+	engine.Eval(code)
+	engine.Eval(code)
+	engine.Eval("(1 +")
+	engine.Close()
+
+	programs, err := store.Load(Version())
+	if err != nil {
+		t.Fatalf("Load 失败: %v", err)
+	}
+	if len(programs) != 1 || programs[0].Source != code {
+		t.Fatalf("期望 1 个脚本,得到 %+v", programs)
+	}
+	if _, err := os.Stat(filepath.Join(dir, sanitizeVersion(stale.Version))); !os.IsNotExist(err) {
+		t.Error("期望其他版本的缓存被删除")
+	}
+	if _, err := os.Stat(foreign); err != nil {
+		t.Errorf("期望保留不是本存储创建的目录: %v", err)
+	}
+
+	// 重启后预热
+	engine = New()
+	defer engine.Close()
+	engine.SetExecutionLimits(Limits{MaxSteps: 100, MaxRecursionDepth: -1, MaxDurationMs: -1})
+
+	n, err := WarmCache(engine, store)
+	if err != nil || n != 1 {
+		t.Fatalf("WarmCache 失败: %d, %v", n, err)
+	}
+	if _, err := engine.GetGlobal("X"); err == nil {
+		t.Error("预热不应执行脚本")
+	}
+	if limits, _ := engine.GetExecutionLimits(); limits.MaxSteps != 100 {
+		t.Errorf("预热后期望恢复执行限制,得到 %+v", limits)
+	}
+
+	if _, err := engine.Eval(code); err != nil {
+		t.Fatalf("Eval 失败: %v", err)
+	}
+	stats, _ := engine.CacheStats()
+	if stats.Hits != 1 {
+		t.Errorf("预热后期望缓存命中,得到 %+v", stats)
+	}
+}
+
+// TestCacheStoreParseError 测试解析失败的持久化脚本会从存储中删除
+func TestCacheStoreParseError(t *testing.T) {
+	store, err := NewDirCacheStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirCacheStore 失败: %v", err)
+	}
+	bad := NewCachedProgram("(1 +")
+	if err := store.Save(bad); err != nil {
+		t.Fatalf("Save 失败: %v", err)
+	}
+
+	engine := New()
+	defer engine.Close()
+	engine.SetCacheStore(store)
+	if _, err := WarmCache(engine, store); err != nil {
+		t.Fatalf("WarmCache 失败: %v", err)
+	}
+
+	// 预热时没有被识别的解析错误在执行时删除
+	if _, err := engine.Eval(bad.Source); CodeOf(err) != CodeParseError {
+		t.Fatalf("期望 parse_error,得到 %v", err)
+	}
+	programs, err := store.Load(Version())
+	if err != nil {
+		t.Fatalf("Load 失败: %v", err)
+	}
+	if len(programs) != 0 {
+		t.Errorf("期望解析失败的脚本被删除,得到 %+v", programs)
+	}
+}
+
+// TestWarmCacheConcurrent 测试预热期间其他调用不会看到 MaxSteps = 0
+func TestWarmCacheConcurrent(t *testing.T) {
+	store, err := NewDirCacheStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirCacheStore 失败: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		if err := store.Save(NewCachedProgram(fmt.Sprintf("(%d + 1)", i))); err != nil {
+			t.Fatalf("Save 失败: %v", err)
+		}
+	}
+
+	engine := New()
+	defer engine.Close()
+
+	done := make(chan struct{})
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if _, err := engine.Eval("1"); err != nil {
+				errs <- err
+				return
+			}
+			if limits, err := engine.GetExecutionLimits(); err != nil || limits.MaxSteps == 0 {
+				errs <- fmt.Errorf("预热期间读取到执行限制 %+v, %v", limits, err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		if _, err := WarmCache(engine, store); err != nil {
+			t.Fatalf("WarmCache 失败: %v", err)
+		}
+	}
+	close(done)
+	if err := <-errs; err != nil {
+		t.Error(err)
+	}
+}
+
 // TestSetOptimization 测试优化设置
 func TestSetOptimization(t *testing.T) {
 	engine := New()
@@ -238,6 +492,31 @@ func TestSetOptimization(t *testing.T) {
 	}
 }
 
+// TestOptimizationProfile 测试优化配置的读取
+func TestOptimizationProfile(t *testing.T) {
+	engine := New()
+	defer engine.Close()
+
+	opt, err := engine.GetOptimization()
+	if !errors.Is(err, ErrOptimizationNotSet) || opt != nil {
+		t.Fatalf("新引擎期望 ErrOptimizationNotSet,得到 %+v, %v", opt, err)
+	}
+
+	if err := engine.ApplyOptimization(OptAggressive); err != nil {
+		t.Fatalf("ApplyOptimization 失败: %v", err)
+	}
+	opt, err = engine.GetOptimization()
+	if err != nil || *opt != OptAggressive || opt.Profile() != "aggressive" {
+		t.Errorf("ApplyOptimization 后配置不正确: %+v, %v", opt, err)
+	}
+
+	engine.SetOptimization(true, false, false)
+	opt, _ = engine.GetOptimization()
+	if !opt.ConstantFolding || opt.DeadCodeElimination || opt.Profile() != "custom" {
+		t.Errorf("SetOptimization 后配置不正确: %+v", opt)
+	}
+}
+
 // TestThreadSafety 测试并发执行
 func TestThreadSafety(t *testing.T) {
 	engine := New()
@@ -286,6 +565,168 @@ func TestCloseMultipleTimes(t *testing.T) {
 	engine.Close() // 不应 panic
 }
 
+// TestEvalErrorCode 测试 Eval 错误携带错误代码
+func TestEvalErrorCode(t *testing.T) {
+	engine := New()
+	defer engine.Close()
+
+	_, err := engine.Eval("UNDEFINED_VAR")
+	var aerr *Error
+	if !errors.As(err, &aerr) {
+		t.Fatalf("期望 *Error,得到 %T: %v", err, err)
+	}
+	if aerr.Code != CodeRuntimeError {
+		t.Errorf("期望 %v,得到 %v", CodeRuntimeError, aerr.Code)
+	}
+
+	engine.Close()
+	_, err = engine.Eval("(1 + 2)")
+	if !errors.Is(err, ErrClosed) || CodeOf(err) != CodeNullPointer {
+		t.Errorf("Close 后期望 ErrClosed,得到 %v", err)
+	}
+}
+
+// TestEvalObserver 测试执行观察者
+func TestEvalObserver(t *testing.T) {
+	engine := New()
+	defer engine.Close()
+
+	var events []EvalEvent
+	engine.AddEvalObserver(func(ev EvalEvent) {
+		// 观察者中可以回调引擎
+		if _, err := engine.CacheStats(); err != nil {
+			t.Errorf("观察者中 CacheStats 失败: %v", err)
+		}
+		events = append(events, ev)
+	})
+
+	engine.Eval("(1 + 2)")
+	engine.Eval("UNDEFINED_VAR")
+
+	if len(events) != 2 {
+		t.Fatalf("期望 2 个事件,得到 %d", len(events))
+	}
+	if events[0].Code != CodeSuccess || events[0].Err != nil {
+		t.Errorf("第一个事件期望成功,得到 %+v", events[0])
+	}
+	if events[1].Code != CodeRuntimeError || events[1].Err == nil {
+		t.Errorf("第二个事件期望运行时错误,得到 %+v", events[1])
+	}
+}
+
+// TestPool 测试引擎池
+func TestPool(t *testing.T) {
+	pool := NewPool(2, nil)
+	defer pool.Close()
+
+	result, err := pool.Eval("(1 + 2)")
+	if err != nil || result != "3" {
+		t.Fatalf("Pool.Eval 失败: %v, %s", err, result)
+	}
+
+	a, _ := pool.Acquire(context.Background())
+	b, _ := pool.Acquire(context.Background())
+	if stats := pool.Stats(); stats.InUse != 2 || stats.Idle != 0 {
+		t.Errorf("期望 2 个引擎被借出,得到 %+v", stats)
+	}
+
+	// 无空闲引擎时应等待到 ctx 结束
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("期望 DeadlineExceeded,得到 %v", err)
+	}
+
+	if err := pool.Release(a); err != nil {
+		t.Fatalf("Release 失败: %v", err)
+	}
+	if err := pool.Release(b); err != nil {
+		t.Fatalf("Release 失败: %v", err)
+	}
+
+	// 重复归还与归还池外的引擎都应被拒绝,且不会阻塞
+	if err := pool.Release(a); !errors.Is(err, ErrNotLent) {
+		t.Errorf("重复归还期望 ErrNotLent,得到 %v", err)
+	}
+	other := New()
+	defer other.Close()
+	if err := pool.Release(other); !errors.Is(err, ErrNotLent) {
+		t.Errorf("归还池外引擎期望 ErrNotLent,得到 %v", err)
+	}
+
+	stats := pool.Stats()
+	if stats.InUse != 0 || stats.Acquired != 3 || stats.Waits != 1 {
+		t.Errorf("统计不正确: %+v", stats)
+	}
+
+	pool.Close()
+	if _, err := pool.Eval("(1 + 2)"); !errors.Is(err, ErrPoolClosed) || CodeOf(err) != CodeNullPointer {
+		t.Errorf("Close 后期望 ErrPoolClosed (null_pointer),得到 %v", err)
+	}
+}
+
+// TestDebugHandler 测试诊断页面
+func TestDebugHandler(t *testing.T) {
+	engine := New()
+	defer engine.Close()
+
+	engine.SetGlobal("name", "Alice")
+	engine.Eval("UNDEFINED_VAR")
+
+	closed := New()
+	closed.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/aether", DebugHandler(engine, closed))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/debug/aether?format=json")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var data struct {
+		Version string           `json:"version"`
+		Engines []EngineSnapshot `json:"engines"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		t.Fatalf("解析 JSON 失败: %v", err)
+	}
+	if data.Version != Version() || len(data.Engines) != 2 {
+		t.Fatalf("响应不正确: %+v", data)
+	}
+
+	snap := data.Engines[0]
+	if snap.Limits == nil || snap.Cache == nil || snap.Trace == nil {
+		t.Errorf("缺少统计信息: %+v", snap)
+	}
+	if len(snap.Globals) != 1 || snap.Globals[0] != "name" {
+		t.Errorf("期望全局变量 [name],得到 %v", snap.Globals)
+	}
+	if len(snap.RecentErrors) != 1 || snap.RecentErrors[0].Code != CodeRuntimeError {
+		t.Errorf("期望 1 个运行时错误,得到 %+v", snap.RecentErrors)
+	}
+	if !data.Engines[1].Closed {
+		t.Error("期望第二个引擎为已关闭状态")
+	}
+
+	resp, err = http.Get(srv.URL + "/debug/aether")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("期望 HTML,得到 %s", ct)
+	}
+
+	PublishExpvar("aether_test", engine)
+	if v := expvar.Get("aether_test"); v == nil || !strings.Contains(v.String(), `"globals":["name"]`) {
+		t.Errorf("expvar 内容不正确: %v", v)
+	}
+}
+
 // BenchmarkBasicEval 基准测试:基本执行
 func BenchmarkBasicEval(b *testing.B) {
 	engine := New()
@@ -342,3 +783,587 @@ func BenchmarkSetGetGlobal(b *testing.B) {
 		}
 	}
 }
+
+// TestCheckLibraryVersion 测试库版本与 aether.lock 记录的版本的比较
+func TestCheckLibraryVersion(t *testing.T) {
+	dir := t.TempDir()
+	if err := checkLibraryVersion(dir); err != nil {
+		t.Fatalf("没有锁文件时不应报错: %v", err)
+	}
+
+	for _, tt := range []struct {
+		locked string
+		match  bool
+	}{
+		{"latest", true},
+		{"v" + Version(), true},
+		{"v999.0.0", false},
+	} {
+		if err := fetch.WriteLock(dir, &fetch.Lock{Version: tt.locked}); err != nil {
+			t.Fatal(err)
+		}
+		err := checkLibraryVersion(dir)
+		if tt.match && err != nil {
+			t.Errorf("%q: 不应报错: %v", tt.locked, err)
+		}
+		if !tt.match && !errors.Is(err, ErrLibraryVersionMismatch) {
+			t.Errorf("%q: 期望 ErrLibraryVersionMismatch,得到 %v", tt.locked, err)
+		}
+	}
+}
+
+// TestInspectLibrary 测试库文件信息与统一的查找规则
+func TestInspectLibrary(t *testing.T) {
+	info, err := InspectLibrary()
+	if err != nil {
+		t.Fatalf("InspectLibrary 失败: %v", err)
+	}
+	if !info.Exists || info.Source != LibrarySourceModule || len(info.SHA256) != 64 || info.LinkedVersion != Version() {
+		t.Errorf("库信息不正确: %+v", info)
+	}
+	if len(info.MissingSymbols) != 0 || len(info.Symbols) != len(abiSymbols) {
+		t.Errorf("缺少 ABI 符号: %v", info.MissingSymbols)
+	}
+
+	// AETHER_LIB_DIR 对所有查找函数都生效
+	libDir := t.TempDir()
+	t.Setenv("AETHER_LIB_DIR", libDir)
+	info, err = InspectLibrary()
+	if err == nil || info.Exists || info.Source != LibrarySourceEnv || info.Dir != libDir {
+		t.Errorf("空目录应报错: %+v, %v", info, err)
+	}
+	if IsLibraryAvailable() || EnsureLibraryError() == nil {
+		t.Error("IsLibraryAvailable 与 EnsureLibraryError 应使用 AETHER_LIB_DIR")
+	}
+	if GetLibraryInfo()["exists"] != false {
+		t.Error("GetLibraryInfo 应使用 AETHER_LIB_DIR")
+	}
+
+	fake := "!<arch>\naether_new\x00aether_eval\x00"
+	if err := os.WriteFile(filepath.Join(libDir, libFileName()), []byte(fake), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err = InspectLibrary()
+	if err != nil || !IsLibraryAvailable() || EnsureLibraryError() != nil {
+		t.Fatalf("库文件存在时不应报错: %v", err)
+	}
+	if len(info.Symbols) != 2 || len(info.MissingSymbols) != len(abiSymbols)-2 {
+		t.Errorf("符号检查不正确: %v / %v", info.Symbols, info.MissingSymbols)
+	}
+}
+
+// cDecls 为从 C 源码中解析出的声明
+type cDecls struct {
+	funcs   map[string]string   // 函数名 -> 规范化的签名
+	structs map[string][]string // 结构体名 -> 规范化的字段列表
+}
+
+var (
+	cComment   = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+	cFunc      = regexp.MustCompile(`([\w\s\*]+?)\s*\b(aether_\w+)\s*\(([^)]*)\)\s*;`)
+	cStruct    = regexp.MustCompile(`typedef struct (\w+) \{([^}]*)\}`)
+	cParamName = regexp.MustCompile(`\s*\b\w+$`)
+)
+
+// normalizeCType 规范化 C 类型: 去掉 struct 关键字,统一空白与指针写法
+func normalizeCType(s string) string {
+	s = strings.ReplaceAll(s, "struct ", "")
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.ReplaceAll(s, " *", "*")
+}
+
+// normalizeCParams 规范化参数列表,只保留类型
+func normalizeCParams(params string) string {
+	params = strings.TrimSpace(params)
+	if params == "" || params == "void" {
+		return ""
+	}
+	var types []string
+	for _, p := range strings.Split(params, ",") {
+		p = normalizeCType(p)
+		// 去掉参数名,指针的 * 留在类型上
+		types = append(types, strings.TrimSpace(cParamName.ReplaceAllString(p, "")))
+	}
+	return strings.Join(types, ", ")
+}
+
+// parseCDecls 解析 C 源码中的 aether_ 函数与结构体定义
+func parseCDecls(src string) cDecls {
+	src = cComment.ReplaceAllString(src, "")
+	d := cDecls{funcs: map[string]string{}, structs: map[string][]string{}}
+	for _, m := range cFunc.FindAllStringSubmatch(src, -1) {
+		ret := m[1]
+		if i := strings.LastIndexAny(ret, ";}\n"); i >= 0 {
+			ret = ret[i+1:]
+		}
+		d.funcs[m[2]] = normalizeCType(ret) + " (" + normalizeCParams(m[3]) + ")"
+	}
+	for _, m := range cStruct.FindAllStringSubmatch(src, -1) {
+		var fields []string
+		for _, f := range strings.Split(m[2], ";") {
+			if f = normalizeCType(f); f != "" {
+				fields = append(fields, f)
+			}
+		}
+		d.structs[m[1]] = fields
+	}
+	return d
+}
+
+// cgoPreamble 返回 Go 文件中 import "C" 之前的注释
+func cgoPreamble(t *testing.T, file string) string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(data)
+	end := strings.Index(src, "*/\nimport \"C\"")
+	if end < 0 {
+		t.Fatalf("%s 中没有 cgo preamble", file)
+	}
+	start := strings.LastIndex(src[:end], "/*")
+	if start < 0 {
+		t.Fatalf("%s 中没有 cgo preamble", file)
+	}
+	return src[start+2 : end]
+}
+
+// TestPreambleMatchesHeader 测试 native.go 中手写的 C 声明与 aether.h 一致
+//
+// 两者不一致时原生库会按不同的结构体布局或签名读写内存。修改声明后还需要
+// 递增 bindingABI
+func TestPreambleMatchesHeader(t *testing.T) {
+	header, err := os.ReadFile("aether.h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := parseCDecls(string(header))
+	preamble := cgoPreamble(t, "native.go")
+	p := parseCDecls(preamble)
+
+	if len(h.funcs) == 0 {
+		t.Fatal("没有从 aether.h 解析出函数")
+	}
+	for name, sig := range h.funcs {
+		if got, ok := p.funcs[name]; !ok {
+			t.Errorf("preamble 缺少 %s", name)
+		} else if got != sig {
+			t.Errorf("%s: preamble 为 %s,aether.h 为 %s", name, got, sig)
+		}
+	}
+	for name := range p.funcs {
+		if _, ok := h.funcs[name]; !ok {
+			t.Errorf("preamble 声明了 aether.h 中没有的 %s", name)
+		}
+	}
+
+	for name, fields := range p.structs {
+		if name == "AetherErrorCode" {
+			continue
+		}
+		want, ok := h.structs[name]
+		if !ok {
+			t.Errorf("aether.h 中没有结构体 %s", name)
+		} else if strings.Join(fields, "; ") != strings.Join(want, "; ") {
+			t.Errorf("%s: preamble 字段为 %v,aether.h 为 %v", name, fields, want)
+		}
+	}
+
+	// aether.h 中没有错误码枚举,与 ErrorCode 常量比较
+	codes := map[string]ErrorCode{
+		"Success": CodeSuccess, "ParseError": CodeParseError, "RuntimeError": CodeRuntimeError,
+		"NullPointer": CodeNullPointer, "Panic": CodePanic, "InvalidJSON": CodeInvalidJSON,
+		"VariableNotFound": CodeVariableNotFound,
+	}
+	enum := regexp.MustCompile(`(\w+) = (\d+),`).FindAllStringSubmatch(preamble, -1)
+	if len(enum) != len(codes) {
+		t.Errorf("AetherErrorCode 有 %d 个值,ErrorCode 有 %d 个", len(enum), len(codes))
+	}
+	for _, m := range enum {
+		if code, ok := codes[m[1]]; !ok || strconv.Itoa(int(code)) != m[2] {
+			t.Errorf("AetherErrorCode %s = %s 与 ErrorCode 不一致", m[1], m[2])
+		}
+	}
+
+	// 动态加载模式的跳板函数与 InspectLibrary 检查的符号也必须与 aether.h 一致
+	dynamic := cgoPreamble(t, "dynamic.go")
+	entries := regexp.MustCompile(`X\((?:([^,()]+), )?(aether_\w+), \(([^)]*)\)`).FindAllStringSubmatch(dynamic, -1)
+	if len(entries) != len(h.funcs) {
+		t.Errorf("dynamic.go 有 %d 个函数,aether.h 有 %d 个", len(entries), len(h.funcs))
+	}
+	for _, m := range entries {
+		ret := m[1]
+		if ret == "" {
+			ret = "void"
+		}
+		if got := normalizeCType(ret) + " (" + normalizeCParams(m[3]) + ")"; got != h.funcs[m[2]] {
+			t.Errorf("dynamic.go %s: %s,aether.h 为 %s", m[2], got, h.funcs[m[2]])
+		}
+	}
+	if len(abiSymbols) != len(h.funcs) {
+		t.Errorf("abiSymbols 有 %d 个符号,aether.h 有 %d 个", len(abiSymbols), len(h.funcs))
+	}
+	for _, sym := range abiSymbols {
+		if _, ok := h.funcs[sym]; !ok {
+			t.Errorf("abiSymbols 中的 %s 不在 aether.h 中", sym)
+		}
+	}
+}
+
+// TestCheckABI 测试按版本查找 ABI 修订号
+func TestCheckABI(t *testing.T) {
+	if err := CheckABI(); err != nil {
+		t.Fatalf("当前库应兼容: %v", err)
+	}
+
+	for _, tt := range []struct {
+		version string
+		abi     int
+		ok      bool
+	}{
+		{"0.4.0", 1, true},
+		{"v0.4.4", 1, true},
+		{"0.4.9-rc.1", 1, true},
+		{"0.5.0", 0, false},
+		{"0.3.9", 0, false},
+		{"dev", 0, false},
+	} {
+		abi, ok := abiForVersion(tt.version)
+		if abi != tt.abi || ok != tt.ok {
+			t.Errorf("abiForVersion(%q) = %d, %v,期望 %d, %v", tt.version, abi, ok, tt.abi, tt.ok)
+		}
+	}
+
+	// 不在表中的版本只是未知,表中记录为其他修订号的版本才不兼容
+	if err := checkABIVersion("0.5.0"); !errors.Is(err, ErrUnknownLibraryVersion) || errors.Is(err, ErrIncompatibleLibrary) {
+		t.Errorf("期望 ErrUnknownLibraryVersion,得到 %v", err)
+	}
+	saved := abiTable
+	defer func() { abiTable = saved }()
+	abiTable = append(abiTable, struct {
+		Min, Max string
+		ABI      int
+	}{"0.5.0", "0.6.0", bindingABI + 1})
+	if err := checkABIVersion("0.5.1"); !errors.Is(err, ErrIncompatibleLibrary) {
+		t.Errorf("期望 ErrIncompatibleLibrary,得到 %v", err)
+	}
+}
+
+// exerciseEvaluator 通过 Evaluator 接口调用所有方法
+func exerciseEvaluator(t *testing.T, ev Evaluator) {
+	t.Helper()
+
+	if err := ev.SetGlobal("x", 20); err != nil {
+		t.Fatalf("SetGlobal 失败: %v", err)
+	}
+	if v, err := ev.GetGlobal("x"); err != nil || v != 20.0 {
+		t.Errorf("GetGlobal = %v, %v", v, err)
+	}
+	if result, err := ev.Eval("(x + 22)"); err != nil || result != "42" {
+		t.Errorf("Eval = %q, %v", result, err)
+	}
+	if _, err := ev.Eval("(1 +"); CodeOf(err) != CodeParseError {
+		t.Errorf("期望解析错误, 得到 %v", err)
+	}
+
+	if _, err := ev.Eval(`TRACE_INFO("ev", "hello")`); err != nil {
+		t.Fatal(err)
+	}
+	if records, err := ev.TraceRecords(); err != nil || len(records) != 1 || records[0].Category != "ev" {
+		t.Errorf("TraceRecords = %+v, %v", records, err)
+	}
+	if stats, err := ev.TraceStats(); err != nil || stats.TotalEntries != 1 {
+		t.Errorf("TraceStats = %+v, %v", stats, err)
+	}
+	if traces, err := ev.TakeTrace(); err != nil || len(traces) != 1 {
+		t.Errorf("TakeTrace = %v, %v", traces, err)
+	}
+	if err := ev.ClearTrace(); err != nil {
+		t.Error(err)
+	}
+
+	limits := Limits{MaxSteps: 1000, MaxRecursionDepth: 50, MaxDurationMs: 100}
+	if err := ev.SetExecutionLimits(limits); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ev.GetExecutionLimits(); err != nil || *got != limits {
+		t.Errorf("GetExecutionLimits = %+v, %v", got, err)
+	}
+
+	if _, err := ev.CacheStats(); err != nil {
+		t.Error(err)
+	}
+	if err := ev.ClearCache(); err != nil {
+		t.Error(err)
+	}
+	if err := ev.ResetEnv(); err != nil {
+		t.Error(err)
+	}
+	if _, err := ev.GetGlobal("x"); err == nil {
+		t.Error("ResetEnv 后变量应不存在")
+	}
+
+	ev.Close()
+	ev.Close()
+	if _, err := ev.Eval("(1 + 1)"); err == nil {
+		t.Error("Close 后 Eval 应返回错误")
+	}
+}
+
+// TestEvaluator 测试 Engine 与 Pool 都实现 Evaluator
+func TestEvaluator(t *testing.T) {
+	t.Run("Engine", func(t *testing.T) {
+		exerciseEvaluator(t, New())
+	})
+	t.Run("Pool", func(t *testing.T) {
+		exerciseEvaluator(t, NewPool(3, nil))
+	})
+}
+
+// TestPoolEvaluator 测试 Pool 把修改广播到所有引擎并汇总查询结果
+func TestPoolEvaluator(t *testing.T) {
+	pool := NewPool(3, nil)
+	defer pool.Close()
+
+	if err := pool.SetGlobal("name", "pool"); err != nil {
+		t.Fatal(err)
+	}
+	for i, e := range pool.Engines() {
+		if v, err := e.GetGlobal("name"); err != nil || v != "pool" {
+			t.Errorf("引擎 %d: GetGlobal = %v, %v", i, v, err)
+		}
+	}
+
+	for _, e := range pool.Engines() {
+		e.Eval(`TRACE("pool", 1)`)
+	}
+	if stats, err := pool.TraceStats(); err != nil || stats.TotalEntries != 3 {
+		t.Errorf("TraceStats = %+v, %v", stats, err)
+	}
+	if records, err := pool.TraceRecords(); err != nil || len(records) != 3 {
+		t.Errorf("TraceRecords = %d, %v", len(records), err)
+	}
+
+	pool.Close()
+	if err := pool.SetGlobal("name", "x"); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Close 后期望 ErrPoolClosed, 得到 %v", err)
+	}
+}
+
+// exampleMatches 返回 examples/main.go 中匹配 pattern 的所有子匹配,作为模糊测试的种子
+func exampleMatches(f *testing.F, pattern string) [][]string {
+	data, err := os.ReadFile(filepath.Join("examples", "main.go"))
+	if err != nil {
+		f.Fatalf("无法读取示例: %v", err)
+	}
+	matches := regexp.MustCompile(pattern).FindAllStringSubmatch(string(data), -1)
+	if len(matches) == 0 {
+		f.Fatalf("示例中没有匹配 %s 的内容", pattern)
+	}
+	return matches
+}
+
+// fuzzEngine 创建模糊测试使用的引擎,执行限制防止死循环拖慢测试
+func fuzzEngine(f *testing.F) *Engine {
+	engine := New()
+	f.Cleanup(engine.Close)
+	engine.SetExecutionLimits(Limits{MaxSteps: 100000, MaxRecursionDepth: 100, MaxDurationMs: 1000})
+	return engine
+}
+
+// checkCStrings 断言没有未释放的 C 字符串
+func checkCStrings(t *testing.T) {
+	t.Helper()
+	if n := cStringsInUse(); n != 0 {
+		if live := DebugLiveStrings(); len(live) > 0 {
+			t.Fatalf("泄漏了 %d 个 C 字符串, 第一个创建于:\n%s", n, live[0].Stack)
+		}
+		t.Fatalf("泄漏了 %d 个 C 字符串 (使用 -tags aether_debug 查看调用栈)", n)
+	}
+}
+
+// jsonRoundTrip 返回 v 经过 JSON 往返后的值,即 GetGlobal 应返回的值
+func jsonRoundTrip(t *testing.T, v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal 失败: %v", err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("json.Unmarshal 失败: %v", err)
+	}
+	return out
+}
+
+// FuzzEval 测试任意代码不会使进程崩溃或泄漏 C 字符串
+func FuzzEval(f *testing.F) {
+	for _, m := range exampleMatches(f, "(?s)`([^`]*)`") {
+		f.Add(m[1])
+	}
+	f.Add("")
+	f.Add("(1 +")
+	f.Add("Set X \x00 10")
+	f.Add(`"unterminated`)
+	f.Add(strings.Repeat("(", 1000))
+	f.Add("Func F (N) {\n    Return F(N)\n}\nF(1)")
+
+	engine := fuzzEngine(f)
+	f.Fuzz(func(t *testing.T, code string) {
+		defer engine.ResetEnv()
+		defer engine.ClearTrace()
+
+		_, err := engine.Eval(code)
+		checkCStrings(t)
+		if err == nil {
+			return
+		}
+
+		var aerr *Error
+		if !errors.As(err, &aerr) {
+			t.Fatalf("Eval 返回的错误不是 *Error: %v", err)
+		}
+		if aerr.Code <= CodeSuccess || aerr.Code > CodeVariableNotFound {
+			t.Fatalf("未知的错误代码 %d: %v", aerr.Code, err)
+		}
+		// 原生库的 panic 被捕获为 CodePanic,之后引擎必须仍然可用
+		if aerr.Code == CodePanic {
+			if result, err := engine.Eval("(1 + 2)"); err != nil || result != "3" {
+				t.Fatalf("CodePanic 之后引擎不可用: %q, %v", result, err)
+			}
+		}
+	})
+}
+
+// FuzzSetGetGlobal 测试 SetGlobal 与 GetGlobal 的往返一致性
+func FuzzSetGetGlobal(f *testing.F) {
+	for _, m := range exampleMatches(f, `SetGlobal\("([^"]+)", "([^"]*)"\)`) {
+		f.Add(m[1], m[2], 0.0, false)
+	}
+	f.Add("config", `{"max_retries": 3}`, 30.5, true)
+	f.Add("X", "", -1e300, false)
+	f.Add("", "\x00", 0.1, true)
+	f.Add("名字", "\xff\xfe", 9007199254740993.0, false)
+
+	engine := fuzzEngine(f)
+	f.Fuzz(func(t *testing.T, name, s string, n float64, b bool) {
+		defer engine.ResetEnv()
+
+		value := map[string]interface{}{
+			"s":      s,
+			"n":      n,
+			"b":      b,
+			"list":   []interface{}{s, n, b, nil},
+			"nested": map[string]interface{}{"s": s},
+		}
+		if _, err := json.Marshal(value); err != nil {
+			t.Skip("NaN 与 Inf 无法序列化为 JSON")
+		}
+
+		for _, v := range []interface{}{value, s, n} {
+			err := engine.SetGlobal(name, v)
+			checkCStrings(t)
+			if err != nil {
+				// 原生库可以拒绝变量名,但必须返回 *Error
+				var aerr *Error
+				if !errors.As(err, &aerr) {
+					t.Fatalf("SetGlobal(%q) 返回的错误不是 *Error: %v", name, err)
+				}
+				return
+			}
+
+			got, err := engine.GetGlobal(name)
+			checkCStrings(t)
+			if err != nil {
+				t.Fatalf("SetGlobal(%q) 成功但 GetGlobal 失败: %v", name, err)
+			}
+			if want := jsonRoundTrip(t, v); !reflect.DeepEqual(got, want) {
+				t.Fatalf("GetGlobal(%q) = %#v, 期望 %#v", name, got, want)
+			}
+		}
+	})
+}
+
+// FuzzTraceDecode 测试任意追踪内容经过原生库编码后能被正确解码
+func FuzzTraceDecode(f *testing.F) {
+	for _, m := range exampleMatches(f, `TRACE_\w+\("[^"]*", "([^"]*)"`) {
+		f.Add(m[1])
+	}
+	f.Add("")
+	f.Add(`"quoted" \\ \n \t`)
+	f.Add("\x00\x01\x1f")
+	f.Add("\xff")
+	f.Add("🚀 emoji")
+
+	engine := fuzzEngine(f)
+	f.Fuzz(func(t *testing.T, msg string) {
+		defer engine.ResetEnv()
+		defer engine.ClearTrace()
+
+		if err := engine.SetGlobal("MSG", msg); err != nil {
+			t.Fatalf("SetGlobal 失败: %v", err)
+		}
+		if _, err := engine.Eval(`TRACE_INFO("fuzz", MSG)`); err != nil {
+			t.Fatalf("Eval 失败: %v", err)
+		}
+
+		records, err := engine.TraceRecords()
+		checkCStrings(t)
+		if err != nil {
+			t.Fatalf("TraceRecords 失败: %v", err)
+		}
+		want := jsonRoundTrip(t, msg)
+		if len(records) != 1 || records[0].Category != "fuzz" || len(records[0].Values) != 1 || records[0].Values[0] != want {
+			t.Fatalf("TraceRecords() = %+v, 期望一个值为 %q 的条目", records, want)
+		}
+
+		stats, err := engine.TraceStats()
+		checkCStrings(t)
+		if err != nil {
+			t.Fatalf("TraceStats 失败: %v", err)
+		}
+		if stats.TotalEntries != 1 {
+			t.Fatalf("TotalEntries = %d, 期望 1", stats.TotalEntries)
+		}
+
+		if _, err := engine.TakeTrace(); err != nil {
+			t.Fatalf("TakeTrace 失败: %v", err)
+		}
+		checkCStrings(t)
+	})
+}
+
+// TestDebugLiveHandles 测试 aether_debug 构建记录句柄与 C 字符串
+func TestDebugLiveHandles(t *testing.T) {
+	if !DebugEnabled {
+		if DebugLiveHandles() != nil || DebugLiveStrings() != nil {
+			t.Error("未启用 aether_debug 时应返回 nil")
+		}
+		t.Skip("需要 -tags aether_debug")
+	}
+
+	before := len(DebugLiveHandles())
+	engine := New()
+	handles := DebugLiveHandles()
+	if len(handles) != before+1 {
+		t.Fatalf("创建引擎后有 %d 个句柄, 期望 %d", len(handles), before+1)
+	}
+	last := handles[len(handles)-1]
+	if last.Kind != "handle" || !strings.Contains(last.Stack, "TestDebugLiveHandles") {
+		t.Errorf("句柄记录为 %+v", last)
+	}
+
+	engine.SetGlobal("X", 1)
+	engine.Eval("(X + 1)")
+	if _, err := engine.Eval("(1 +"); err == nil {
+		t.Error("语法错误没有返回错误")
+	}
+	engine.GetGlobal("missing")
+	if live := DebugLiveStrings(); len(live) != 0 {
+		t.Errorf("有 %d 个未释放的 C 字符串, 第一个创建于:\n%s", len(live), live[0].Stack)
+	}
+
+	engine.Close()
+	if n := len(DebugLiveHandles()); n != before {
+		t.Errorf("关闭引擎后有 %d 个句柄, 期望 %d", n, before)
+	}
+}
@@ -0,0 +1,368 @@
+// Package aetherfake 提供可编排的 aether.Evaluator 替身 Fake
+//
+// Fake 按脚本返回预设的结果、记录所有调用,并可以按 aether.ErrorCode 注入错误:
+//
+//	fake := aetherfake.New().
+//	    Returns(`(price * 0.9)`, "90").
+//	    Fails(`(1 +`, aether.CodeParseError, "unexpected end of input")
+//
+//	got, err := ApplyDiscount(fake, order) // 接受 aether.Evaluator 的业务代码
+//
+//	if evals := fake.Evals(); len(evals) != 1 { ... }
+//
+// 本包只引用不依赖 cgo 的 aethertypes,只使用 Fake 的测试构建时不需要原生库与 C 编译器。
+// aethertest 以 aethertest.Fake 导出同一类型,并提供同时适用于真实引擎与 Fake 的断言函数
+package aetherfake
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	aether "github.com/xiaozuhui/aether-go/aethertypes"
+)
+
+// Response 为 Fake 对某个脚本的预设响应
+type Response struct {
+	// Result 为 Eval 的返回值
+	Result string
+	// Code 不为 CodeSuccess 时 Eval 返回 *aether.Error{Code, Message}
+	Code    aether.ErrorCode
+	Message string
+	// Traces 为执行脚本时产生的追踪条目
+	Traces []aether.TraceEntry
+	// Globals 为执行脚本后设置的变量,相当于脚本中的 Set
+	Globals map[string]interface{}
+}
+
+// Call 为 Fake 记录的一次方法调用
+type Call struct {
+	// Method 为方法名,如 "Eval"、"SetGlobal"
+	Method string
+	// Args 为调用参数
+	Args []interface{}
+}
+
+// Fake 是可编排的 aether.Evaluator 替身
+//
+// 脚本按去除首尾空白后的内容匹配。没有预设响应的脚本默认返回 CodeRuntimeError,
+// 可以用 Fallback 改变这一行为。Fake 是线程安全的
+type Fake struct {
+	mu        sync.Mutex
+	responses map[string]Response
+	fallback  func(code string) (string, error)
+	failures  map[string]aether.ErrorCode
+
+	globals map[string]interface{}
+	traces  []aether.TraceEntry
+	limits  aether.Limits
+	seen    map[string]bool
+	hits    int
+	misses  int
+	calls   []Call
+	closed  bool
+}
+
+// New 创建没有任何预设响应的 Fake
+func New() *Fake {
+	return &Fake{
+		responses: make(map[string]Response),
+		failures:  make(map[string]aether.ErrorCode),
+		globals:   make(map[string]interface{}),
+		seen:      make(map[string]bool),
+		limits:    aether.Limits{MaxSteps: -1, MaxRecursionDepth: -1, MaxDurationMs: -1},
+	}
+}
+
+// On 为脚本 code 设置响应
+func (f *Fake) On(code string, r Response) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[strings.TrimSpace(code)] = r
+	return f
+}
+
+// Returns 设置脚本 code 的返回值
+func (f *Fake) Returns(code, result string) *Fake {
+	return f.On(code, Response{Result: result})
+}
+
+// Fails 设置脚本 code 返回错误代码为 c 的 *aether.Error
+func (f *Fake) Fails(code string, c aether.ErrorCode, message string) *Fake {
+	return f.On(code, Response{Code: c, Message: message})
+}
+
+// Fallback 设置没有预设响应的脚本的处理函数
+func (f *Fake) Fallback(fn func(code string) (string, error)) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fallback = fn
+	return f
+}
+
+// FailMethod 使方法 method(如 "SetGlobal")返回错误代码为 c 的 *aether.Error
+//
+// c 为 CodeSuccess 时取消注入
+func (f *Fake) FailMethod(method string, c aether.ErrorCode) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c == aether.CodeSuccess {
+		delete(f.failures, method)
+	} else {
+		f.failures[method] = c
+	}
+	return f
+}
+
+// Calls 返回所有方法调用的副本,按调用顺序排列
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := make([]Call, len(f.calls))
+	copy(calls, f.calls)
+	return calls
+}
+
+// Evals 返回所有传给 Eval 的脚本,按调用顺序排列
+func (f *Fake) Evals() []string {
+	var codes []string
+	for _, c := range f.Calls() {
+		if c.Method == "Eval" {
+			codes = append(codes, c.Args[0].(string))
+		}
+	}
+	return codes
+}
+
+// Closed 返回是否已调用 Close
+func (f *Fake) Closed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+// begin 记录调用并返回应返回的错误,调用者必须持有 mu
+func (f *Fake) begin(method string, args ...interface{}) error {
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+	if f.closed {
+		return aether.ErrClosed
+	}
+	if c, ok := f.failures[method]; ok {
+		return &aether.Error{Code: c, Message: fmt.Sprintf("注入的 %s 错误", method)}
+	}
+	return nil
+}
+
+// Eval 返回脚本的预设响应
+func (f *Fake) Eval(code string) (string, error) {
+	f.mu.Lock()
+	if err := f.begin("Eval", code); err != nil {
+		f.mu.Unlock()
+		return "", err
+	}
+
+	key := strings.TrimSpace(code)
+	if f.seen[key] {
+		f.hits++
+	} else {
+		f.misses++
+		f.seen[key] = true
+	}
+
+	r, ok := f.responses[key]
+	if !ok {
+		fallback := f.fallback
+		f.mu.Unlock()
+		if fallback != nil {
+			return fallback(code)
+		}
+		return "", &aether.Error{Code: aether.CodeRuntimeError, Message: "没有为脚本设置响应: " + key}
+	}
+	defer f.mu.Unlock()
+
+	now := time.Now().Unix()
+	for _, t := range r.Traces {
+		if t.Timestamp == 0 {
+			t.Timestamp = now
+		}
+		f.traces = append(f.traces, t)
+	}
+	for name, v := range r.Globals {
+		f.globals[name] = normalize(v)
+	}
+	if r.Code != aether.CodeSuccess {
+		return "", &aether.Error{Code: r.Code, Message: r.Message}
+	}
+	return r.Result, nil
+}
+
+// SetGlobal 保存变量,值经过 JSON 往返,与真实引擎一样数字变为 float64
+func (f *Fake) SetGlobal(name string, value interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("SetGlobal", name, value); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("无法将值序列化为 JSON: %w", err)
+	}
+	var v interface{}
+	json.Unmarshal(data, &v)
+	f.globals[name] = v
+	return nil
+}
+
+// GetGlobal 返回变量的值,不存在时返回与 *aether.Engine 相同的错误
+func (f *Fake) GetGlobal(name string) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("GetGlobal", name); err != nil {
+		return nil, err
+	}
+
+	v, ok := f.globals[name]
+	if !ok {
+		return nil, fmt.Errorf("变量未找到: %s (错误代码: %d)", name, aether.CodeVariableNotFound)
+	}
+	return v, nil
+}
+
+// ResetEnv 清除所有变量
+func (f *Fake) ResetEnv() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("ResetEnv"); err != nil {
+		return err
+	}
+	f.globals = make(map[string]interface{})
+	return nil
+}
+
+// TakeTrace 以 "[category] values" 的形式返回所有追踪条目
+func (f *Fake) TakeTrace() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("TakeTrace"); err != nil {
+		return nil, err
+	}
+
+	traces := make([]string, len(f.traces))
+	for i, t := range f.traces {
+		traces[i] = fmt.Sprintf("[%s] %s", t.Category, strings.Join(t.Values, " "))
+	}
+	return traces, nil
+}
+
+// TraceRecords 返回结构化的追踪条目
+func (f *Fake) TraceRecords() ([]aether.TraceEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("TraceRecords"); err != nil {
+		return nil, err
+	}
+
+	entries := make([]aether.TraceEntry, len(f.traces))
+	copy(entries, f.traces)
+	return entries, nil
+}
+
+// TraceStats 返回追踪统计信息
+func (f *Fake) TraceStats() (*aether.TraceStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("TraceStats"); err != nil {
+		return nil, err
+	}
+
+	stats := &aether.TraceStats{
+		TotalEntries: len(f.traces),
+		ByLevel:      make(map[string]int),
+		ByCategory:   make(map[string]int),
+	}
+	for _, t := range f.traces {
+		stats.ByLevel[t.Level]++
+		stats.ByCategory[t.Category]++
+	}
+	return stats, nil
+}
+
+// ClearTrace 清空追踪条目
+func (f *Fake) ClearTrace() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("ClearTrace"); err != nil {
+		return err
+	}
+	f.traces = nil
+	return nil
+}
+
+// SetExecutionLimits 保存执行限制,Fake 不会执行这些限制
+func (f *Fake) SetExecutionLimits(limits aether.Limits) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("SetExecutionLimits", limits); err != nil {
+		return err
+	}
+	f.limits = limits
+	return nil
+}
+
+// GetExecutionLimits 返回保存的执行限制,默认均为 -1
+func (f *Fake) GetExecutionLimits() (*aether.Limits, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("GetExecutionLimits"); err != nil {
+		return nil, err
+	}
+	limits := f.limits
+	return &limits, nil
+}
+
+// ClearCache 清空缓存,之后第一次执行的脚本重新计为未命中
+func (f *Fake) ClearCache() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("ClearCache"); err != nil {
+		return err
+	}
+	f.seen = make(map[string]bool)
+	return nil
+}
+
+// CacheStats 返回缓存统计,重复执行的脚本计为命中
+func (f *Fake) CacheStats() (*aether.CacheStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("CacheStats"); err != nil {
+		return nil, err
+	}
+	return &aether.CacheStats{Hits: f.hits, Misses: f.misses, Size: len(f.seen)}, nil
+}
+
+// Close 关闭 Fake,之后的调用返回 aether.ErrClosed
+func (f *Fake) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Call{Method: "Close"})
+	f.closed = true
+}
+
+// normalize 对 v 做 JSON 往返,使其与从引擎读取的值类型一致
+func normalize(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+var _ aether.Evaluator = (*Fake)(nil)
@@ -0,0 +1,30 @@
+package aetherfake
+
+import (
+	"errors"
+	"testing"
+
+	aether "github.com/xiaozuhui/aether-go/aethertypes"
+)
+
+// TestFake 测试预设响应、错误注入与调用记录,构建时不需要原生库
+func TestFake(t *testing.T) {
+	var ev aether.Evaluator = New().
+		Returns("(1 + 2)", "3").
+		Fails("(1 +", aether.CodeParseError, "unexpected end of input")
+
+	if got, err := ev.Eval(" (1 + 2) "); err != nil || got != "3" {
+		t.Errorf("Eval = %q, %v, 期望 3", got, err)
+	}
+	if _, err := ev.Eval("(1 +"); aether.CodeOf(err) != aether.CodeParseError {
+		t.Errorf("期望 parse_error,得到 %v", err)
+	}
+
+	ev.Close()
+	if _, err := ev.Eval("(1 + 2)"); !errors.Is(err, aether.ErrClosed) {
+		t.Errorf("Close 后期望 ErrClosed,得到 %v", err)
+	}
+	if evals := ev.(*Fake).Evals(); len(evals) != 3 {
+		t.Errorf("Evals() = %q, 期望 3 次调用", evals)
+	}
+}
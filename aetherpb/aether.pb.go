@@ -0,0 +1,1191 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: aetherpb/aether.proto
+
+package aetherpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ErrorCode 对应原生库的 AetherErrorCode
+type ErrorCode int32
+
+const (
+	ErrorCode_SUCCESS            ErrorCode = 0
+	ErrorCode_PARSE_ERROR        ErrorCode = 1
+	ErrorCode_RUNTIME_ERROR      ErrorCode = 2
+	ErrorCode_NULL_POINTER       ErrorCode = 3
+	ErrorCode_PANIC              ErrorCode = 4
+	ErrorCode_INVALID_JSON       ErrorCode = 5
+	ErrorCode_VARIABLE_NOT_FOUND ErrorCode = 6
+)
+
+// Enum value maps for ErrorCode.
+var (
+	ErrorCode_name = map[int32]string{
+		0: "SUCCESS",
+		1: "PARSE_ERROR",
+		2: "RUNTIME_ERROR",
+		3: "NULL_POINTER",
+		4: "PANIC",
+		5: "INVALID_JSON",
+		6: "VARIABLE_NOT_FOUND",
+	}
+	ErrorCode_value = map[string]int32{
+		"SUCCESS":            0,
+		"PARSE_ERROR":        1,
+		"RUNTIME_ERROR":      2,
+		"NULL_POINTER":       3,
+		"PANIC":              4,
+		"INVALID_JSON":       5,
+		"VARIABLE_NOT_FOUND": 6,
+	}
+)
+
+func (x ErrorCode) Enum() *ErrorCode {
+	p := new(ErrorCode)
+	*p = x
+	return p
+}
+
+func (x ErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_aetherpb_aether_proto_enumTypes[0].Descriptor()
+}
+
+func (ErrorCode) Type() protoreflect.EnumType {
+	return &file_aetherpb_aether_proto_enumTypes[0]
+}
+
+func (x ErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorCode.Descriptor instead.
+func (ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_aetherpb_aether_proto_rawDescGZIP(), []int{0}
+}
+
+// Limits 为请求中的执行限制,0 表示使用服务的默认值
+//
+// 请求只能设置比服务更严格的限制;调用的截止时间也会缩短 timeout_ms
+type Limits struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MaxSteps  int32 `protobuf:"varint,1,opt,name=max_steps,json=maxSteps,proto3" json:"max_steps,omitempty"`
+	MaxDepth  int32 `protobuf:"varint,2,opt,name=max_depth,json=maxDepth,proto3" json:"max_depth,omitempty"`
+	TimeoutMs int32 `protobuf:"varint,3,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
+}
+
+func (x *Limits) Reset() {
+	*x = Limits{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aetherpb_aether_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Limits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Limits) ProtoMessage() {}
+
+func (x *Limits) ProtoReflect() protoreflect.Message {
+	mi := &file_aetherpb_aether_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Limits.ProtoReflect.Descriptor instead.
+func (*Limits) Descriptor() ([]byte, []int) {
+	return file_aetherpb_aether_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Limits) GetMaxSteps() int32 {
+	if x != nil {
+		return x.MaxSteps
+	}
+	return 0
+}
+
+func (x *Limits) GetMaxDepth() int32 {
+	if x != nil {
+		return x.MaxDepth
+	}
+	return 0
+}
+
+func (x *Limits) GetTimeoutMs() int32 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
+type EvalRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code string `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// globals 中的每个键在执行前通过 SetGlobal 设置为变量
+	Globals *structpb.Struct `protobuf:"bytes,2,opt,name=globals,proto3" json:"globals,omitempty"`
+	Limits  *Limits          `protobuf:"bytes,3,opt,name=limits,proto3" json:"limits,omitempty"`
+}
+
+func (x *EvalRequest) Reset() {
+	*x = EvalRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aetherpb_aether_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EvalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvalRequest) ProtoMessage() {}
+
+func (x *EvalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aetherpb_aether_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvalRequest.ProtoReflect.Descriptor instead.
+func (*EvalRequest) Descriptor() ([]byte, []int) {
+	return file_aetherpb_aether_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EvalRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *EvalRequest) GetGlobals() *structpb.Struct {
+	if x != nil {
+		return x.Globals
+	}
+	return nil
+}
+
+func (x *EvalRequest) GetLimits() *Limits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+type RegisterProgramRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// name 只能包含字母、数字、_、- 与 .,且不超过 128 个字符
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Code string `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+}
+
+func (x *RegisterProgramRequest) Reset() {
+	*x = RegisterProgramRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aetherpb_aether_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterProgramRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterProgramRequest) ProtoMessage() {}
+
+func (x *RegisterProgramRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aetherpb_aether_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterProgramRequest.ProtoReflect.Descriptor instead.
+func (*RegisterProgramRequest) Descriptor() ([]byte, []int) {
+	return file_aetherpb_aether_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RegisterProgramRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RegisterProgramRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type RegisterProgramResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// created 为 false 表示替换了同名脚本
+	Created bool `protobuf:"varint,2,opt,name=created,proto3" json:"created,omitempty"`
+}
+
+func (x *RegisterProgramResponse) Reset() {
+	*x = RegisterProgramResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aetherpb_aether_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterProgramResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterProgramResponse) ProtoMessage() {}
+
+func (x *RegisterProgramResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aetherpb_aether_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterProgramResponse.ProtoReflect.Descriptor instead.
+func (*RegisterProgramResponse) Descriptor() ([]byte, []int) {
+	return file_aetherpb_aether_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RegisterProgramResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RegisterProgramResponse) GetCreated() bool {
+	if x != nil {
+		return x.Created
+	}
+	return false
+}
+
+type RunProgramRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name    string           `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Globals *structpb.Struct `protobuf:"bytes,2,opt,name=globals,proto3" json:"globals,omitempty"`
+	Limits  *Limits          `protobuf:"bytes,3,opt,name=limits,proto3" json:"limits,omitempty"`
+}
+
+func (x *RunProgramRequest) Reset() {
+	*x = RunProgramRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aetherpb_aether_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RunProgramRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunProgramRequest) ProtoMessage() {}
+
+func (x *RunProgramRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aetherpb_aether_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunProgramRequest.ProtoReflect.Descriptor instead.
+func (*RunProgramRequest) Descriptor() ([]byte, []int) {
+	return file_aetherpb_aether_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RunProgramRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RunProgramRequest) GetGlobals() *structpb.Struct {
+	if x != nil {
+		return x.Globals
+	}
+	return nil
+}
+
+func (x *RunProgramRequest) GetLimits() *Limits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+type StreamTraceRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Source:
+	//	*StreamTraceRequest_Code
+	//	*StreamTraceRequest_Program
+	Source  isStreamTraceRequest_Source `protobuf_oneof:"source"`
+	Globals *structpb.Struct            `protobuf:"bytes,3,opt,name=globals,proto3" json:"globals,omitempty"`
+	Limits  *Limits                     `protobuf:"bytes,4,opt,name=limits,proto3" json:"limits,omitempty"`
+}
+
+func (x *StreamTraceRequest) Reset() {
+	*x = StreamTraceRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aetherpb_aether_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamTraceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTraceRequest) ProtoMessage() {}
+
+func (x *StreamTraceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aetherpb_aether_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTraceRequest.ProtoReflect.Descriptor instead.
+func (*StreamTraceRequest) Descriptor() ([]byte, []int) {
+	return file_aetherpb_aether_proto_rawDescGZIP(), []int{5}
+}
+
+func (m *StreamTraceRequest) GetSource() isStreamTraceRequest_Source {
+	if m != nil {
+		return m.Source
+	}
+	return nil
+}
+
+func (x *StreamTraceRequest) GetCode() string {
+	if x, ok := x.GetSource().(*StreamTraceRequest_Code); ok {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *StreamTraceRequest) GetProgram() string {
+	if x, ok := x.GetSource().(*StreamTraceRequest_Program); ok {
+		return x.Program
+	}
+	return ""
+}
+
+func (x *StreamTraceRequest) GetGlobals() *structpb.Struct {
+	if x != nil {
+		return x.Globals
+	}
+	return nil
+}
+
+func (x *StreamTraceRequest) GetLimits() *Limits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+type isStreamTraceRequest_Source interface {
+	isStreamTraceRequest_Source()
+}
+
+type StreamTraceRequest_Code struct {
+	Code string `protobuf:"bytes,1,opt,name=code,proto3,oneof"`
+}
+
+type StreamTraceRequest_Program struct {
+	// program 为已注册的脚本名称
+	Program string `protobuf:"bytes,2,opt,name=program,proto3,oneof"`
+}
+
+func (*StreamTraceRequest_Code) isStreamTraceRequest_Source() {}
+
+func (*StreamTraceRequest_Program) isStreamTraceRequest_Source() {}
+
+type StreamTraceResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Event:
+	//	*StreamTraceResponse_Entry
+	//	*StreamTraceResponse_Result
+	Event isStreamTraceResponse_Event `protobuf_oneof:"event"`
+}
+
+func (x *StreamTraceResponse) Reset() {
+	*x = StreamTraceResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aetherpb_aether_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamTraceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTraceResponse) ProtoMessage() {}
+
+func (x *StreamTraceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aetherpb_aether_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTraceResponse.ProtoReflect.Descriptor instead.
+func (*StreamTraceResponse) Descriptor() ([]byte, []int) {
+	return file_aetherpb_aether_proto_rawDescGZIP(), []int{6}
+}
+
+func (m *StreamTraceResponse) GetEvent() isStreamTraceResponse_Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+func (x *StreamTraceResponse) GetEntry() *TraceEntry {
+	if x, ok := x.GetEvent().(*StreamTraceResponse_Entry); ok {
+		return x.Entry
+	}
+	return nil
+}
+
+func (x *StreamTraceResponse) GetResult() *EvalResponse {
+	if x, ok := x.GetEvent().(*StreamTraceResponse_Result); ok {
+		return x.Result
+	}
+	return nil
+}
+
+type isStreamTraceResponse_Event interface {
+	isStreamTraceResponse_Event()
+}
+
+type StreamTraceResponse_Entry struct {
+	Entry *TraceEntry `protobuf:"bytes,1,opt,name=entry,proto3,oneof"`
+}
+
+type StreamTraceResponse_Result struct {
+	// result 为最后一条消息,其中的 trace 为空
+	Result *EvalResponse `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*StreamTraceResponse_Entry) isStreamTraceResponse_Event() {}
+
+func (*StreamTraceResponse_Result) isStreamTraceResponse_Event() {}
+
+type EvalResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// result 为脚本的返回值,出错时为空
+	Result string `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	// error 只出现在 gRPC 错误的详情中
+	Error *Error        `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Trace []*TraceEntry `protobuf:"bytes,4,rep,name=trace,proto3" json:"trace,omitempty"`
+	Usage *Usage        `protobuf:"bytes,5,opt,name=usage,proto3" json:"usage,omitempty"`
+}
+
+func (x *EvalResponse) Reset() {
+	*x = EvalResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aetherpb_aether_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EvalResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvalResponse) ProtoMessage() {}
+
+func (x *EvalResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aetherpb_aether_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvalResponse.ProtoReflect.Descriptor instead.
+func (*EvalResponse) Descriptor() ([]byte, []int) {
+	return file_aetherpb_aether_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *EvalResponse) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *EvalResponse) GetError() *Error {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *EvalResponse) GetTrace() []*TraceEntry {
+	if x != nil {
+		return x.Trace
+	}
+	return nil
+}
+
+func (x *EvalResponse) GetUsage() *Usage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+type Error struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code    ErrorCode `protobuf:"varint,1,opt,name=code,proto3,enum=aether.v1.ErrorCode" json:"code,omitempty"`
+	Message string    `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aetherpb_aether_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_aetherpb_aether_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_aetherpb_aether_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Error) GetCode() ErrorCode {
+	if x != nil {
+		return x.Code
+	}
+	return ErrorCode_SUCCESS
+}
+
+func (x *Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type TraceEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Level     string   `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	Category  string   `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
+	Timestamp int64    `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Values    []string `protobuf:"bytes,4,rep,name=values,proto3" json:"values,omitempty"`
+	Label     *string  `protobuf:"bytes,5,opt,name=label,proto3,oneof" json:"label,omitempty"`
+}
+
+func (x *TraceEntry) Reset() {
+	*x = TraceEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aetherpb_aether_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TraceEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraceEntry) ProtoMessage() {}
+
+func (x *TraceEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_aetherpb_aether_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraceEntry.ProtoReflect.Descriptor instead.
+func (*TraceEntry) Descriptor() ([]byte, []int) {
+	return file_aetherpb_aether_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TraceEntry) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *TraceEntry) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *TraceEntry) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *TraceEntry) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *TraceEntry) GetLabel() string {
+	if x != nil && x.Label != nil {
+		return *x.Label
+	}
+	return ""
+}
+
+type Usage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// duration_ms 为执行耗时
+	DurationMs float64 `protobuf:"fixed64,1,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	// wait_ms 为等待空闲引擎的时间
+	WaitMs float64 `protobuf:"fixed64,2,opt,name=wait_ms,json=waitMs,proto3" json:"wait_ms,omitempty"`
+	// limits 为本次执行实际使用的限制,-1 表示不限制
+	Limits *Limits `protobuf:"bytes,3,opt,name=limits,proto3" json:"limits,omitempty"`
+}
+
+func (x *Usage) Reset() {
+	*x = Usage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_aetherpb_aether_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Usage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Usage) ProtoMessage() {}
+
+func (x *Usage) ProtoReflect() protoreflect.Message {
+	mi := &file_aetherpb_aether_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Usage.ProtoReflect.Descriptor instead.
+func (*Usage) Descriptor() ([]byte, []int) {
+	return file_aetherpb_aether_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Usage) GetDurationMs() float64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *Usage) GetWaitMs() float64 {
+	if x != nil {
+		return x.WaitMs
+	}
+	return 0
+}
+
+func (x *Usage) GetLimits() *Limits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+var File_aetherpb_aether_proto protoreflect.FileDescriptor
+
+var file_aetherpb_aether_proto_rawDesc = []byte{
+	0x0a, 0x15, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x70, 0x62, 0x2f, 0x61, 0x65, 0x74, 0x68, 0x65,
+	0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2f, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x22, 0x61, 0x0a, 0x06, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x61,
+	0x78, 0x5f, 0x73, 0x74, 0x65, 0x70, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x6d,
+	0x61, 0x78, 0x53, 0x74, 0x65, 0x70, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x61, 0x78, 0x5f, 0x64,
+	0x65, 0x70, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x6d, 0x61, 0x78, 0x44,
+	0x65, 0x70, 0x74, 0x68, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f,
+	0x6d, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75,
+	0x74, 0x4d, 0x73, 0x22, 0x7f, 0x0a, 0x0b, 0x45, 0x76, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x31, 0x0a, 0x07, 0x67, 0x6c, 0x6f, 0x62, 0x61, 0x6c,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x75, 0x63, 0x74,
+	0x52, 0x07, 0x67, 0x6c, 0x6f, 0x62, 0x61, 0x6c, 0x73, 0x12, 0x29, 0x0a, 0x06, 0x6c, 0x69, 0x6d,
+	0x69, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x61, 0x65, 0x74, 0x68,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x52, 0x06, 0x6c, 0x69,
+	0x6d, 0x69, 0x74, 0x73, 0x22, 0x40, 0x0a, 0x16, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72,
+	0x50, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12,
+	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x22, 0x47, 0x0a, 0x17, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x65, 0x72, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x22,
+	0x85, 0x01, 0x0a, 0x11, 0x52, 0x75, 0x6e, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x31, 0x0a, 0x07, 0x67, 0x6c, 0x6f,
+	0x62, 0x61, 0x6c, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72,
+	0x75, 0x63, 0x74, 0x52, 0x07, 0x67, 0x6c, 0x6f, 0x62, 0x61, 0x6c, 0x73, 0x12, 0x29, 0x0a, 0x06,
+	0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x61,
+	0x65, 0x74, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x52,
+	0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x22, 0xae, 0x01, 0x0a, 0x12, 0x53, 0x74, 0x72, 0x65,
+	0x61, 0x6d, 0x54, 0x72, 0x61, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14,
+	0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x04,
+	0x63, 0x6f, 0x64, 0x65, 0x12, 0x1a, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d,
+	0x12, 0x31, 0x0a, 0x07, 0x67, 0x6c, 0x6f, 0x62, 0x61, 0x6c, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x75, 0x63, 0x74, 0x52, 0x07, 0x67, 0x6c, 0x6f, 0x62,
+	0x61, 0x6c, 0x73, 0x12, 0x29, 0x0a, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x52, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x42, 0x08,
+	0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x22, 0x80, 0x01, 0x0a, 0x13, 0x53, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x54, 0x72, 0x61, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x2d, 0x0a, 0x05, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x15, 0x2e, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x63,
+	0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x48, 0x00, 0x52, 0x05, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x12,
+	0x31, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x17, 0x2e, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x76, 0x61, 0x6c,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x42, 0x07, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x22, 0xb1, 0x01, 0x0a, 0x0c,
+	0x45, 0x76, 0x61, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x12, 0x26, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x2b, 0x0a, 0x05,
+	0x74, 0x72, 0x61, 0x63, 0x65, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x61, 0x65,
+	0x74, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x63, 0x65, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x05, 0x74, 0x72, 0x61, 0x63, 0x65, 0x12, 0x26, 0x0a, 0x05, 0x75, 0x73, 0x61,
+	0x67, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x61, 0x65, 0x74, 0x68, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x73, 0x61, 0x67, 0x65, 0x52, 0x05, 0x75, 0x73, 0x61, 0x67,
+	0x65, 0x4a, 0x04, 0x08, 0x03, 0x10, 0x04, 0x52, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x22,
+	0x4b, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x28, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x63, 0x6f,
+	0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x99, 0x01, 0x0a,
+	0x0a, 0x54, 0x72, 0x61, 0x63, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x6c,
+	0x65, 0x76, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65,
+	0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x12, 0x1c, 0x0a,
+	0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x73, 0x12, 0x19, 0x0a, 0x05, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x88, 0x01, 0x01, 0x42, 0x08,
+	0x0a, 0x06, 0x5f, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x22, 0x6c, 0x0a, 0x05, 0x55, 0x73, 0x61, 0x67,
+	0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6d, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x4d, 0x73, 0x12, 0x17, 0x0a, 0x07, 0x77, 0x61, 0x69, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x06, 0x77, 0x61, 0x69, 0x74, 0x4d, 0x73, 0x12, 0x29, 0x0a, 0x06, 0x6c,
+	0x69, 0x6d, 0x69, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x61, 0x65,
+	0x74, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x52, 0x06,
+	0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x2a, 0x83, 0x01, 0x0a, 0x09, 0x45, 0x72, 0x72, 0x6f, 0x72,
+	0x43, 0x6f, 0x64, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x55, 0x43, 0x43, 0x45, 0x53, 0x53, 0x10,
+	0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x50, 0x41, 0x52, 0x53, 0x45, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52,
+	0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x52, 0x55, 0x4e, 0x54, 0x49, 0x4d, 0x45, 0x5f, 0x45, 0x52,
+	0x52, 0x4f, 0x52, 0x10, 0x02, 0x12, 0x10, 0x0a, 0x0c, 0x4e, 0x55, 0x4c, 0x4c, 0x5f, 0x50, 0x4f,
+	0x49, 0x4e, 0x54, 0x45, 0x52, 0x10, 0x03, 0x12, 0x09, 0x0a, 0x05, 0x50, 0x41, 0x4e, 0x49, 0x43,
+	0x10, 0x04, 0x12, 0x10, 0x0a, 0x0c, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x5f, 0x4a, 0x53,
+	0x4f, 0x4e, 0x10, 0x05, 0x12, 0x16, 0x0a, 0x12, 0x56, 0x41, 0x52, 0x49, 0x41, 0x42, 0x4c, 0x45,
+	0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x46, 0x4f, 0x55, 0x4e, 0x44, 0x10, 0x06, 0x32, 0xb0, 0x02, 0x0a,
+	0x06, 0x41, 0x65, 0x74, 0x68, 0x65, 0x72, 0x12, 0x37, 0x0a, 0x04, 0x45, 0x76, 0x61, 0x6c, 0x12,
+	0x16, 0x2e, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x76, 0x61, 0x6c,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x45, 0x76, 0x61, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x58, 0x0a, 0x0f, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x50, 0x72, 0x6f, 0x67,
+	0x72, 0x61, 0x6d, 0x12, 0x21, 0x2e, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x50, 0x72, 0x6f, 0x67, 0x72,
+	0x61, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x0a, 0x52, 0x75,
+	0x6e, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x12, 0x1c, 0x2e, 0x61, 0x65, 0x74, 0x68, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x75, 0x6e, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x45, 0x76, 0x61, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x4e, 0x0a, 0x0b, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x54, 0x72, 0x61, 0x63, 0x65, 0x12, 0x1d,
+	0x2e, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x54, 0x72, 0x61, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e,
+	0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x54, 0x72, 0x61, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x42,
+	0x29, 0x5a, 0x27, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x78, 0x69,
+	0x61, 0x6f, 0x7a, 0x75, 0x68, 0x75, 0x69, 0x2f, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x2d, 0x67,
+	0x6f, 0x2f, 0x61, 0x65, 0x74, 0x68, 0x65, 0x72, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_aetherpb_aether_proto_rawDescOnce sync.Once
+	file_aetherpb_aether_proto_rawDescData = file_aetherpb_aether_proto_rawDesc
+)
+
+func file_aetherpb_aether_proto_rawDescGZIP() []byte {
+	file_aetherpb_aether_proto_rawDescOnce.Do(func() {
+		file_aetherpb_aether_proto_rawDescData = protoimpl.X.CompressGZIP(file_aetherpb_aether_proto_rawDescData)
+	})
+	return file_aetherpb_aether_proto_rawDescData
+}
+
+var file_aetherpb_aether_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_aetherpb_aether_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_aetherpb_aether_proto_goTypes = []any{
+	(ErrorCode)(0),                  // 0: aether.v1.ErrorCode
+	(*Limits)(nil),                  // 1: aether.v1.Limits
+	(*EvalRequest)(nil),             // 2: aether.v1.EvalRequest
+	(*RegisterProgramRequest)(nil),  // 3: aether.v1.RegisterProgramRequest
+	(*RegisterProgramResponse)(nil), // 4: aether.v1.RegisterProgramResponse
+	(*RunProgramRequest)(nil),       // 5: aether.v1.RunProgramRequest
+	(*StreamTraceRequest)(nil),      // 6: aether.v1.StreamTraceRequest
+	(*StreamTraceResponse)(nil),     // 7: aether.v1.StreamTraceResponse
+	(*EvalResponse)(nil),            // 8: aether.v1.EvalResponse
+	(*Error)(nil),                   // 9: aether.v1.Error
+	(*TraceEntry)(nil),              // 10: aether.v1.TraceEntry
+	(*Usage)(nil),                   // 11: aether.v1.Usage
+	(*structpb.Struct)(nil),         // 12: google.protobuf.Struct
+}
+var file_aetherpb_aether_proto_depIdxs = []int32{
+	12, // 0: aether.v1.EvalRequest.globals:type_name -> google.protobuf.Struct
+	1,  // 1: aether.v1.EvalRequest.limits:type_name -> aether.v1.Limits
+	12, // 2: aether.v1.RunProgramRequest.globals:type_name -> google.protobuf.Struct
+	1,  // 3: aether.v1.RunProgramRequest.limits:type_name -> aether.v1.Limits
+	12, // 4: aether.v1.StreamTraceRequest.globals:type_name -> google.protobuf.Struct
+	1,  // 5: aether.v1.StreamTraceRequest.limits:type_name -> aether.v1.Limits
+	10, // 6: aether.v1.StreamTraceResponse.entry:type_name -> aether.v1.TraceEntry
+	8,  // 7: aether.v1.StreamTraceResponse.result:type_name -> aether.v1.EvalResponse
+	9,  // 8: aether.v1.EvalResponse.error:type_name -> aether.v1.Error
+	10, // 9: aether.v1.EvalResponse.trace:type_name -> aether.v1.TraceEntry
+	11, // 10: aether.v1.EvalResponse.usage:type_name -> aether.v1.Usage
+	0,  // 11: aether.v1.Error.code:type_name -> aether.v1.ErrorCode
+	1,  // 12: aether.v1.Usage.limits:type_name -> aether.v1.Limits
+	2,  // 13: aether.v1.Aether.Eval:input_type -> aether.v1.EvalRequest
+	3,  // 14: aether.v1.Aether.RegisterProgram:input_type -> aether.v1.RegisterProgramRequest
+	5,  // 15: aether.v1.Aether.RunProgram:input_type -> aether.v1.RunProgramRequest
+	6,  // 16: aether.v1.Aether.StreamTrace:input_type -> aether.v1.StreamTraceRequest
+	8,  // 17: aether.v1.Aether.Eval:output_type -> aether.v1.EvalResponse
+	4,  // 18: aether.v1.Aether.RegisterProgram:output_type -> aether.v1.RegisterProgramResponse
+	8,  // 19: aether.v1.Aether.RunProgram:output_type -> aether.v1.EvalResponse
+	7,  // 20: aether.v1.Aether.StreamTrace:output_type -> aether.v1.StreamTraceResponse
+	17, // [17:21] is the sub-list for method output_type
+	13, // [13:17] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_aetherpb_aether_proto_init() }
+func file_aetherpb_aether_proto_init() {
+	if File_aetherpb_aether_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_aetherpb_aether_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Limits); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aetherpb_aether_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*EvalRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aetherpb_aether_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*RegisterProgramRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aetherpb_aether_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*RegisterProgramResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aetherpb_aether_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*RunProgramRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aetherpb_aether_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*StreamTraceRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aetherpb_aether_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*StreamTraceResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aetherpb_aether_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*EvalResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aetherpb_aether_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*Error); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aetherpb_aether_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*TraceEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_aetherpb_aether_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*Usage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_aetherpb_aether_proto_msgTypes[5].OneofWrappers = []any{
+		(*StreamTraceRequest_Code)(nil),
+		(*StreamTraceRequest_Program)(nil),
+	}
+	file_aetherpb_aether_proto_msgTypes[6].OneofWrappers = []any{
+		(*StreamTraceResponse_Entry)(nil),
+		(*StreamTraceResponse_Result)(nil),
+	}
+	file_aetherpb_aether_proto_msgTypes[9].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_aetherpb_aether_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_aetherpb_aether_proto_goTypes,
+		DependencyIndexes: file_aetherpb_aether_proto_depIdxs,
+		EnumInfos:         file_aetherpb_aether_proto_enumTypes,
+		MessageInfos:      file_aetherpb_aether_proto_msgTypes,
+	}.Build()
+	File_aetherpb_aether_proto = out.File
+	file_aetherpb_aether_proto_rawDesc = nil
+	file_aetherpb_aether_proto_goTypes = nil
+	file_aetherpb_aether_proto_depIdxs = nil
+}
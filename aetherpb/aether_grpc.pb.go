@@ -0,0 +1,261 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: aetherpb/aether.proto
+
+package aetherpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Aether_Eval_FullMethodName            = "/aether.v1.Aether/Eval"
+	Aether_RegisterProgram_FullMethodName = "/aether.v1.Aether/RegisterProgram"
+	Aether_RunProgram_FullMethodName      = "/aether.v1.Aether/RunProgram"
+	Aether_StreamTrace_FullMethodName     = "/aether.v1.Aether/StreamTrace"
+)
+
+// AetherClient is the client API for Aether service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// # Aether 为脚本执行服务,语义与 server 包的 HTTP 接口相同
+//
+// 脚本出错时返回 gRPC 错误,错误代码由 ErrorCode 映射而来,
+// 错误详情(status details)中附带完整的 EvalResponse,包含追踪条目与用量
+type AetherClient interface {
+	// Eval 执行一段代码
+	Eval(ctx context.Context, in *EvalRequest, opts ...grpc.CallOption) (*EvalResponse, error)
+	// RegisterProgram 注册或替换命名脚本
+	RegisterProgram(ctx context.Context, in *RegisterProgramRequest, opts ...grpc.CallOption) (*RegisterProgramResponse, error)
+	// RunProgram 执行已注册的脚本
+	RunProgram(ctx context.Context, in *RunProgramRequest, opts ...grpc.CallOption) (*EvalResponse, error)
+	// StreamTrace 执行代码或已注册的脚本,逐条发送追踪条目,最后发送结果
+	//
+	// 原生库没有回调接口,追踪条目在执行结束后才能读取,因此在脚本执行完毕后发送
+	StreamTrace(ctx context.Context, in *StreamTraceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamTraceResponse], error)
+}
+
+type aetherClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAetherClient(cc grpc.ClientConnInterface) AetherClient {
+	return &aetherClient{cc}
+}
+
+func (c *aetherClient) Eval(ctx context.Context, in *EvalRequest, opts ...grpc.CallOption) (*EvalResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EvalResponse)
+	err := c.cc.Invoke(ctx, Aether_Eval_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aetherClient) RegisterProgram(ctx context.Context, in *RegisterProgramRequest, opts ...grpc.CallOption) (*RegisterProgramResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterProgramResponse)
+	err := c.cc.Invoke(ctx, Aether_RegisterProgram_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aetherClient) RunProgram(ctx context.Context, in *RunProgramRequest, opts ...grpc.CallOption) (*EvalResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EvalResponse)
+	err := c.cc.Invoke(ctx, Aether_RunProgram_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aetherClient) StreamTrace(ctx context.Context, in *StreamTraceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamTraceResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Aether_ServiceDesc.Streams[0], Aether_StreamTrace_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamTraceRequest, StreamTraceResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Aether_StreamTraceClient = grpc.ServerStreamingClient[StreamTraceResponse]
+
+// AetherServer is the server API for Aether service.
+// All implementations must embed UnimplementedAetherServer
+// for forward compatibility.
+//
+// # Aether 为脚本执行服务,语义与 server 包的 HTTP 接口相同
+//
+// 脚本出错时返回 gRPC 错误,错误代码由 ErrorCode 映射而来,
+// 错误详情(status details)中附带完整的 EvalResponse,包含追踪条目与用量
+type AetherServer interface {
+	// Eval 执行一段代码
+	Eval(context.Context, *EvalRequest) (*EvalResponse, error)
+	// RegisterProgram 注册或替换命名脚本
+	RegisterProgram(context.Context, *RegisterProgramRequest) (*RegisterProgramResponse, error)
+	// RunProgram 执行已注册的脚本
+	RunProgram(context.Context, *RunProgramRequest) (*EvalResponse, error)
+	// StreamTrace 执行代码或已注册的脚本,逐条发送追踪条目,最后发送结果
+	//
+	// 原生库没有回调接口,追踪条目在执行结束后才能读取,因此在脚本执行完毕后发送
+	StreamTrace(*StreamTraceRequest, grpc.ServerStreamingServer[StreamTraceResponse]) error
+	mustEmbedUnimplementedAetherServer()
+}
+
+// UnimplementedAetherServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAetherServer struct{}
+
+func (UnimplementedAetherServer) Eval(context.Context, *EvalRequest) (*EvalResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Eval not implemented")
+}
+func (UnimplementedAetherServer) RegisterProgram(context.Context, *RegisterProgramRequest) (*RegisterProgramResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterProgram not implemented")
+}
+func (UnimplementedAetherServer) RunProgram(context.Context, *RunProgramRequest) (*EvalResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunProgram not implemented")
+}
+func (UnimplementedAetherServer) StreamTrace(*StreamTraceRequest, grpc.ServerStreamingServer[StreamTraceResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTrace not implemented")
+}
+func (UnimplementedAetherServer) mustEmbedUnimplementedAetherServer() {}
+func (UnimplementedAetherServer) testEmbeddedByValue()                {}
+
+// UnsafeAetherServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AetherServer will
+// result in compilation errors.
+type UnsafeAetherServer interface {
+	mustEmbedUnimplementedAetherServer()
+}
+
+func RegisterAetherServer(s grpc.ServiceRegistrar, srv AetherServer) {
+	// If the following call pancis, it indicates UnimplementedAetherServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Aether_ServiceDesc, srv)
+}
+
+func _Aether_Eval_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AetherServer).Eval(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Aether_Eval_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AetherServer).Eval(ctx, req.(*EvalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Aether_RegisterProgram_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterProgramRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AetherServer).RegisterProgram(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Aether_RegisterProgram_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AetherServer).RegisterProgram(ctx, req.(*RegisterProgramRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Aether_RunProgram_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunProgramRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AetherServer).RunProgram(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Aether_RunProgram_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AetherServer).RunProgram(ctx, req.(*RunProgramRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Aether_StreamTrace_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTraceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AetherServer).StreamTrace(m, &grpc.GenericServerStream[StreamTraceRequest, StreamTraceResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Aether_StreamTraceServer = grpc.ServerStreamingServer[StreamTraceResponse]
+
+// Aether_ServiceDesc is the grpc.ServiceDesc for Aether service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Aether_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "aether.v1.Aether",
+	HandlerType: (*AetherServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Eval",
+			Handler:    _Aether_Eval_Handler,
+		},
+		{
+			MethodName: "RegisterProgram",
+			Handler:    _Aether_RegisterProgram_Handler,
+		},
+		{
+			MethodName: "RunProgram",
+			Handler:    _Aether_RunProgram_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamTrace",
+			Handler:       _Aether_StreamTrace_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "aetherpb/aether.proto",
+}
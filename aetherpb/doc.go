@@ -0,0 +1,6 @@
+// Package aetherpb 为 aether.proto 生成的 protobuf 消息与 gRPC 接口
+//
+// 服务端实现见 grpcserver 包。修改 aether.proto 后在仓库根目录运行 make proto 重新生成。
+package aetherpb
+
+//go:generate protoc -I .. --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative ../aetherpb/aether.proto
@@ -0,0 +1,598 @@
+package aethertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+// recorder 记录辅助函数报告的失败,而不使外层测试失败
+type recorder struct {
+	testing.TB
+	mu       sync.Mutex
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Cleanup(fn func()) {
+	r.cleanups = append(r.cleanups, fn)
+}
+
+func (r *recorder) Log(args ...interface{}) {}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// run 在新的 goroutine 中执行 fn,使 Fatalf 只终止 fn
+func (r *recorder) run(fn func(tb testing.TB)) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			for i := len(r.cleanups) - 1; i >= 0; i-- {
+				r.cleanups[i]()
+			}
+		}()
+		fn(r)
+	}()
+	<-done
+}
+
+// failed 返回 fn 是否报告了失败
+func failed(t *testing.T, fn func(tb testing.TB)) (bool, []string) {
+	r := &recorder{TB: t}
+	r.run(fn)
+	return len(r.errors) > 0, r.errors
+}
+
+// TestFakeScripts 测试按脚本预设的结果与错误
+func TestFakeScripts(t *testing.T) {
+	fake := NewFake().
+		Returns("(1 + 2)", "3").
+		Fails("(1 +", aether.CodeParseError, "unexpected end of input")
+
+	if got := MustEval(t, fake, "  (1 + 2)\n"); got != "3" {
+		t.Errorf("Eval = %q, 期望 3", got)
+	}
+
+	_, err := fake.Eval("(1 +")
+	AssertErrorCode(t, err, aether.CodeParseError)
+	if err.Error() != "aether: unexpected end of input" {
+		t.Errorf("错误信息为 %q", err.Error())
+	}
+
+	_, err = fake.Eval("UNKNOWN()")
+	AssertErrorCode(t, err, aether.CodeRuntimeError)
+
+	fake.Fallback(func(code string) (string, error) { return "fallback:" + code, nil })
+	if got := MustEval(t, fake, "UNKNOWN()"); got != "fallback:UNKNOWN()" {
+		t.Errorf("Fallback 结果为 %q", got)
+	}
+
+	want := []string{"  (1 + 2)\n", "(1 +", "UNKNOWN()", "UNKNOWN()"}
+	evals := fake.Evals()
+	if fmt.Sprint(evals) != fmt.Sprint(want) {
+		t.Errorf("Evals() = %q, 期望 %q", evals, want)
+	}
+}
+
+// TestFakeResponseEffects 测试响应中的追踪条目与变量
+func TestFakeResponseEffects(t *testing.T) {
+	fake := NewFake().On(`Set X 10 TRACE("calc", X)`, Response{
+		Result:  "10",
+		Traces:  []aether.TraceEntry{{Level: "info", Category: "calc", Values: []string{"10"}}},
+		Globals: map[string]interface{}{"X": 10},
+	})
+
+	MustEval(t, fake, `Set X 10 TRACE("calc", X)`)
+	AssertGlobal(t, fake, "X", 10)
+	AssertTrace(t, fake, "calc", "10")
+	AssertTrace(t, fake, "other")
+
+	stats, err := fake.TraceStats()
+	if err != nil {
+		t.Fatalf("TraceStats 失败: %v", err)
+	}
+	if stats.TotalEntries != 1 || stats.ByCategory["calc"] != 1 || stats.ByLevel["info"] != 1 {
+		t.Errorf("TraceStats = %+v", stats)
+	}
+
+	records, _ := fake.TraceRecords()
+	if records[0].Timestamp == 0 {
+		t.Error("追踪条目没有时间戳")
+	}
+
+	fake.ClearTrace()
+	AssertTrace(t, fake, "calc")
+
+	fake.ResetEnv()
+	if _, err := fake.GetGlobal("X"); err == nil {
+		t.Error("ResetEnv 后变量仍然存在")
+	}
+}
+
+// TestFakeGlobals 测试变量经过 JSON 往返
+func TestFakeGlobals(t *testing.T) {
+	fake := NewFake()
+
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	if err := fake.SetGlobal("user", user{Name: "Alice", Age: 30}); err != nil {
+		t.Fatalf("SetGlobal 失败: %v", err)
+	}
+	v, _ := fake.GetGlobal("user")
+	m, ok := v.(map[string]interface{})
+	if !ok || m["age"] != float64(30) {
+		t.Errorf("GetGlobal = %#v, 期望 JSON 对象", v)
+	}
+	AssertGlobal(t, fake, "user", map[string]interface{}{"name": "Alice", "age": 30})
+
+	if err := fake.SetGlobal("ch", make(chan int)); err == nil {
+		t.Error("无法序列化的值应返回错误")
+	}
+}
+
+// TestFakeFailMethod 测试按方法注入错误
+func TestFakeFailMethod(t *testing.T) {
+	fake := NewFake().FailMethod("SetGlobal", aether.CodeInvalidJSON)
+
+	AssertErrorCode(t, fake.SetGlobal("X", 1), aether.CodeInvalidJSON)
+
+	fake.FailMethod("SetGlobal", aether.CodeSuccess)
+	if err := fake.SetGlobal("X", 1); err != nil {
+		t.Errorf("取消注入后 SetGlobal 失败: %v", err)
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 2 || calls[0].Method != "SetGlobal" || calls[0].Args[0] != "X" {
+		t.Errorf("Calls() = %+v", calls)
+	}
+}
+
+// TestFakeLimitsAndCache 测试执行限制与缓存统计
+func TestFakeLimitsAndCache(t *testing.T) {
+	fake := NewFake().Returns("1", "1")
+
+	limits, _ := fake.GetExecutionLimits()
+	if limits.MaxSteps != -1 || limits.MaxRecursionDepth != -1 || limits.MaxDurationMs != -1 {
+		t.Errorf("默认执行限制为 %+v", limits)
+	}
+	fake.SetExecutionLimits(aether.Limits{MaxSteps: 100, MaxRecursionDepth: -1, MaxDurationMs: -1})
+	limits, _ = fake.GetExecutionLimits()
+	if limits.MaxSteps != 100 {
+		t.Errorf("MaxSteps = %d, 期望 100", limits.MaxSteps)
+	}
+
+	MustEval(t, fake, "1")
+	MustEval(t, fake, "1")
+	stats, _ := fake.CacheStats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Size != 1 {
+		t.Errorf("CacheStats = %+v", stats)
+	}
+
+	fake.ClearCache()
+	MustEval(t, fake, "1")
+	stats, _ = fake.CacheStats()
+	if stats.Misses != 2 || stats.Size != 1 {
+		t.Errorf("ClearCache 后 CacheStats = %+v", stats)
+	}
+}
+
+// TestFakeClose 测试关闭后返回 ErrClosed
+func TestFakeClose(t *testing.T) {
+	fake := NewFake().Returns("1", "1")
+	fake.Close()
+	fake.Close()
+
+	if !fake.Closed() {
+		t.Error("Closed() 返回 false")
+	}
+	if _, err := fake.Eval("1"); !errors.Is(err, aether.ErrClosed) {
+		t.Errorf("关闭后 Eval 返回 %v, 期望 ErrClosed", err)
+	}
+	if err := fake.SetGlobal("X", 1); !errors.Is(err, aether.ErrClosed) {
+		t.Errorf("关闭后 SetGlobal 返回 %v, 期望 ErrClosed", err)
+	}
+}
+
+// TestFakeConcurrent 测试并发调用
+func TestFakeConcurrent(t *testing.T) {
+	fake := NewFake().Returns("1", "1")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			fake.Eval("1")
+			fake.SetGlobal(fmt.Sprintf("v%d", i), i)
+		}(i)
+	}
+	wg.Wait()
+
+	if n := len(fake.Calls()); n != 20 {
+		t.Errorf("记录了 %d 次调用, 期望 20", n)
+	}
+}
+
+// TestHelperFailures 测试辅助函数报告失败
+func TestHelperFailures(t *testing.T) {
+	fake := NewFake().Fails("bad", aether.CodeRuntimeError, "boom")
+	fake.SetGlobal("X", 1)
+
+	cases := []struct {
+		name string
+		fn   func(tb testing.TB)
+	}{
+		{"MustEval", func(tb testing.TB) { MustEval(tb, fake, "bad") }},
+		{"AssertGlobal 值不同", func(tb testing.TB) { AssertGlobal(tb, fake, "X", 2) }},
+		{"AssertGlobal 变量不存在", func(tb testing.TB) { AssertGlobal(tb, fake, "Y", 1) }},
+		{"AssertTrace", func(tb testing.TB) { AssertTrace(tb, fake, "calc", "1") }},
+		{"AssertErrorCode 没有错误", func(tb testing.TB) { AssertErrorCode(tb, nil, aether.CodeParseError) }},
+		{"AssertErrorCode 代码不同", func(tb testing.TB) {
+			AssertErrorCode(tb, &aether.Error{Code: aether.CodePanic}, aether.CodeParseError)
+		}},
+		{"AssertErrorCode 不是 *Error", func(tb testing.TB) {
+			AssertErrorCode(tb, errors.New("x"), aether.CodeParseError)
+		}},
+	}
+	for _, c := range cases {
+		if ok, _ := failed(t, c.fn); !ok {
+			t.Errorf("%s 没有报告失败", c.name)
+		}
+	}
+
+	if ok, msgs := failed(t, func(tb testing.TB) { AssertGlobal(tb, fake, "X", 1) }); ok {
+		t.Errorf("AssertGlobal 报告了失败: %v", msgs)
+	}
+}
+
+// TestHelpersWithEngine 测试辅助函数用于真实引擎
+func TestHelpersWithEngine(t *testing.T) {
+	if !aether.IsLibraryAvailable() {
+		t.Skip("Aether 库不可用")
+	}
+	engine := aether.New()
+	defer engine.Close()
+
+	if got := MustEval(t, engine, "Set X 10\nSet Y 20\n(X + Y)"); got != "30" {
+		t.Errorf("Eval = %q, 期望 30", got)
+	}
+	AssertGlobal(t, engine, "X", 10)
+
+	_, err := engine.Eval("(1 +")
+	if aether.CodeOf(err) == aether.CodeSuccess {
+		t.Error("语法错误没有返回错误")
+	}
+
+	MustEval(t, engine, `TRACE("calc", "hello")`)
+	records, err := engine.TraceRecords()
+	if err != nil || len(records) == 0 {
+		t.Fatalf("TraceRecords() = %v, %v", records, err)
+	}
+	AssertTrace(t, engine, records[0].Category, records[0].Values...)
+}
+
+// TestRunDir 测试用真实引擎执行 golden 脚本
+func TestRunDir(t *testing.T) {
+	if !aether.IsLibraryAvailable() {
+		t.Skip("Aether 库不可用")
+	}
+	RunDir(t, "testdata/rules")
+}
+
+// TestRunScript 测试脚本执行与输入变量
+func TestRunScript(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "rule.aether")
+	os.WriteFile(script, []byte("(A + B)\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "rule.input.json"), []byte(`{"B": 2, "A": 12345678901234567890}`), 0644)
+
+	label := "step"
+	fake := NewFake().On("(A + B)", Response{
+		Result: "3",
+		Traces: []aether.TraceEntry{{Level: "INFO", Category: "calc", Values: []string{"3"}, Label: &label}},
+	})
+	g, err := RunScript(fake, script)
+	if err != nil {
+		t.Fatalf("RunScript 失败: %v", err)
+	}
+	if g.Result != "3" || g.Error != nil || len(g.Trace) != 1 || *g.Trace[0].Label != "step" {
+		t.Errorf("RunScript = %+v", g)
+	}
+
+	calls := fake.Calls()
+	var methods []string
+	for _, c := range calls {
+		methods = append(methods, c.Method)
+	}
+	if strings.Join(methods, ",") != "SetGlobal,SetGlobal,Eval,TraceRecords" {
+		t.Errorf("调用顺序为 %v", methods)
+	}
+	if calls[0].Args[0] != "A" || fmt.Sprint(calls[0].Args[1]) != "12345678901234567890" {
+		t.Errorf("第一次 SetGlobal 为 %v, 期望按名称排序且保留整数精度", calls[0].Args)
+	}
+
+	// 执行期间写入标准输出的内容记录在 Output 中
+	printer := NewFake().Fallback(func(code string) (string, error) {
+		fmt.Println("hello")
+		return "1", nil
+	})
+	if g, err := RunScript(printer, script); err != nil {
+		t.Fatalf("RunScript 失败: %v", err)
+	} else if canCaptureStdout && g.Output != "hello\n" {
+		t.Errorf("Output = %q, 期望 %q", g.Output, "hello\n")
+	}
+
+	fake.Fails("(A + B)", aether.CodeParseError, "bad")
+	g, err = RunScript(fake, script)
+	if err != nil {
+		t.Fatalf("RunScript 失败: %v", err)
+	}
+	if g.Error == nil || g.Error.Code != "parse_error" || g.Error.Message != "aether: bad" {
+		t.Errorf("错误为 %+v", g.Error)
+	}
+
+	os.WriteFile(filepath.Join(dir, "rule.input.json"), []byte(`[1]`), 0644)
+	if _, err := RunScript(fake, script); err == nil {
+		t.Error("输入文件不是 JSON 对象时应返回错误")
+	}
+}
+
+// TestRunDirUpdate 测试 AETHER_UPDATE_GOLDEN 生成 golden 文件
+func TestRunDirUpdate(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	os.WriteFile(filepath.Join(dir, "a.aether"), []byte("1"), 0644)
+	os.WriteFile(filepath.Join(dir, "sub", "b.aether"), []byte("bad"), 0644)
+
+	newFake := func() aether.Evaluator {
+		return NewFake().Returns("1", "1").Fails("bad", aether.CodeRuntimeError, "boom")
+	}
+
+	t.Setenv(UpdateEnv, "1")
+	RunDirWith(t, dir, newFake)
+	t.Setenv(UpdateEnv, "")
+
+	data, err := os.ReadFile(filepath.Join(dir, "sub", "b.golden"))
+	if err != nil {
+		t.Fatalf("没有生成 golden 文件: %v", err)
+	}
+	if !strings.Contains(string(data), `"code": "runtime_error"`) {
+		t.Errorf("golden 文件内容为 %s", data)
+	}
+
+	RunDirWith(t, dir, newFake)
+}
+
+// TestDiffGolden 测试 golden 文件比较
+func TestDiffGolden(t *testing.T) {
+	want, _ := marshalGolden(&Golden{Result: "1", Trace: []GoldenTrace{}})
+	got, _ := marshalGolden(&Golden{Result: "2", Trace: []GoldenTrace{}})
+
+	if diff := diffGolden(want, want); diff != "" {
+		t.Errorf("相同内容的差异为 %q", diff)
+	}
+	crlf := []byte(strings.ReplaceAll(string(want), "\n", "\r\n"))
+	if diff := diffGolden(crlf, want); diff != "" {
+		t.Errorf("CRLF 内容的差异为 %q", diff)
+	}
+	diff := diffGolden(want, got)
+	if !strings.Contains(diff, `-   "result": "1"`) || !strings.Contains(diff, `+   "result": "2"`) {
+		t.Errorf("差异为 %q", diff)
+	}
+}
+
+// TestParseTestFile 测试解析测试函数
+func TestParseTestFile(t *testing.T) {
+	src := "Func TEST_A () {\n}\n  Func TEST_B() {\n}\nFunc HELPER () {\n}\n// Func TEST_C ()\nFunc TEST_A () {\n}\n"
+	f := ParseTestFile("x_test.aether", src)
+	if fmt.Sprint(f.Tests) != "[TEST_A TEST_B]" {
+		t.Errorf("Tests = %v, 期望 [TEST_A TEST_B]", f.Tests)
+	}
+}
+
+// TestDiscoverTests 测试查找测试文件
+func TestDiscoverTests(t *testing.T) {
+	files, err := DiscoverTests("testdata/unit/...")
+	if err != nil {
+		t.Fatalf("DiscoverTests 失败: %v", err)
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, filepath.ToSlash(f.Path))
+	}
+	want := "[testdata/unit/math_test.aether testdata/unit/nested/strings_test.aether]"
+	if fmt.Sprint(paths) != want {
+		t.Errorf("找到 %v, 期望 %s", paths, want)
+	}
+	if fmt.Sprint(files[0].Tests) != "[TEST_ADD TEST_ADD_NEGATIVE]" {
+		t.Errorf("Tests = %v", files[0].Tests)
+	}
+
+	files, err = DiscoverTests("testdata/unit/math_test.aether", "testdata/unit")
+	if err != nil || len(files) != 2 {
+		t.Errorf("重复的路径应只出现一次: %d 个文件, %v", len(files), err)
+	}
+
+	if _, err := DiscoverTests("testdata/unit/nested/helper.aether"); err == nil {
+		t.Error("不是 _test.aether 的文件应返回错误")
+	}
+	if _, err := DiscoverTests("testdata/missing"); err == nil {
+		t.Error("不存在的路径应返回错误")
+	}
+}
+
+// unitFake 返回模拟执行测试文件的 Fake
+func unitFake() *Fake {
+	return NewFake().
+		Fallback(func(string) (string, error) { return "null", nil }).
+		On("TEST_PASS()", Response{
+			Result: "null",
+			Traces: []aether.TraceEntry{{Level: "INFO", Category: "log", Values: []string{"hello"}}},
+		}).
+		On("TEST_FAIL()", Response{
+			Result: "null",
+			Traces: []aether.TraceEntry{{Level: "ERROR", Category: AssertCategory, Values: []string{"ASSERT_EQ: 实际", "1", "期望", "2"}}},
+		}).
+		Fails("TEST_ERROR()", aether.CodeRuntimeError, "Undefined variable: X")
+}
+
+// TestRunTests 测试执行测试函数
+func TestRunTests(t *testing.T) {
+	files := []*TestFile{ParseTestFile("a_test.aether",
+		"Func TEST_PASS () {\n}\nFunc TEST_FAIL () {\n}\nFunc TEST_ERROR () {\n}\n")}
+
+	fake := unitFake()
+	report := RunTests(fake, files, nil)
+	if report.Passed != 1 || report.Failed != 2 || report.OK() {
+		t.Fatalf("Passed = %d, Failed = %d", report.Passed, report.Failed)
+	}
+
+	pass, fail, errRes := report.Results[0], report.Results[1], report.Results[2]
+	if !pass.Passed || fmt.Sprint(pass.Output) != "[[INFO] log: hello]" {
+		t.Errorf("TEST_PASS = %+v", pass)
+	}
+	if fail.Passed || fmt.Sprint(fail.Failures) != "[ASSERT_EQ: 实际 1 期望 2]" {
+		t.Errorf("TEST_FAIL = %+v", fail)
+	}
+	if errRes.Passed || !strings.Contains(errRes.Failures[0], "Undefined variable: X") {
+		t.Errorf("TEST_ERROR = %+v", errRes)
+	}
+	for _, r := range report.Results {
+		if r.Duration <= 0 {
+			t.Errorf("%s 的耗时为 %v", r.Name, r.Duration)
+		}
+	}
+
+	var methods []string
+	for _, c := range fake.Calls()[:6] {
+		methods = append(methods, c.Method)
+	}
+	if strings.Join(methods, ",") != "ResetEnv,ClearTrace,Eval,Eval,Eval,TraceRecords" {
+		t.Errorf("调用顺序为 %v", methods)
+	}
+	if evals := fake.Evals(); evals[0] != Prelude || evals[2] != "TEST_PASS()" {
+		t.Errorf("Eval 顺序为 %q", evals[:3])
+	}
+
+	report = RunTests(unitFake(), files, regexp.MustCompile("PASS"))
+	if len(report.Results) != 1 || !report.OK() {
+		t.Errorf("-run 过滤后的结果为 %+v", report.Results)
+	}
+
+	fake = unitFake().FailMethod("ResetEnv", aether.CodeNullPointer)
+	report = RunTests(fake, files[:1], regexp.MustCompile("PASS"))
+	if report.OK() || !strings.Contains(report.Results[0].Failures[0], "ResetEnv") {
+		t.Errorf("ResetEnv 失败时的结果为 %+v", report.Results)
+	}
+}
+
+// TestTestReportFormats 测试文本、JSON 与 JUnit 输出
+func TestTestReportFormats(t *testing.T) {
+	files := []*TestFile{
+		ParseTestFile("a_test.aether", "Func TEST_PASS () {\n}\nFunc TEST_FAIL () {\n}\n"),
+		ParseTestFile("b_test.aether", "Func HELPER () {\n}\n"),
+	}
+	report := RunTests(unitFake(), files, nil)
+
+	var text bytes.Buffer
+	report.WriteText(&text, files, false)
+	out := text.String()
+	for _, want := range []string{"--- FAIL: TEST_FAIL", "    ASSERT_EQ: 实际 1 期望 2", "FAIL\ta_test.aether", "?   \tb_test.aether\t[no test functions]", "\nFAIL\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("文本输出缺少 %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "TEST_PASS") {
+		t.Errorf("非 -v 输出不应包含通过的测试:\n%s", out)
+	}
+
+	text.Reset()
+	report.WriteText(&text, files, true)
+	if !strings.Contains(text.String(), "=== RUN   TEST_PASS\n--- PASS: TEST_PASS") ||
+		!strings.Contains(text.String(), "    [INFO] log: hello") {
+		t.Errorf("-v 输出为:\n%s", text.String())
+	}
+
+	var js bytes.Buffer
+	if err := report.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	var decoded TestReport
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil || decoded.Failed != 1 || len(decoded.Results) != 2 {
+		t.Errorf("JSON 输出为 %s, %v", js.String(), err)
+	}
+
+	var junit bytes.Buffer
+	if err := report.WriteJUnit(&junit); err != nil {
+		t.Fatal(err)
+	}
+	var suites junitSuites
+	if err := xml.Unmarshal(junit.Bytes(), &suites); err != nil {
+		t.Fatalf("JUnit 输出不是有效的 XML: %v\n%s", err, junit.String())
+	}
+	if suites.Tests != 2 || suites.Failures != 1 || len(suites.Suites) != 1 {
+		t.Fatalf("JUnit 输出为 %s", junit.String())
+	}
+	c := suites.Suites[0].Cases[1]
+	if c.Name != "TEST_FAIL" || c.Classname != "a" || c.Failure == nil || !strings.Contains(c.Failure.Message, "ASSERT_EQ") {
+		t.Errorf("testcase = %+v", c)
+	}
+}
+
+// TestCheckLeaks 测试检查未关闭的引擎
+func TestCheckLeaks(t *testing.T) {
+	if !aether.DebugEnabled {
+		t.Skip("需要 -tags aether_debug")
+	}
+	if !aether.IsLibraryAvailable() {
+		t.Skip("Aether 库不可用")
+	}
+
+	var leaked *aether.Engine
+	ok, msgs := failed(t, func(tb testing.TB) {
+		CheckLeaks(tb)
+		leaked = aether.New()
+		leaked.Eval("(1 + 2)")
+	})
+	leaked.Close()
+	if !ok || len(msgs) != 1 || !strings.Contains(msgs[0], "引擎句柄") || !strings.Contains(msgs[0], "TestCheckLeaks") {
+		t.Errorf("未关闭的引擎应报告一次泄漏及其调用栈, 实际为 %q", msgs)
+	}
+
+	if ok, msgs := failed(t, func(tb testing.TB) {
+		CheckLeaks(tb)
+		engine := aether.New()
+		defer engine.Close()
+		engine.SetGlobal("X", 1)
+		engine.Eval("(X + 1)")
+		engine.GetGlobal("X")
+		engine.TraceRecords()
+	}); ok {
+		t.Errorf("没有泄漏时报告了失败: %q", msgs)
+	}
+}
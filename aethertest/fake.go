@@ -0,0 +1,34 @@
+// Package aethertest 提供测试 aether.Evaluator 调用方的工具
+//
+// Fake 是可编排的 Evaluator 替身,按脚本返回预设的结果、记录所有调用,
+// 并可以按 aether.ErrorCode 注入错误:
+//
+//	fake := aethertest.NewFake().
+//	    Returns(`(price * 0.9)`, "90").
+//	    Fails(`(1 +`, aether.CodeParseError, "unexpected end of input")
+//
+//	got, err := ApplyDiscount(fake, order) // 接受 aether.Evaluator 的业务代码
+//
+//	if evals := fake.Evals(); len(evals) != 1 { ... }
+//
+// MustEval、AssertGlobal、AssertTrace 等辅助函数对真实引擎与 Fake 都适用。
+//
+// 引用本包的默认构建需要链接 libaether.a;只使用 Fake 的测试可以直接引用
+// aetherfake,它不依赖 cgo,构建时不需要原生库。
+package aethertest
+
+import "github.com/xiaozuhui/aether-go/aetherfake"
+
+// Fake 是可编排的 aether.Evaluator 替身,即 aetherfake.Fake
+type Fake = aetherfake.Fake
+
+// Response 为 Fake 对某个脚本的预设响应
+type Response = aetherfake.Response
+
+// Call 为 Fake 记录的一次方法调用
+type Call = aetherfake.Call
+
+// NewFake 创建没有任何预设响应的 Fake
+func NewFake() *Fake {
+	return aetherfake.New()
+}
@@ -0,0 +1,297 @@
+package aethertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+// UpdateEnv 为控制 golden 文件更新的环境变量,设置为 1 时 RunDir 重写 golden 文件而不是比较
+const UpdateEnv = "AETHER_UPDATE_GOLDEN"
+
+// stdoutMu 保证同一时间只有一个脚本的标准输出被捕获
+var stdoutMu sync.Mutex
+
+// updateGolden 返回是否应重写 golden 文件
+func updateGolden() bool {
+	update, _ := strconv.ParseBool(os.Getenv(UpdateEnv))
+	return update
+}
+
+// 脚本及其附属文件的扩展名
+const (
+	scriptExt = ".aether"
+	inputExt  = ".input.json"
+	goldenExt = ".golden"
+)
+
+// Golden 为 golden 文件的内容,即一个脚本的执行结果
+type Golden struct {
+	// Result 为 Eval 的返回值,出错时为空
+	Result string `json:"result"`
+	// Output 为执行期间写入标准输出的内容,如 Print 的输出。原生库的标准输出按行缓冲,
+	// 不以换行结尾的内容可能没有被记录;不支持捕获标准输出的平台(Windows)上不比较
+	Output string `json:"output,omitempty"`
+	// Error 为 Eval 返回的错误,成功时为 nil
+	Error *GoldenError `json:"error,omitempty"`
+	// Trace 为执行期间产生的追踪条目,不含时间戳
+	Trace []GoldenTrace `json:"trace"`
+
+	// skipOutput 为 true 时不捕获标准输出,比较时忽略 Output
+	skipOutput bool
+}
+
+// GoldenError 为 golden 文件中记录的错误
+type GoldenError struct {
+	// Code 为 aether.ErrorCode 的名称,如 "parse_error"
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// GoldenTrace 为 golden 文件中记录的追踪条目
+type GoldenTrace struct {
+	Level    string   `json:"level"`
+	Category string   `json:"category"`
+	Values   []string `json:"values"`
+	Label    *string  `json:"label,omitempty"`
+}
+
+// RunDir 以子测试的形式执行目录 dir(包括子目录)中的所有 *.aether 脚本,
+// 并与同名的 *.golden 文件比较。*_test.aether 文件由 RunTests 执行,RunDir 跳过它们
+//
+// 脚本 rules/discount.aether 的附属文件为:
+//
+//	rules/discount.input.json  可选,JSON 对象,每个键通过 SetGlobal 设置为变量
+//	rules/discount.golden      期望的结果、输出、错误与追踪条目,格式见 Golden
+//
+// 每个脚本使用新创建的引擎执行,只调用 SetGlobal、Eval 与 TraceRecords。
+// 设置环境变量 AETHER_UPDATE_GOLDEN=1 运行 go test 时重写 golden 文件:
+//
+//	func TestRules(t *testing.T) {
+//	    aethertest.RunDir(t, "testdata/rules")
+//	}
+func RunDir(t *testing.T, dir string) {
+	t.Helper()
+	RunDirWith(t, dir, nil)
+}
+
+// RunDirWith 与 RunDir 相同,但使用 newEvaluator 为每个脚本创建引擎
+//
+// newEvaluator 为 nil 时使用 aether.New。RunDirWith 在脚本执行完毕后关闭引擎
+func RunDirWith(t *testing.T, dir string, newEvaluator func() aether.Evaluator) {
+	t.Helper()
+	if newEvaluator == nil {
+		newEvaluator = func() aether.Evaluator { return aether.New() }
+	}
+
+	scripts, err := findScripts(dir)
+	if err != nil {
+		t.Fatalf("无法读取脚本目录: %v", err)
+	}
+	if len(scripts) == 0 {
+		t.Fatalf("%s 中没有 %s 脚本", dir, scriptExt)
+	}
+
+	for _, script := range scripts {
+		script := script
+		name := strings.TrimSuffix(filepath.ToSlash(mustRel(dir, script)), scriptExt)
+		t.Run(name, func(t *testing.T) {
+			ev := newEvaluator()
+			defer ev.Close()
+
+			got, err := RunScript(ev, script)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, goldenPath(script), got)
+		})
+	}
+}
+
+// RunScript 在 ev 中执行脚本文件,返回应写入 golden 文件的结果
+//
+// 附属的 *.input.json 存在时先设置其中的变量。脚本返回的错误记录在 Golden.Error 中,
+// 执行期间写入标准输出的内容记录在 Golden.Output 中。
+// 只有读取文件、设置变量或捕获标准输出失败时才返回错误
+func RunScript(ev aether.Evaluator, script string) (*Golden, error) {
+	code, err := os.ReadFile(script)
+	if err != nil {
+		return nil, err
+	}
+	if err := setInputs(ev, inputPath(script)); err != nil {
+		return nil, err
+	}
+
+	g := &Golden{Trace: []GoldenTrace{}, skipOutput: !canCaptureStdout}
+	var result string
+	var evalErr error
+	g.Output, err = captureStdout(func() {
+		result, evalErr = ev.Eval(string(code))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := evalErr; err != nil {
+		g.Error = &GoldenError{Code: aether.CodeOf(err).String(), Message: err.Error()}
+	} else {
+		g.Result = result
+	}
+
+	records, err := ev.TraceRecords()
+	if err != nil {
+		return nil, fmt.Errorf("无法读取追踪条目: %w", err)
+	}
+	for _, r := range records {
+		g.Trace = append(g.Trace, GoldenTrace{Level: r.Level, Category: r.Category, Values: r.Values, Label: r.Label})
+	}
+	return g, nil
+}
+
+// setInputs 按键名顺序设置输入文件中的变量,文件不存在时不做任何事
+func setInputs(ev aether.Evaluator, path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var inputs map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&inputs); err != nil {
+		return fmt.Errorf("%s 不是 JSON 对象: %w", path, err)
+	}
+
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := ev.SetGlobal(name, inputs[name]); err != nil {
+			return fmt.Errorf("无法设置变量 %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// checkGolden 比较 got 与 golden 文件,设置 AETHER_UPDATE_GOLDEN=1 时重写文件
+func checkGolden(t *testing.T, path string, got *Golden) {
+	t.Helper()
+	want, readErr := os.ReadFile(path)
+	if readErr != nil && !errors.Is(readErr, fs.ErrNotExist) {
+		t.Fatal(readErr)
+	}
+
+	if got.skipOutput && readErr == nil {
+		// 没有捕获标准输出时沿用 golden 文件中的输出
+		var g Golden
+		if err := json.Unmarshal(want, &g); err == nil {
+			got.Output = g.Output
+		}
+	}
+	data, err := marshalGolden(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updateGolden() {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	if readErr != nil {
+		t.Fatalf("%s 不存在,设置 %s=1 运行 go test 生成", path, UpdateEnv)
+	}
+	if diff := diffGolden(want, data); diff != "" {
+		t.Errorf("结果与 %s 不一致 (-期望 +实际):\n%s\n如果修改是预期的,设置 %s=1 运行 go test 更新", path, diff, UpdateEnv)
+	}
+}
+
+// marshalGolden 以稳定的格式序列化 golden 内容
+func marshalGolden(g *Golden) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(g); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// diffGolden 逐行比较两个 golden 文件,相同时返回空字符串
+//
+// 比较忽略行尾的 \r,使在 Windows 上检出的文件也能通过
+func diffGolden(want, got []byte) string {
+	wantLines := strings.Split(strings.ReplaceAll(string(want), "\r\n", "\n"), "\n")
+	gotLines := strings.Split(string(got), "\n")
+
+	var b strings.Builder
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w == g {
+			continue
+		}
+		if i < len(wantLines) {
+			fmt.Fprintf(&b, "%d: - %s\n", i+1, w)
+		}
+		if i < len(gotLines) {
+			fmt.Fprintf(&b, "%d: + %s\n", i+1, g)
+		}
+	}
+	return b.String()
+}
+
+// findScripts 返回 dir 中所有脚本的路径,按路径排序
+func findScripts(dir string) ([]string, error) {
+	var scripts []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, scriptExt) && !strings.HasSuffix(path, TestFileSuffix) {
+			scripts = append(scripts, path)
+		}
+		return nil
+	})
+	sort.Strings(scripts)
+	return scripts, err
+}
+
+func inputPath(script string) string {
+	return strings.TrimSuffix(script, scriptExt) + inputExt
+}
+
+func goldenPath(script string) string {
+	return strings.TrimSuffix(script, scriptExt) + goldenExt
+}
+
+func mustRel(base, path string) string {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
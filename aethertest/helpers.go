@@ -0,0 +1,111 @@
+package aethertest
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+// MustEval 执行代码并返回结果,出错时终止测试
+func MustEval(t testing.TB, e aether.Evaluator, code string) string {
+	t.Helper()
+	result, err := e.Eval(code)
+	if err != nil {
+		t.Fatalf("Eval(%q) 失败: %v", summarize(code), err)
+	}
+	return result
+}
+
+// AssertGlobal 断言变量 name 的值等于 want
+//
+// want 与变量的值都经过 JSON 往返后比较,因此 AssertGlobal(t, e, "X", 30)
+// 与引擎返回的 float64(30) 相等
+func AssertGlobal(t testing.TB, e aether.Evaluator, name string, want interface{}) {
+	t.Helper()
+	got, err := e.GetGlobal(name)
+	if err != nil {
+		t.Errorf("GetGlobal(%q) 失败: %v", name, err)
+		return
+	}
+	if !reflect.DeepEqual(normalize(got), normalize(want)) {
+		t.Errorf("变量 %s = %s, 期望 %s", name, toJSON(got), toJSON(want))
+	}
+}
+
+// AssertTrace 断言类别为 category 的追踪条目的值依次为 values
+//
+// 每个条目的 Values 用空格连接后与 values 中对应的字符串比较。
+// values 为空时断言该类别没有任何条目
+func AssertTrace(t testing.TB, e aether.Evaluator, category string, values ...string) {
+	t.Helper()
+	records, err := e.TraceRecords()
+	if err != nil {
+		t.Errorf("TraceRecords() 失败: %v", err)
+		return
+	}
+
+	var got []string
+	for _, r := range records {
+		if r.Category == category {
+			got = append(got, strings.Join(r.Values, " "))
+		}
+	}
+	if len(got) == 0 && len(values) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Errorf("类别 %s 的追踪条目为 %q, 期望 %q", category, got, values)
+	}
+}
+
+// AssertErrorCode 断言 err 为错误代码是 want 的 *aether.Error
+func AssertErrorCode(t testing.TB, err error, want aether.ErrorCode) {
+	t.Helper()
+	if err == nil {
+		t.Errorf("期望错误代码 %s, 实际没有错误", want)
+		return
+	}
+	var aerr *aether.Error
+	if !errors.As(err, &aerr) {
+		t.Errorf("期望错误代码 %s, 实际错误不是 *aether.Error: %v", want, err)
+		return
+	}
+	if aerr.Code != want {
+		t.Errorf("错误代码为 %s, 期望 %s: %v", aerr.Code, want, err)
+	}
+}
+
+// summarize 截断过长的代码,使失败信息保持可读
+func summarize(code string) string {
+	code = strings.TrimSpace(code)
+	if len(code) > 60 {
+		return code[:60] + "..."
+	}
+	return code
+}
+
+// toJSON 返回 v 的 JSON 表示,用于失败信息
+func toJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "<无法序列化>"
+	}
+	return string(data)
+}
+
+// normalize 对 v 做 JSON 往返,使其与从引擎读取的值类型一致
+func normalize(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package aethertest
+
+import (
+	"testing"
+
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+// CheckLeaks 在测试结束时检查测试期间创建的引擎句柄与 C 字符串是否都已释放,
+// 未释放时报告它们的创建调用栈并使测试失败
+//
+// 需要使用 go test -tags aether_debug 运行,否则只记录一条日志。应在测试开始时调用,
+// 使其在其他 t.Cleanup 之后执行。进程内的所有分配都会被检查,不要与 t.Parallel 一起使用:
+//
+//	func TestRender(t *testing.T) {
+//	    aethertest.CheckLeaks(t)
+//	    engine := aether.New()
+//	    defer engine.Close()
+//	    ...
+//	}
+func CheckLeaks(t testing.TB) {
+	t.Helper()
+	if !aether.DebugEnabled {
+		t.Log("aethertest: CheckLeaks 需要 -tags aether_debug,本次不检查泄漏")
+		return
+	}
+
+	before := make(map[uint64]bool)
+	for _, a := range liveAllocations() {
+		before[a.ID] = true
+	}
+
+	t.Cleanup(func() {
+		t.Helper()
+		for _, a := range liveAllocations() {
+			if before[a.ID] {
+				continue
+			}
+			switch a.Kind {
+			case "handle":
+				t.Errorf("引擎句柄 #%d 没有关闭,创建于:\n%s", a.ID, a.Stack)
+			default:
+				t.Errorf("C 字符串 #%d 没有释放,创建于:\n%s", a.ID, a.Stack)
+			}
+		}
+	})
+}
+
+func liveAllocations() []aether.Allocation {
+	return append(aether.DebugLiveHandles(), aether.DebugLiveStrings()...)
+}
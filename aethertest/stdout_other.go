@@ -0,0 +1,12 @@
+//go:build !unix
+
+package aethertest
+
+// canCaptureStdout 表示此平台能否捕获原生库写入标准输出的内容
+const canCaptureStdout = false
+
+// captureStdout 执行 fn,此平台上不捕获标准输出
+func captureStdout(fn func()) (string, error) {
+	fn()
+	return "", nil
+}
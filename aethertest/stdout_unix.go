@@ -0,0 +1,68 @@
+//go:build unix
+
+package aethertest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// canCaptureStdout 表示此平台能否捕获原生库写入标准输出的内容
+const canCaptureStdout = true
+
+// captureStdout 执行 fn 并返回其间写入文件描述符 1 的内容
+//
+// 原生库直接写入文件描述符 1,只替换 os.Stdout 无法捕获,因此在执行期间把它指向管道
+func captureStdout(fn func()) (string, error) {
+	stdoutMu.Lock()
+	defer stdoutMu.Unlock()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", fmt.Errorf("无法捕获标准输出: %w", err)
+	}
+	defer r.Close()
+
+	saved, err := unix.Dup(1)
+	if err != nil {
+		w.Close()
+		return "", fmt.Errorf("无法复制标准输出: %w", err)
+	}
+	defer unix.Close(saved)
+	if err := unix.Dup2(int(w.Fd()), 1); err != nil {
+		w.Close()
+		return "", fmt.Errorf("无法重定向标准输出: %w", err)
+	}
+
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		io.Copy(&buf, r)
+	}()
+
+	// fn panic 时也要恢复文件描述符 1;关闭写端后读取协程在读完管道后退出
+	restored := false
+	restore := func() error {
+		restored = true
+		err := unix.Dup2(saved, 1)
+		w.Close()
+		<-done
+		return err
+	}
+	defer func() {
+		if !restored {
+			restore()
+		}
+	}()
+
+	fn()
+	if err := restore(); err != nil {
+		return "", fmt.Errorf("无法恢复标准输出: %w", err)
+	}
+	return buf.String(), nil
+}
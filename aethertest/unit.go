@@ -0,0 +1,371 @@
+package aethertest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+// TestFileSuffix 为 Aether 单元测试文件的后缀
+const TestFileSuffix = "_test.aether"
+
+// AssertCategory 为断言失败时写入的追踪类别
+const AssertCategory = "aether.test"
+
+// Prelude 为每个测试执行前定义的断言函数
+//
+// aether.h 没有注册宿主函数的接口,因此断言以 Aether 函数实现:
+// 失败时写入类别为 AssertCategory 的 TRACE_ERROR 条目,由 RunTests 收集
+const Prelude = `Func ASSERT_EQ (ACTUAL, EXPECTED) {
+    If (ACTUAL != EXPECTED) {
+        TRACE_ERROR("aether.test", "ASSERT_EQ: 实际", ACTUAL, "期望", EXPECTED)
+    }
+    Return (ACTUAL == EXPECTED)
+}
+
+Func ASSERT_TRUE (COND) {
+    If (COND != True) {
+        TRACE_ERROR("aether.test", "ASSERT_TRUE: 实际", COND)
+    }
+    Return (COND == True)
+}
+`
+
+// testFuncPattern 匹配行首的 Func TEST_XXX 定义
+var testFuncPattern = regexp.MustCompile(`(?m)^[ \t]*Func[ \t]+(TEST_[A-Za-z0-9_]*)[ \t]*\(`)
+
+// TestFile 为一个 *_test.aether 文件
+type TestFile struct {
+	// Path 为文件路径
+	Path string
+	// Source 为文件内容
+	Source string
+	// Tests 为文件中的测试函数名,按定义顺序排列
+	Tests []string
+}
+
+// TestResult 为一个测试函数的执行结果
+type TestResult struct {
+	File   string `json:"file"`
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	// Duration 为执行耗时,单位为纳秒
+	Duration time.Duration `json:"duration_ns"`
+	// Failures 为断言失败或执行错误的描述
+	Failures []string `json:"failures,omitempty"`
+	// Output 为测试产生的其他追踪条目
+	Output []string `json:"output,omitempty"`
+}
+
+// TestReport 为 RunTests 的结果
+type TestReport struct {
+	Results []TestResult `json:"results"`
+	// Duration 为总耗时,单位为纳秒
+	Duration time.Duration `json:"duration_ns"`
+	Passed   int           `json:"passed"`
+	Failed   int           `json:"failed"`
+}
+
+// OK 返回是否所有测试都通过
+func (r *TestReport) OK() bool {
+	return r.Failed == 0
+}
+
+// DiscoverTests 在 paths 中查找 *_test.aether 文件并解析其中的测试函数
+//
+// paths 中的目录递归查找,以 /... 结尾的路径与目录相同;文件必须以 TestFileSuffix 结尾。
+// 返回的文件按路径排序
+func DiscoverTests(paths ...string) ([]*TestFile, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	seen := make(map[string]bool)
+	var files []*TestFile
+	add := func(path string) error {
+		if seen[path] {
+			return nil
+		}
+		seen[path] = true
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, ParseTestFile(path, string(data)))
+		return nil
+	}
+
+	for _, p := range paths {
+		p = strings.TrimSuffix(strings.TrimSuffix(p, "..."), string(filepath.Separator))
+		p = strings.TrimSuffix(p, "/")
+		if p == "" {
+			p = "."
+		}
+
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			if !strings.HasSuffix(p, TestFileSuffix) {
+				return nil, fmt.Errorf("%s 不是 %s 文件", p, TestFileSuffix)
+			}
+			if err := add(p); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		err = filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && path != p && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && strings.HasSuffix(path, TestFileSuffix) {
+				return add(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// ParseTestFile 解析源代码中的测试函数
+func ParseTestFile(path, source string) *TestFile {
+	f := &TestFile{Path: path, Source: source}
+	seen := make(map[string]bool)
+	for _, m := range testFuncPattern.FindAllStringSubmatch(source, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			f.Tests = append(f.Tests, m[1])
+		}
+	}
+	return f
+}
+
+// RunTests 在 ev 中依次执行所有测试函数
+//
+// 每个测试之前调用 ResetEnv 与 ClearTrace,然后依次执行 Prelude、测试文件与测试函数,
+// 因此测试之间不共享变量。run 不为 nil 时只执行名称匹配的测试
+func RunTests(ev aether.Evaluator, files []*TestFile, run *regexp.Regexp) *TestReport {
+	report := &TestReport{Results: []TestResult{}}
+	start := time.Now()
+
+	for _, f := range files {
+		for _, name := range f.Tests {
+			if run != nil && !run.MatchString(name) {
+				continue
+			}
+			r := runTest(ev, f, name)
+			if r.Passed {
+				report.Passed++
+			} else {
+				report.Failed++
+			}
+			report.Results = append(report.Results, r)
+		}
+	}
+
+	report.Duration = time.Since(start)
+	return report
+}
+
+// runTest 执行一个测试函数
+//
+// 使用具名返回值,使 defer 中记录的耗时写入返回的结果
+func runTest(ev aether.Evaluator, f *TestFile, name string) (r TestResult) {
+	r = TestResult{File: f.Path, Name: name}
+	start := time.Now()
+	defer func() { r.Duration = time.Since(start) }()
+
+	if err := ev.ResetEnv(); err != nil {
+		r.Failures = append(r.Failures, "ResetEnv 失败: "+err.Error())
+		return r
+	}
+	if err := ev.ClearTrace(); err != nil {
+		r.Failures = append(r.Failures, "ClearTrace 失败: "+err.Error())
+		return r
+	}
+
+	steps := []struct{ what, code string }{
+		{"断言函数", Prelude},
+		{"测试文件", f.Source},
+		{name, name + "()"},
+	}
+	for _, s := range steps {
+		if _, err := ev.Eval(s.code); err != nil {
+			r.Failures = append(r.Failures, fmt.Sprintf("执行%s失败: %v", s.what, err))
+			break
+		}
+	}
+
+	records, err := ev.TraceRecords()
+	if err != nil {
+		r.Failures = append(r.Failures, "TraceRecords 失败: "+err.Error())
+	}
+	for _, rec := range records {
+		line := strings.Join(rec.Values, " ")
+		if rec.Category == AssertCategory {
+			r.Failures = append(r.Failures, line)
+		} else {
+			r.Output = append(r.Output, fmt.Sprintf("[%s] %s: %s", rec.Level, rec.Category, line))
+		}
+	}
+
+	r.Passed = len(r.Failures) == 0
+	return r
+}
+
+// WriteText 以类似 go test 的格式输出结果
+//
+// verbose 为 false 时只输出失败的测试与每个文件的汇总
+func (r *TestReport) WriteText(w io.Writer, files []*TestFile, verbose bool) {
+	byFile := make(map[string][]TestResult)
+	for _, res := range r.Results {
+		byFile[res.File] = append(byFile[res.File], res)
+	}
+
+	for _, f := range files {
+		results := byFile[f.Path]
+		if len(f.Tests) == 0 {
+			fmt.Fprintf(w, "?   \t%s\t[no test functions]\n", f.Path)
+			continue
+		}
+		if len(results) == 0 {
+			fmt.Fprintf(w, "ok  \t%s\t0.000s [no tests to run]\n", f.Path)
+			continue
+		}
+
+		ok := true
+		var total time.Duration
+		for _, res := range results {
+			total += res.Duration
+			if verbose {
+				fmt.Fprintf(w, "=== RUN   %s\n", res.Name)
+			}
+			if res.Passed && !verbose {
+				continue
+			}
+			status := "PASS"
+			if !res.Passed {
+				status = "FAIL"
+				ok = false
+			}
+			fmt.Fprintf(w, "--- %s: %s (%.2fs)\n", status, res.Name, res.Duration.Seconds())
+			for _, line := range append(res.Failures, res.Output...) {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+		}
+
+		if ok {
+			fmt.Fprintf(w, "ok  \t%s\t%.3fs\n", f.Path, total.Seconds())
+		} else {
+			fmt.Fprintf(w, "FAIL\t%s\t%.3fs\n", f.Path, total.Seconds())
+		}
+	}
+
+	if r.OK() {
+		fmt.Fprintln(w, "PASS")
+	} else {
+		fmt.Fprintln(w, "FAIL")
+	}
+}
+
+// WriteJSON 以 JSON 输出结果
+func (r *TestReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// junitSuites 等为 JUnit XML 的结构
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit 以 JUnit XML 输出结果,每个测试文件为一个 testsuite
+func (r *TestReport) WriteJUnit(w io.Writer) error {
+	seconds := func(d time.Duration) string { return fmt.Sprintf("%.3f", d.Seconds()) }
+
+	doc := junitSuites{Tests: len(r.Results), Failures: r.Failed, Time: seconds(r.Duration)}
+	index := make(map[string]int)
+	var durations []time.Duration
+	for _, res := range r.Results {
+		i, ok := index[res.File]
+		if !ok {
+			i = len(doc.Suites)
+			index[res.File] = i
+			doc.Suites = append(doc.Suites, junitSuite{Name: res.File})
+			durations = append(durations, 0)
+		}
+		s := &doc.Suites[i]
+
+		c := junitCase{
+			Name:      res.Name,
+			Classname: strings.TrimSuffix(filepath.ToSlash(res.File), TestFileSuffix),
+			Time:      seconds(res.Duration),
+			SystemOut: strings.Join(res.Output, "\n"),
+		}
+		if !res.Passed {
+			c.Failure = &junitFailure{Message: res.Failures[0], Text: strings.Join(res.Failures, "\n")}
+			s.Failures++
+		}
+		s.Tests++
+		s.Cases = append(s.Cases, c)
+		durations[i] += res.Duration
+		s.Time = seconds(durations[i])
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
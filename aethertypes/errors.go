@@ -0,0 +1,83 @@
+package aethertypes
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode 对应原生库返回的 AetherErrorCode
+type ErrorCode int
+
+const (
+	// CodeSuccess 执行成功
+	CodeSuccess ErrorCode = 0
+	// CodeParseError 解析错误
+	CodeParseError ErrorCode = 1
+	// CodeRuntimeError 运行时错误
+	CodeRuntimeError ErrorCode = 2
+	// CodeNullPointer 空指针(引擎已关闭等)
+	CodeNullPointer ErrorCode = 3
+	// CodePanic 原生库内部 panic
+	CodePanic ErrorCode = 4
+	// CodeInvalidJSON 无效的 JSON
+	CodeInvalidJSON ErrorCode = 5
+	// CodeVariableNotFound 变量未找到
+	CodeVariableNotFound ErrorCode = 6
+)
+
+// String 返回错误代码的 snake_case 名称
+func (c ErrorCode) String() string {
+	switch c {
+	case CodeSuccess:
+		return "success"
+	case CodeParseError:
+		return "parse_error"
+	case CodeRuntimeError:
+		return "runtime_error"
+	case CodeNullPointer:
+		return "null_pointer"
+	case CodePanic:
+		return "panic"
+	case CodeInvalidJSON:
+		return "invalid_json"
+	case CodeVariableNotFound:
+		return "variable_not_found"
+	default:
+		return fmt.Sprintf("code_%d", int(c))
+	}
+}
+
+// Error 表示带有错误代码的 Aether 错误
+//
+// 使用 errors.As 获取错误代码:
+//
+//	var aerr *aether.Error
+//	if errors.As(err, &aerr) && aerr.Code == aether.CodeParseError {
+//	    // 语法错误
+//	}
+type Error struct {
+	Code    ErrorCode
+	Message string
+}
+
+// Error 实现 error 接口
+func (e *Error) Error() string {
+	return "aether: " + e.Message
+}
+
+// ErrClosed 在引擎关闭后调用其方法时返回
+var ErrClosed = &Error{Code: CodeNullPointer, Message: "引擎已关闭"}
+
+// CodeOf 返回错误对应的错误代码
+//
+// err 为 nil 时返回 CodeSuccess; 不是 *Error 的错误视为 CodeRuntimeError
+func CodeOf(err error) ErrorCode {
+	if err == nil {
+		return CodeSuccess
+	}
+	var aerr *Error
+	if errors.As(err, &aerr) {
+		return aerr.Code
+	}
+	return CodeRuntimeError
+}
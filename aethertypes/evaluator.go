@@ -0,0 +1,39 @@
+package aethertypes
+
+// Evaluator 是 Aether 引擎的方法集
+//
+// aether.Evaluator 是本接口的别名,*aether.Engine、*aether.Pool 与 aetherfake.Fake
+// 都实现了它
+type Evaluator interface {
+	// Eval 执行代码并返回结果字符串
+	Eval(code string) (string, error)
+
+	// SetGlobal 设置变量,值被序列化为 JSON
+	SetGlobal(name string, value interface{}) error
+	// GetGlobal 获取变量的值
+	GetGlobal(name string) (interface{}, error)
+	// ResetEnv 清除所有变量
+	ResetEnv() error
+
+	// TakeTrace 返回所有追踪条目
+	TakeTrace() ([]string, error)
+	// TraceRecords 返回结构化的追踪条目
+	TraceRecords() ([]TraceEntry, error)
+	// TraceStats 返回追踪统计信息
+	TraceStats() (*TraceStats, error)
+	// ClearTrace 清空追踪缓冲区
+	ClearTrace() error
+
+	// SetExecutionLimits 设置执行限制
+	SetExecutionLimits(limits Limits) error
+	// GetExecutionLimits 获取当前的执行限制
+	GetExecutionLimits() (*Limits, error)
+
+	// ClearCache 清空缓存
+	ClearCache() error
+	// CacheStats 返回缓存统计信息
+	CacheStats() (*CacheStats, error)
+
+	// Close 释放资源,可以多次调用
+	Close()
+}
@@ -0,0 +1,44 @@
+// Package aethertypes 定义 aether 包的公共类型与 Evaluator 接口,不依赖 cgo
+//
+// 根包 aether 以类型别名导出这些类型,使用时通常直接引用 aether.Limits 等名字。
+// 只需要这些类型的包(例如 aetherfake)引用本包,构建时不需要原生库与 C 编译器
+package aethertypes
+
+// Limits 控制执行约束
+type Limits struct {
+	// 最大执行步数 (-1 表示无限制)
+	MaxSteps int
+	// 最大递归深度 (-1 表示无限制)
+	MaxRecursionDepth int
+	// 最大执行时间(毫秒, -1 表示无限制)
+	MaxDurationMs int
+}
+
+// CacheStats 表示缓存统计信息
+type CacheStats struct {
+	Hits   int
+	Misses int
+	Size   int
+	// Evictions 为因容量或 TTL 清空缓存时丢弃的条目数,仅在设置 aether.CacheConfig 后统计
+	Evictions int
+	// Bytes 为自上次清空以来执行过的脚本源码的总字节数,仅在设置 aether.CacheConfig 后统计
+	Bytes int
+}
+
+// TraceStats 表示追踪统计信息
+type TraceStats struct {
+	TotalEntries int            `json:"total_entries"`
+	ByLevel      map[string]int `json:"by_level"`
+	ByCategory   map[string]int `json:"by_category"`
+	BufferSize   int            `json:"buffer_size"`
+	BufferFull   bool           `json:"buffer_full"`
+}
+
+// TraceEntry 表示单个追踪条目
+type TraceEntry struct {
+	Level     string   `json:"level"`
+	Category  string   `json:"category"`
+	Timestamp int64    `json:"timestamp"`
+	Values    []string `json:"values"`
+	Label     *string  `json:"label,omitempty"`
+}
@@ -0,0 +1,217 @@
+package aether
+
+import (
+	"crypto/sha256"
+	"time"
+)
+
+// EvictionPolicy 表示缓存淘汰策略
+type EvictionPolicy int
+
+const (
+	// LRU 淘汰最久未使用的条目
+	LRU EvictionPolicy = iota
+	// LFU 淘汰使用次数最少的条目,次数相同时淘汰最久未使用的
+	LFU
+)
+
+// String 返回淘汰策略的名称
+func (p EvictionPolicy) String() string {
+	switch p {
+	case LRU:
+		return "LRU"
+	case LFU:
+		return "LFU"
+	default:
+		return "unknown"
+	}
+}
+
+// CacheConfig 配置 AST 缓存的容量与淘汰策略
+//
+// 各项限制为 0 表示不限制
+type CacheConfig struct {
+	// MaxEntries 为最大缓存条目数
+	MaxEntries int
+	// MaxBytes 为所有缓存脚本源码的最大总字节数
+	MaxBytes int
+	// TTL 为条目的最长存活时间,从条目加入缓存时开始计算
+	TTL time.Duration
+	// Policy 为超出容量时的淘汰策略
+	Policy EvictionPolicy
+}
+
+// programKey 为脚本源码的 SHA-256
+type programKey [sha256.Size]byte
+
+// cacheEntry 记录一个缓存脚本的使用情况
+type cacheEntry struct {
+	code     string
+	created  time.Time
+	lastUsed time.Time
+	uses     int
+}
+
+// programCache 是位于原生 AST 缓存之前、按源码哈希索引的 Go 侧缓存
+//
+// programCache 按 CacheConfig 决定保留哪些脚本。原生库只能整体清空 AST 缓存,
+// 因此有条目被淘汰时,Engine 清空原生缓存并以 MaxSteps = 0 重新解析其余条目,
+// 使原生缓存与索引保持一致;原生库在 MaxSteps = 0 时仍会执行脚本时只清空,
+// 其余条目在下次执行时重新解析。
+//
+// programCache 不是线程安全的,由 Engine.mu 保护
+type programCache struct {
+	cfg     CacheConfig
+	entries map[programKey]*cacheEntry
+	bytes   int
+
+	evictions int
+	// reparsed 为重新解析产生的原生未命中数,从 CacheStats 的 Misses 中扣除
+	reparsed int
+	// noReparse 为 true 时原生库在 MaxSteps = 0 时仍执行脚本,淘汰后不重新解析
+	noReparse bool
+	probed    bool
+}
+
+// newProgramCache 创建一个 Go 侧缓存
+func newProgramCache(cfg CacheConfig) *programCache {
+	return &programCache{
+		cfg:     cfg,
+		entries: make(map[programKey]*cacheEntry),
+	}
+}
+
+// admit 在执行 code 之前调用,记录本次使用并按配置淘汰条目,返回是否有条目被淘汰
+func (c *programCache) admit(code string, now time.Time) (evicted bool) {
+	key := programKey(sha256.Sum256([]byte(code)))
+
+	if entry, ok := c.entries[key]; ok {
+		if !c.expired(entry, now) {
+			entry.uses++
+			entry.lastUsed = now
+			return false
+		}
+		c.remove(key)
+		evicted = true
+	}
+
+	c.entries[key] = &cacheEntry{
+		code:     code,
+		created:  now,
+		lastUsed: now,
+		uses:     1,
+	}
+	c.bytes += len(code)
+
+	if c.overflow() {
+		if c.removeExpired(now, key) {
+			evicted = true
+		}
+		for c.overflow() && len(c.entries) > 1 {
+			c.remove(c.victim(key))
+			evicted = true
+		}
+	}
+	return evicted
+}
+
+// expired 判断条目是否超过 TTL
+func (c *programCache) expired(entry *cacheEntry, now time.Time) bool {
+	return c.cfg.TTL > 0 && now.Sub(entry.created) >= c.cfg.TTL
+}
+
+// overflow 判断缓存是否超出容量
+func (c *programCache) overflow() bool {
+	return (c.cfg.MaxEntries > 0 && len(c.entries) > c.cfg.MaxEntries) ||
+		(c.cfg.MaxBytes > 0 && c.bytes > c.cfg.MaxBytes)
+}
+
+// removeExpired 淘汰除 keep 以外的所有过期条目
+func (c *programCache) removeExpired(now time.Time, keep programKey) (removed bool) {
+	for key, entry := range c.entries {
+		if key != keep && c.expired(entry, now) {
+			c.remove(key)
+			removed = true
+		}
+	}
+	return removed
+}
+
+// victim 按淘汰策略选出要淘汰的条目,不会选中 keep
+func (c *programCache) victim(keep programKey) programKey {
+	var victim programKey
+	var best *cacheEntry
+	for key, entry := range c.entries {
+		if key == keep {
+			continue
+		}
+		if best == nil || c.less(entry, best) {
+			victim, best = key, entry
+		}
+	}
+	return victim
+}
+
+// less 判断 a 是否比 b 更应该被淘汰
+func (c *programCache) less(a, b *cacheEntry) bool {
+	if c.cfg.Policy == LFU && a.uses != b.uses {
+		return a.uses < b.uses
+	}
+	return a.lastUsed.Before(b.lastUsed)
+}
+
+// remove 淘汰一个条目
+func (c *programCache) remove(key programKey) {
+	if entry, ok := c.entries[key]; ok {
+		c.bytes -= len(entry.code)
+		delete(c.entries, key)
+		c.evictions++
+	}
+}
+
+// survivors 返回除 code 以外仍在缓存中的脚本
+func (c *programCache) survivors(code string) []string {
+	skip := programKey(sha256.Sum256([]byte(code)))
+	sources := make([]string, 0, len(c.entries))
+	for key, entry := range c.entries {
+		if key != skip {
+			sources = append(sources, entry.code)
+		}
+	}
+	return sources
+}
+
+// clear 在原生缓存被清空后清空索引,保留累计计数
+func (c *programCache) clear() {
+	c.entries = make(map[programKey]*cacheEntry)
+	c.bytes = 0
+}
+
+// rebuildCacheLocked 在有条目被淘汰后清空原生缓存,并以 MaxSteps = 0 重新解析
+// code 以外仍在缓存中的脚本;调用方必须持有 e.mu 的写锁
+func (e *Engine) rebuildCacheLocked(code string) {
+	c := e.cache
+	e.handle.clearCache()
+
+	limits := e.handle.getLimits()
+	noSteps := limits
+	noSteps.MaxSteps = 0
+	e.handle.setLimits(noSteps)
+	defer e.handle.setLimits(limits)
+
+	if !c.probed {
+		// 用没有副作用的脚本确认原生库在 MaxSteps = 0 时不执行脚本
+		c.probed = true
+		_, err := e.handle.eval("0")
+		c.noReparse = err == nil
+		c.reparsed++
+		e.handle.clearCache()
+	}
+	if c.noReparse {
+		return
+	}
+	for _, source := range c.survivors(code) {
+		e.handle.eval(source)
+		c.reparsed++
+	}
+}
@@ -0,0 +1,305 @@
+package aether
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrWarmUnsupported 表示原生库在 MaxSteps = 0 时仍执行了脚本,无法在不执行脚本的情况下预热缓存
+var ErrWarmUnsupported = errors.New("aether: 原生库在 MaxSteps = 0 时仍执行了脚本,无法只解析预热")
+
+// CachedProgram 表示一个持久化的脚本
+type CachedProgram struct {
+	// Hash 为源码 SHA-256 的十六进制表示
+	Hash string `json:"hash"`
+	// Version 为保存时 Aether 引擎的 Version()
+	Version string `json:"version"`
+	// Source 为脚本源码
+	Source string `json:"source"`
+	// Created 为保存时间
+	Created time.Time `json:"created"`
+}
+
+// NewCachedProgram 为当前引擎版本创建一个持久化脚本
+func NewCachedProgram(source string) CachedProgram {
+	return CachedProgram{
+		Hash:    programHash(source),
+		Version: Version(),
+		Source:  source,
+		Created: time.Now(),
+	}
+}
+
+// programHash 返回源码 SHA-256 的十六进制表示
+func programHash(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+// CacheStore 持久化已验证的脚本,用于重启后预热 AST 缓存
+//
+// 实现必须是线程安全的
+type CacheStore interface {
+	// Load 返回 version 下的所有脚本
+	//
+	// 其他版本保存的条目不得返回,并应被删除
+	Load(version string) ([]CachedProgram, error)
+	// Save 保存一个脚本,已存在时覆盖
+	Save(p CachedProgram) error
+	// Delete 删除一个脚本,不存在时不返回错误
+	Delete(version, hash string) error
+}
+
+// DirCacheStore 是基于目录的 CacheStore
+//
+// 目录结构为 <dir>/<version>/<hash>.json,每个版本目录中有一个标记文件 .aether-cache。
+// 清理其他版本时只删除带有标记文件的目录,dir 中的其他文件与目录不受影响
+type DirCacheStore struct {
+	dir string
+}
+
+// storeMarker 为 DirCacheStore 创建的版本目录中的标记文件
+const storeMarker = ".aether-cache"
+
+// NewDirCacheStore 创建一个基于目录的 CacheStore,目录不存在时自动创建
+func NewDirCacheStore(dir string) (*DirCacheStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("无法创建缓存目录: %w", err)
+	}
+	return &DirCacheStore{dir: dir}, nil
+}
+
+// versionDir 返回版本对应的子目录
+func (s *DirCacheStore) versionDir(version string) string {
+	return filepath.Join(s.dir, sanitizeVersion(version))
+}
+
+// sanitizeVersion 将版本号转换为安全的目录名
+func sanitizeVersion(version string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '+':
+			return r
+		default:
+			return '_'
+		}
+	}, version)
+}
+
+// Load 实现 CacheStore 接口
+//
+// 其他版本的子目录(带有标记文件的)会被整体删除;无法解析或哈希不匹配的文件也会被删除
+func (s *DirCacheStore) Load(version string) ([]CachedProgram, error) {
+	current := sanitizeVersion(version)
+
+	dirs, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取缓存目录失败: %w", err)
+	}
+	for _, d := range dirs {
+		if !d.IsDir() || d.Name() == current {
+			continue
+		}
+		// 只删除本存储创建的目录
+		if _, err := os.Stat(filepath.Join(s.dir, d.Name(), storeMarker)); err == nil {
+			if err := os.RemoveAll(filepath.Join(s.dir, d.Name())); err != nil {
+				return nil, fmt.Errorf("删除过期缓存失败: %w", err)
+			}
+		}
+	}
+
+	files, err := os.ReadDir(s.versionDir(version))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取缓存目录失败: %w", err)
+	}
+
+	var programs []CachedProgram
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(s.versionDir(version), f.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取缓存文件失败: %w", err)
+		}
+
+		var p CachedProgram
+		if err := json.Unmarshal(data, &p); err != nil || p.Version != version || programHash(p.Source) != p.Hash {
+			os.Remove(path)
+			continue
+		}
+		programs = append(programs, p)
+	}
+	return programs, nil
+}
+
+// Save 实现 CacheStore 接口
+//
+// 先写入临时文件再重命名,避免并发读取到不完整的文件
+func (s *DirCacheStore) Save(p CachedProgram) error {
+	dir := s.versionDir(p.Version)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("无法创建缓存目录: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, storeMarker), nil, 0644); err != nil {
+		return fmt.Errorf("无法创建缓存目录: %w", err)
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("序列化缓存条目失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入缓存文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入缓存文件失败: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(dir, p.Hash+".json"))
+}
+
+// Delete 实现 CacheStore 接口
+func (s *DirCacheStore) Delete(version, hash string) error {
+	err := os.Remove(filepath.Join(s.versionDir(version), hash+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除缓存文件失败: %w", err)
+	}
+	return nil
+}
+
+// SetCacheStore 设置持久化脚本存储
+//
+// 设置后每个首次执行成功的脚本都会保存到 store 中,
+// 重启后可以用 WarmCache 预热。传入 nil 停止保存。
+// 保存失败不会影响 Eval 的结果。
+// 此方法是线程安全的
+func (e *Engine) SetCacheStore(store CacheStore) {
+	e.storeMu.Lock()
+	defer e.storeMu.Unlock()
+
+	e.store = store
+	e.stored = make(map[string]struct{})
+}
+
+// persist 将执行成功的脚本保存到 CacheStore
+func (e *Engine) persist(code string) {
+	e.storeMu.Lock()
+	defer e.storeMu.Unlock()
+
+	if e.store == nil {
+		return
+	}
+
+	hash := programHash(code)
+	if _, ok := e.stored[hash]; ok {
+		return
+	}
+	p := NewCachedProgram(code)
+	if err := e.store.Save(p); err == nil {
+		e.stored[hash] = struct{}{}
+	}
+}
+
+// WarmCache 将 store 中当前引擎版本的脚本预加载到引擎的 AST 缓存
+//
+// 原生库没有只解析的接口,脚本以 MaxSteps = 0 执行:解析后在执行第一步前因步数限制停止,
+// 因此不会产生副作用。整个预热过程持有引擎锁,并发的 Eval、SetExecutionLimits 等调用
+// 会等待预热结束,不会以 MaxSteps = 0 执行,也不会被恢复的限制覆盖。
+// 原生库在 MaxSteps = 0 时仍执行成功的脚本会使预热停止并返回 ErrWarmUnsupported。
+// 解析失败或以步数限制之外的原因失败的脚本会从 store 中删除;原生库先检查步数再解析时,
+// 解析错误在预热时表现为步数限制,这些脚本在之后的 Eval 返回 CodeParseError 时删除。
+// 返回成功预热的脚本数。
+func WarmCache(engine *Engine, store CacheStore) (int, error) {
+	version := Version()
+	programs, err := store.Load(version)
+	if err != nil {
+		return 0, err
+	}
+	return engine.warm(store, version, programs)
+}
+
+// warm 在持有引擎锁的情况下以 MaxSteps = 0 解析脚本,结束后恢复执行限制
+func (e *Engine) warm(store CacheStore, version string, programs []CachedProgram) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.handle == nil {
+		return 0, ErrClosed
+	}
+
+	limits := e.handle.getLimits()
+	noSteps := limits
+	noSteps.MaxSteps = 0
+	e.handle.setLimits(noSteps)
+	defer e.handle.setLimits(limits)
+
+	warmed := 0
+	for _, p := range programs {
+		if strings.TrimSpace(p.Source) == "" {
+			continue
+		}
+		_, err := e.evalLocked(p.Source)
+		switch CodeOf(err) {
+		case CodeSuccess:
+			return warmed, ErrWarmUnsupported
+		case CodeNullPointer:
+			return warmed, err
+		case CodeRuntimeError:
+			// 在第一步前因步数限制停止
+			warmed++
+			e.markStored(p.Hash)
+		default:
+			store.Delete(version, p.Hash)
+		}
+	}
+	return warmed, nil
+}
+
+// forget 从 CacheStore 中删除解析失败的脚本
+//
+// 只删除本引擎保存或预热过的脚本
+func (e *Engine) forget(code string) {
+	e.storeMu.Lock()
+	defer e.storeMu.Unlock()
+
+	if e.store == nil {
+		return
+	}
+	hash := programHash(code)
+	if _, ok := e.stored[hash]; !ok {
+		return
+	}
+	if err := e.store.Delete(Version(), hash); err == nil {
+		delete(e.stored, hash)
+	}
+}
+
+// markStored 记录已在 CacheStore 中的脚本,避免重复保存
+func (e *Engine) markStored(hash string) {
+	e.storeMu.Lock()
+	defer e.storeMu.Unlock()
+
+	if e.stored != nil {
+		e.stored[hash] = struct{}{}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	aether "github.com/xiaozuhui/aether-go"
+	"github.com/xiaozuhui/aether-go/internal/doctor"
+)
+
+// runDoctor 输出 cmd/doctor 的全部检查,并加入链接进本程序的库的检查
+//
+// 本命令需要链接 Aether 库;库文件缺失时 aether 无法编译,应改用 cmd/doctor
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "以 JSON 输出")
+	fs.Parse(args)
+
+	info, _ := aether.InspectLibrary()
+	report := doctor.Diagnose(doctor.Options{Shared: info.Backend == "dynamic"})
+	linkedChecks(report)
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		doctor.Print(os.Stdout, report)
+	}
+
+	if report.Failed() {
+		return 1
+	}
+	return 0
+}
+
+// linkedChecks 检查链接进本程序的库的 ABI 与版本
+func linkedChecks(report *doctor.Report) {
+	lib := report.Library
+	if err := aether.CheckABI(); errors.Is(err, aether.ErrUnknownLibraryVersion) {
+		report.Add(doctor.Check{Name: "ABI", Status: doctor.StatusWarn, Detail: err.Error(),
+			Fix: "该版本未经本绑定验证,建议使用与 aether-go 版本匹配的库: " + doctor.FetchFix(lib)})
+	} else if err != nil {
+		report.Add(doctor.Check{Name: "ABI", Status: doctor.StatusFail, Detail: err.Error(),
+			Fix: "使用与 aether-go 版本匹配的库: " + doctor.FetchFix(lib) + " --force"})
+		return
+	} else {
+		report.Add(doctor.Check{Name: "ABI", Status: doctor.StatusOK, Detail: "与本绑定兼容"})
+	}
+
+	// 链接进本程序的库与磁盘上的库可能不同(例如修改库文件后没有重新编译)
+	lib.LinkedVersion = aether.Version()
+	if err := aether.CheckLibraryVersion(); errors.Is(err, aether.ErrLibraryVersionMismatch) {
+		report.Add(doctor.Check{Name: "链接版本", Status: doctor.StatusFail,
+			Detail: fmt.Sprintf("本程序链接的库为 %s,aether.lock 记录为 %s", lib.LinkedVersion, lib.LockVersion),
+			Fix:    "运行 go clean -cache 后重新编译"})
+	} else if err != nil {
+		report.Add(doctor.Check{Name: "链接版本", Status: doctor.StatusFail, Detail: err.Error(),
+			Fix: doctor.FetchFix(lib) + " --force,然后重新编译"})
+	} else {
+		report.Add(doctor.Check{Name: "链接版本", Status: doctor.StatusOK, Detail: lib.LinkedVersion})
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+// maxHistory 为历史文件保留的最大行数
+const maxHistory = 1000
+
+// errInterrupt 在用户按下 Ctrl-C 时由 readLine 返回
+var errInterrupt = errors.New("interrupt")
+
+// lineEditor 从终端读取一行输入,支持光标移动与历史记录
+//
+// 标准输入是终端时切换到原始模式并自行处理按键,不依赖 readline 等 C 库;
+// 否则(管道或文件)逐行读取,不显示提示符
+type lineEditor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	fd      int
+	tty     bool
+	history []string
+	// histFile 为历史文件路径,为空时不保存历史
+	histFile string
+}
+
+func newLineEditor(in *os.File, out io.Writer, histFile string) *lineEditor {
+	fd := int(in.Fd())
+	e := &lineEditor{
+		in:       bufio.NewReader(in),
+		out:      out,
+		fd:       fd,
+		tty:      term.IsTerminal(fd),
+		histFile: histFile,
+	}
+	e.loadHistory()
+	return e
+}
+
+// interactive 返回输入是否来自终端
+func (e *lineEditor) interactive() bool {
+	return e.tty
+}
+
+// readLine 显示提示符并读取一行,不含换行符
+//
+// 输入结束或在空行按下 Ctrl-D 时返回 io.EOF,按下 Ctrl-C 时返回 errInterrupt
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	if !e.tty {
+		line, err := e.in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	state, err := term.MakeRaw(e.fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(e.fd, state)
+
+	line, err := e.edit(prompt)
+	fmt.Fprint(e.out, "\r\n")
+	if err == nil {
+		e.addHistory(line)
+	}
+	return line, err
+}
+
+// edit 在原始模式下处理按键直到回车
+func (e *lineEditor) edit(prompt string) (string, error) {
+	var buf []rune
+	pos := 0
+	// hist 为当前显示的历史条目下标,等于 len(e.history) 时为正在编辑的行
+	hist := len(e.history)
+	var pending []rune
+
+	redraw := func() {
+		fmt.Fprintf(e.out, "\r\x1b[K%s%s", prompt, string(buf))
+		if back := displayWidth(buf[pos:]); back > 0 {
+			fmt.Fprintf(e.out, "\x1b[%dD", back)
+		}
+	}
+	recall := func(i int) {
+		if i < 0 || i > len(e.history) {
+			return
+		}
+		if hist == len(e.history) {
+			pending = append([]rune(nil), buf...)
+		}
+		hist = i
+		if i == len(e.history) {
+			buf = append([]rune(nil), pending...)
+		} else {
+			buf = []rune(e.history[i])
+		}
+		pos = len(buf)
+	}
+
+	redraw()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			return string(buf), nil
+		case 3: // Ctrl-C
+			return "", errInterrupt
+		case 4: // Ctrl-D
+			if len(buf) == 0 {
+				return "", io.EOF
+			}
+			if pos < len(buf) {
+				buf = append(buf[:pos], buf[pos+1:]...)
+			}
+		case 1: // Ctrl-A
+			pos = 0
+		case 5: // Ctrl-E
+			pos = len(buf)
+		case 2: // Ctrl-B
+			if pos > 0 {
+				pos--
+			}
+		case 6: // Ctrl-F
+			if pos < len(buf) {
+				pos++
+			}
+		case 11: // Ctrl-K
+			buf = buf[:pos]
+		case 21: // Ctrl-U
+			buf = append([]rune(nil), buf[pos:]...)
+			pos = 0
+		case 16: // Ctrl-P
+			recall(hist - 1)
+		case 14: // Ctrl-N
+			recall(hist + 1)
+		case 127, 8: // Backspace
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+			}
+		case 27: // 转义序列
+			switch e.readEscape() {
+			case "[A", "OA":
+				recall(hist - 1)
+			case "[B", "OB":
+				recall(hist + 1)
+			case "[C", "OC":
+				if pos < len(buf) {
+					pos++
+				}
+			case "[D", "OD":
+				if pos > 0 {
+					pos--
+				}
+			case "[H", "OH", "[1~":
+				pos = 0
+			case "[F", "OF", "[4~":
+				pos = len(buf)
+			case "[3~":
+				if pos < len(buf) {
+					buf = append(buf[:pos], buf[pos+1:]...)
+				}
+			}
+		default:
+			if r == '\t' || unicode.IsPrint(r) {
+				buf = append(buf[:pos], append([]rune{r}, buf[pos:]...)...)
+				pos++
+			}
+		}
+		redraw()
+	}
+}
+
+// readEscape 读取 ESC 之后的 CSI 或 SS3 序列,不认识的序列返回空字符串
+func (e *lineEditor) readEscape() string {
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return ""
+	}
+	seq := []rune{r}
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return ""
+		}
+		seq = append(seq, r)
+		if r >= 0x40 && r <= 0x7e {
+			return string(seq)
+		}
+	}
+}
+
+// displayWidth 返回字符在终端中占用的列数,中日韩字符按两列计算
+func displayWidth(rs []rune) int {
+	w := 0
+	for _, r := range rs {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hangul, r) ||
+			unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) ||
+			(r >= 0xff01 && r <= 0xff60) || (r >= 0x3000 && r <= 0x303f) {
+			w += 2
+		} else {
+			w++
+		}
+	}
+	return w
+}
+
+// addHistory 记录一行输入并追加到历史文件,忽略空行与和上一条相同的行
+func (e *lineEditor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if n := len(e.history); n > 0 && e.history[n-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+
+	if e.histFile == "" {
+		return
+	}
+	f, err := os.OpenFile(e.histFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+// loadHistory 读取历史文件,超过 maxHistory 行时截断文件
+func (e *lineEditor) loadHistory() {
+	if e.histFile == "" {
+		return
+	}
+	data, err := os.ReadFile(e.histFile)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			e.history = append(e.history, line)
+		}
+	}
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+		os.WriteFile(e.histFile, []byte(strings.Join(e.history, "\n")+"\n"), 0600)
+	}
+}
+
+// defaultHistoryFile 返回默认的历史文件路径 ~/.aether_history
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aether_history")
+}
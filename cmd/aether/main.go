@@ -0,0 +1,92 @@
+// aether 命令行工具
+//
+// 用法:
+//
+//	aether <命令> [参数]
+//
+// 命令:
+//
+//	doctor    检查 cgo、工具链与预编译库,并给出修复方法
+//	repl      启动交互式会话
+//	run       执行脚本文件或标准输入中的脚本
+//	serve     启动 HTTP 脚本执行服务
+//	test      执行 *_test.aether 文件中的测试
+//	worker    在标准输入输出上提供 JSON-RPC 接口,供 worker.Client 使用
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+// command 描述一个子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) int
+}
+
+var commands = []command{
+	{"doctor", "检查 cgo、工具链与预编译库,并给出修复方法", runDoctor},
+	{"repl", "启动交互式会话", runRepl},
+	{"run", "执行脚本文件或标准输入中的脚本", runRun},
+	{"serve", "启动 HTTP 脚本执行服务", runServe},
+	{"test", "执行 *_test.aether 文件中的测试", runTest},
+	{"worker", "在标准输入输出上提供 JSON-RPC 接口,供 worker.Client 使用", runWorker},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			os.Exit(cmd.run(os.Args[2:]))
+		}
+	}
+
+	if name != "help" && name != "-h" && name != "--help" {
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", name)
+	}
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "用法: aether <命令> [参数]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "命令:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+// newEngine 检查库后创建引擎,库不可用或不兼容时返回错误而不是 panic
+func newEngine(allowIO bool) (*aether.Engine, error) {
+	if err := checkLibrary(); err != nil {
+		return nil, err
+	}
+	if allowIO {
+		return aether.NewWithPermissions(), nil
+	}
+	return aether.New(), nil
+}
+
+// checkLibrary 检查库是否可用且 ABI 兼容,库版本不在兼容表中时只输出警告
+func checkLibrary() error {
+	err := aether.CheckABI()
+	if errors.Is(err, aether.ErrUnknownLibraryVersion) {
+		fmt.Fprintf(os.Stderr, "警告: %v\n", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w\n运行 aether doctor 查看详情", err)
+	}
+	return nil
+}
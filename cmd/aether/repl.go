@@ -0,0 +1,374 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+const (
+	prompt         = "aether> "
+	continuePrompt = "   ...> "
+)
+
+// setPattern 匹配脚本中的 Set NAME,用于记录 :globals 要显示的变量
+//
+// aether.h 没有列出变量的接口,因此 REPL 只能显示它见过的变量名
+var setPattern = regexp.MustCompile(`\bSet[ \t]+([A-Za-z_][A-Za-z0-9_]*)`)
+
+const replHelp = `输入 Aether 代码执行并显示结果,括号未闭合时继续读取下一行。
+Ctrl-C 放弃当前输入,Ctrl-D 或 :quit 退出。
+
+  :globals                    显示已设置的变量及其值
+  :get NAME                   显示变量的值
+  :set NAME VALUE             设置变量,VALUE 不是合法的 JSON 时作为字符串
+  :trace [json|stats|clear]   显示或清除追踪条目
+  :limits [steps=N] [depth=N] [timeout=DUR]
+                              显示或修改执行限制,-1(timeout 为 0)表示不限制
+  :cache [clear]              显示缓存统计或清除缓存
+  :reset                      重置环境,清除所有变量
+  :load FILE                  执行脚本文件
+  :help                       显示此帮助
+  :quit                       退出`
+
+// repl 保存交互会话的状态
+type repl struct {
+	engine *aether.Engine
+	out    io.Writer
+	errOut io.Writer
+	// globals 为会话中设置过的变量名
+	globals map[string]bool
+}
+
+func runRepl(args []string) int {
+	fs := flag.NewFlagSet("repl", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: aether repl [参数]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "启动交互式会话,所有输入在同一个引擎中执行。输入 :help 查看命令。")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	allowIO := fs.Bool("allow-io", false, "启用 IO 权限")
+	history := fs.String("history", defaultHistoryFile(), "历史文件路径,为空时不保存历史")
+	fs.Parse(args)
+
+	engine, err := newEngine(*allowIO)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer engine.Close()
+
+	r := &repl{engine: engine, out: os.Stdout, errOut: os.Stderr, globals: make(map[string]bool)}
+	editor := newLineEditor(os.Stdin, os.Stdout, *history)
+	if editor.interactive() {
+		fmt.Fprintf(r.out, "Aether %s,输入 :help 查看命令\n", aether.Version())
+	}
+	r.loop(editor)
+	return 0
+}
+
+// loop 读取输入直到结束
+func (r *repl) loop(editor *lineEditor) {
+	var pending []string
+	for {
+		p := prompt
+		if len(pending) > 0 {
+			p = continuePrompt
+		}
+		line, err := editor.readLine(p)
+		if errors.Is(err, errInterrupt) {
+			pending = nil
+			continue
+		}
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintln(r.errOut, err)
+			}
+			return
+		}
+
+		if len(pending) == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			if !r.command(strings.TrimSpace(line)) {
+				return
+			}
+			continue
+		}
+
+		pending = append(pending, line)
+		code := strings.Join(pending, "\n")
+		if bracketDepth(code) > 0 {
+			continue
+		}
+		pending = nil
+		if strings.TrimSpace(code) != "" {
+			r.eval(code)
+		}
+	}
+}
+
+// eval 执行代码并显示结果
+func (r *repl) eval(code string) {
+	result, err := r.engine.Eval(code)
+	for _, m := range setPattern.FindAllStringSubmatch(code, -1) {
+		r.globals[m[1]] = true
+	}
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+		return
+	}
+	fmt.Fprintln(r.out, result)
+}
+
+// command 执行元命令,返回 false 表示退出
+func (r *repl) command(line string) bool {
+	fields := strings.Fields(line)
+	name, args := fields[0], fields[1:]
+
+	var err error
+	switch name {
+	case ":quit", ":q", ":exit":
+		return false
+	case ":help", ":h":
+		fmt.Fprintln(r.out, replHelp)
+	case ":globals":
+		err = r.showGlobals()
+	case ":get":
+		if len(args) != 1 {
+			err = errors.New("用法: :get NAME")
+			break
+		}
+		var v interface{}
+		if v, err = r.engine.GetGlobal(args[0]); err == nil {
+			fmt.Fprintln(r.out, formatValue(v))
+		}
+	case ":set":
+		rest := strings.TrimSpace(strings.TrimPrefix(line, name))
+		n, value, ok := strings.Cut(rest, " ")
+		if !ok || n == "" {
+			err = errors.New("用法: :set NAME VALUE")
+			break
+		}
+		if err = r.engine.SetGlobal(n, parseValue(strings.TrimSpace(value))); err == nil {
+			r.globals[n] = true
+		}
+	case ":trace":
+		err = r.trace(args)
+	case ":limits":
+		err = r.limits(args)
+	case ":cache":
+		err = r.cache(args)
+	case ":reset":
+		if err = r.engine.ResetEnv(); err == nil {
+			r.globals = make(map[string]bool)
+		}
+	case ":load":
+		if len(args) != 1 {
+			err = errors.New("用法: :load FILE")
+			break
+		}
+		var code string
+		if code, err = readScript(args[0]); err == nil {
+			r.eval(code)
+		}
+	default:
+		err = fmt.Errorf("未知命令: %s,输入 :help 查看命令", name)
+	}
+
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+	}
+	return true
+}
+
+// showGlobals 按名称顺序显示会话中设置过且仍然存在的变量
+func (r *repl) showGlobals() error {
+	names := make([]string, 0, len(r.globals))
+	for name := range r.globals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v, err := r.engine.GetGlobal(name)
+		if err != nil {
+			delete(r.globals, name)
+			continue
+		}
+		fmt.Fprintf(r.out, "%s = %s\n", name, formatValue(v))
+	}
+	return nil
+}
+
+func (r *repl) trace(args []string) error {
+	mode := ""
+	if len(args) > 0 {
+		mode = args[0]
+	}
+	switch mode {
+	case "", "text", "json":
+		if mode == "" {
+			mode = "text"
+		}
+		return writeTrace(r.out, r.engine, mode)
+	case "stats":
+		stats, err := r.engine.TraceStats()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out, "条目: %d, 缓冲区: %d\n", stats.TotalEntries, stats.BufferSize)
+		fmt.Fprintf(r.out, "按级别: %s\n", formatCounts(stats.ByLevel))
+		fmt.Fprintf(r.out, "按类别: %s\n", formatCounts(stats.ByCategory))
+		return nil
+	case "clear":
+		return r.engine.ClearTrace()
+	default:
+		return errors.New("用法: :trace [json|stats|clear]")
+	}
+}
+
+func (r *repl) limits(args []string) error {
+	limits, err := r.engine.GetExecutionLimits()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		fmt.Fprintf(r.out, "steps=%d depth=%d timeout=%s\n", limits.MaxSteps, limits.MaxRecursionDepth, formatTimeout(limits.MaxDurationMs))
+		return nil
+	}
+
+	for _, arg := range args {
+		key, value, _ := strings.Cut(arg, "=")
+		switch key {
+		case "steps", "depth":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("无效的 %s: %s", key, value)
+			}
+			if key == "steps" {
+				limits.MaxSteps = n
+			} else {
+				limits.MaxRecursionDepth = n
+			}
+		case "timeout":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("无效的 timeout: %s", value)
+			}
+			limits.MaxDurationMs = -1
+			if d > 0 {
+				limits.MaxDurationMs = int(d / time.Millisecond)
+			}
+		default:
+			return errors.New("用法: :limits [steps=N] [depth=N] [timeout=DUR]")
+		}
+	}
+	return r.engine.SetExecutionLimits(*limits)
+}
+
+func (r *repl) cache(args []string) error {
+	if len(args) == 1 && args[0] == "clear" {
+		return r.engine.ClearCache()
+	}
+	if len(args) != 0 {
+		return errors.New("用法: :cache [clear]")
+	}
+	stats, err := r.engine.CacheStats()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "命中: %d, 未命中: %d, 条目: %d\n", stats.Hits, stats.Misses, stats.Size)
+	return nil
+}
+
+// bracketDepth 返回代码中未闭合的 ( [ { 数量
+//
+// 字符串("..." 与 '...')与注释(// 与 /* */)中的括号不计入;未结束的字符串或块注释
+// 计为一层,使输入继续到下一行
+func bracketDepth(code string) int {
+	depth := 0
+	var quote rune // 当前字符串的引号,不在字符串中时为 0
+	escaped := false
+	lineComment, blockComment := false, false
+
+	runes := []rune(code)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		switch {
+		case lineComment:
+			lineComment = c != '\n'
+		case blockComment:
+			if c == '*' && next == '/' {
+				blockComment = false
+				i++
+			}
+		case escaped:
+			escaped = false
+		case quote != 0 && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '/' && next == '/':
+			lineComment = true
+			i++
+		case c == '/' && next == '*':
+			blockComment = true
+			i++
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		}
+	}
+	if quote != 0 || blockComment {
+		depth++
+	}
+	return depth
+}
+
+// formatValue 以 JSON 显示变量的值
+func formatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func formatCounts(m map[string]int) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%d", k, m[k])
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatTimeout(ms int) string {
+	if ms < 0 {
+		return "-1"
+	}
+	return (time.Duration(ms) * time.Millisecond).String()
+}
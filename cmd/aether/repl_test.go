@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+// TestBracketDepth 测试多行输入的括号计数忽略字符串与注释
+func TestBracketDepth(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want int
+	}{
+		{"完整表达式", "(1 + 2)", 0},
+		{"未闭合", "Func F (N) {", 1},
+		{"嵌套", "Set X [1, (2", 2},
+		{"多行闭合", "If (X) {\n  Return 1\n}", 0},
+		{"字符串中的括号", `PRINT("(")`, 0},
+		{"单引号字符串", `Set S '{'`, 0},
+		{"转义引号", `Set S "\"("`, 0},
+		{"未结束的字符串", `Set S "abc`, 1},
+		{"行注释", "Set X 1 // (", 0},
+		{"行注释后换行", "// {\n{", 1},
+		{"块注释", "/* ( [ { */ X", 0},
+		{"未结束的块注释", "/* (", 1},
+		{"注释中的引号", "// \"\n(", 1},
+	}
+	for _, tt := range tests {
+		if got := bracketDepth(tt.code); got != tt.want {
+			t.Errorf("%s: bracketDepth(%q) = %d,期望 %d", tt.name, tt.code, got, tt.want)
+		}
+	}
+}
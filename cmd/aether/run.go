@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+// exitUsage 为参数错误、无法读取输入或库不可用时的退出码
+//
+// 脚本执行失败时退出码为 AetherErrorCode 的值(1-6),因此参数错误使用
+// sysexits.h 的 EX_USAGE,不与其冲突
+const exitUsage = 64
+
+// globalFlags 收集可重复的 -global name=value 参数
+type globalFlags []string
+
+func (g *globalFlags) String() string {
+	return strings.Join(*g, ",")
+}
+
+func (g *globalFlags) Set(s string) error {
+	if !strings.Contains(s, "=") {
+		return fmt.Errorf("应为 name=value 格式: %q", s)
+	}
+	*g = append(*g, s)
+	return nil
+}
+
+// runResult 为 -output json 的输出
+type runResult struct {
+	Result string    `json:"result"`
+	Error  *runError `json:"error,omitempty"`
+}
+
+type runError struct {
+	// Code 为 aether.ErrorCode 的名称,如 "parse_error"
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func runRun(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: aether run [参数] [脚本.aether | -]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "执行脚本文件并输出结果,没有指定脚本或脚本为 - 时从标准输入读取。")
+		fmt.Fprintln(fs.Output(), "执行成功时退出码为 0,失败时为错误代码(1-6),参数错误时为 64。")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	var globals globalFlags
+	globalsFile := fs.String("globals", "", "从 JSON 对象文件设置变量,每个键为一个变量")
+	fs.Var(&globals, "global", "设置变量 name=value,value 不是合法的 JSON 时作为字符串,可重复")
+	allowIO := fs.Bool("allow-io", false, "启用 IO 权限")
+	maxSteps := fs.Int("max-steps", -1, "最大执行步数,-1 表示不限制,未指定时使用引擎默认值")
+	maxDepth := fs.Int("max-depth", -1, "最大递归深度,-1 表示不限制,未指定时使用引擎默认值")
+	timeout := fs.Duration("timeout", 0, "最长执行时间,0 表示不限制,未指定时使用引擎默认值")
+	trace := fs.String("trace", "", "执行后把追踪条目写到标准错误: text 或 json")
+	output := fs.String("output", "text", "结果格式: text 或 json")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitUsage
+	}
+
+	if *trace != "" && *trace != "text" && *trace != "json" {
+		fmt.Fprintf(os.Stderr, "未知的追踪格式: %s\n", *trace)
+		return exitUsage
+	}
+	if *output != "text" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "未知的输出格式: %s\n", *output)
+		return exitUsage
+	}
+	if fs.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "只能指定一个脚本")
+		return exitUsage
+	}
+
+	code, err := readScript(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	engine, err := newEngine(*allowIO)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	defer engine.Close()
+
+	if err := applyLimits(engine, fs, *maxSteps, *maxDepth, *timeout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if err := setGlobalsFile(engine, *globalsFile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	for _, kv := range globals {
+		name, value, _ := strings.Cut(kv, "=")
+		if err := engine.SetGlobal(name, parseValue(value)); err != nil {
+			fmt.Fprintf(os.Stderr, "无法设置变量 %s: %v\n", name, err)
+			return exitUsage
+		}
+	}
+
+	result, evalErr := engine.Eval(code)
+
+	if *trace != "" {
+		if err := writeTrace(os.Stderr, engine, *trace); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	if *output == "json" {
+		r := runResult{Result: result}
+		if evalErr != nil {
+			r.Result = ""
+			r.Error = &runError{Code: aether.CodeOf(evalErr).String(), Message: evalErr.Error()}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+	} else if evalErr != nil {
+		fmt.Fprintln(os.Stderr, evalErr)
+	} else {
+		fmt.Println(result)
+	}
+
+	return int(aether.CodeOf(evalErr))
+}
+
+// readScript 读取脚本文件,path 为空或 - 时读取标准输入
+func readScript(path string) (string, error) {
+	if path == "" || path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("无法读取标准输入: %w", err)
+		}
+		return string(data), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// applyLimits 只覆盖命令行中指定的执行限制,其余保留引擎的默认值
+func applyLimits(e aether.Evaluator, fs *flag.FlagSet, maxSteps, maxDepth int, timeout time.Duration) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["max-steps"] && !set["max-depth"] && !set["timeout"] {
+		return nil
+	}
+
+	limits, err := e.GetExecutionLimits()
+	if err != nil {
+		return err
+	}
+	if set["max-steps"] {
+		limits.MaxSteps = maxSteps
+	}
+	if set["max-depth"] {
+		limits.MaxRecursionDepth = maxDepth
+	}
+	if set["timeout"] {
+		limits.MaxDurationMs = -1
+		if timeout > 0 {
+			limits.MaxDurationMs = int(timeout / time.Millisecond)
+		}
+	}
+	return e.SetExecutionLimits(*limits)
+}
+
+// setGlobalsFile 按键名顺序设置 JSON 对象文件中的变量,path 为空时不做任何事
+func setGlobalsFile(e aether.Evaluator, path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var globals map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&globals); err != nil {
+		return fmt.Errorf("%s 不是 JSON 对象: %w", path, err)
+	}
+
+	names := make([]string, 0, len(globals))
+	for name := range globals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := e.SetGlobal(name, globals[name]); err != nil {
+			return fmt.Errorf("无法设置变量 %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// parseValue 把命令行中的值解析为 JSON,不是合法的 JSON 时作为字符串
+func parseValue(s string) interface{} {
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return s
+	}
+	return v
+}
+
+// writeTrace 以 text 或 json 格式输出追踪条目
+func writeTrace(w io.Writer, e aether.Evaluator, format string) error {
+	records, err := e.TraceRecords()
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		if records == nil {
+			records = []aether.TraceEntry{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(records)
+	}
+	for _, r := range records {
+		line := strings.Join(r.Values, " ")
+		if r.Label != nil {
+			line = *r.Label + ": " + line
+		}
+		fmt.Fprintf(w, "[%s] %s: %s\n", r.Level, r.Category, line)
+	}
+	return nil
+}
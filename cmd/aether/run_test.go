@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	aether "github.com/xiaozuhui/aether-go"
+	"github.com/xiaozuhui/aether-go/aetherfake"
+)
+
+// TestApplyLimits 测试命令行参数只覆盖指定的执行限制
+func TestApplyLimits(t *testing.T) {
+	defaults := aether.Limits{MaxSteps: 100, MaxRecursionDepth: 50, MaxDurationMs: 1000}
+	tests := []struct {
+		name string
+		// withSteps 为 true 时与 aether run 一样定义 -max-steps 与 -max-depth,否则与 aether test 一样只有 -timeout
+		withSteps bool
+		args      []string
+		want      aether.Limits
+	}{
+		{"run 未指定", true, nil, defaults},
+		{"run -max-steps", true, []string{"-max-steps", "10"}, aether.Limits{MaxSteps: 10, MaxRecursionDepth: 50, MaxDurationMs: 1000}},
+		{"run -max-depth", true, []string{"-max-depth", "5"}, aether.Limits{MaxSteps: 100, MaxRecursionDepth: 5, MaxDurationMs: 1000}},
+		{"run 全部", true, []string{"-max-steps", "-1", "-max-depth", "-1", "-timeout", "2s"}, aether.Limits{MaxSteps: -1, MaxRecursionDepth: -1, MaxDurationMs: 2000}},
+		{"test 未指定", false, nil, defaults},
+		{"test -timeout", false, []string{"-timeout", "500ms"}, aether.Limits{MaxSteps: 100, MaxRecursionDepth: 50, MaxDurationMs: 500}},
+		{"test -timeout 0", false, []string{"-timeout", "0"}, aether.Limits{MaxSteps: 100, MaxRecursionDepth: 50, MaxDurationMs: -1}},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet(tt.name, flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		maxSteps, maxDepth := new(int), new(int)
+		if tt.withSteps {
+			maxSteps = fs.Int("max-steps", 0, "")
+			maxDepth = fs.Int("max-depth", 0, "")
+		}
+		timeout := fs.Duration("timeout", 0, "")
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		fake := aetherfake.New()
+		fake.SetExecutionLimits(defaults)
+		if err := applyLimits(fake, fs, *maxSteps, *maxDepth, *timeout); err != nil {
+			t.Fatalf("%s: applyLimits 失败: %v", tt.name, err)
+		}
+		if got, _ := fake.GetExecutionLimits(); *got != tt.want {
+			t.Errorf("%s: 限制为 %+v,期望 %+v", tt.name, *got, tt.want)
+		}
+	}
+}
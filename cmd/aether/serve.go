@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+
+	aether "github.com/xiaozuhui/aether-go"
+	"github.com/xiaozuhui/aether-go/grpcserver"
+	"github.com/xiaozuhui/aether-go/server"
+)
+
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: aether serve [参数]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "启动 HTTP 服务,提供 POST /eval、POST /programs 与 POST /programs/{name}/run;\n指定 -grpc 时同时启动 aetherpb.Aether gRPC 服务,两者共享引擎池与已注册的脚本。")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", "127.0.0.1:8080", "HTTP 监听地址")
+	grpcAddr := fs.String("grpc", "", "gRPC 监听地址,为空时不启动 gRPC 服务")
+	poolSize := fs.Int("pool", runtime.NumCPU(), "引擎池大小")
+	allowIO := fs.Bool("allow-io", false, "启用 IO 权限,对所有请求生效")
+	maxSteps := fs.Int("max-steps", -1, "默认及最大执行步数,-1 表示不限制")
+	maxDepth := fs.Int("max-depth", -1, "默认及最大递归深度,-1 表示不限制")
+	timeout := fs.Duration("timeout", 5*time.Second, "默认及最长执行时间,0 表示不限制")
+	maxBody := fs.Int64("max-body", server.DefaultMaxBodyBytes, "请求体的最大字节数")
+	maxCode := fs.Int("max-code", server.DefaultMaxCodeBytes, "脚本的最大字节数")
+	maxPrograms := fs.Int("max-programs", server.DefaultMaxPrograms, "最多能注册的脚本数")
+	fs.Parse(args)
+
+	if err := checkLibrary(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	limits := aether.Limits{MaxSteps: *maxSteps, MaxRecursionDepth: *maxDepth, MaxDurationMs: -1}
+	if *timeout > 0 {
+		limits.MaxDurationMs = int(*timeout / time.Millisecond)
+	}
+	srv, err := server.New(server.Config{
+		PoolSize:     *poolSize,
+		AllowIO:      *allowIO,
+		Limits:       limits,
+		MaxBodyBytes: *maxBody,
+		MaxCodeBytes: *maxCode,
+		MaxPrograms:  *maxPrograms,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer srv.Close()
+
+	hs := &http.Server{Addr: *addr, Handler: srv, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		hs.Shutdown(shutdown)
+	}()
+
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		gs := grpc.NewServer()
+		grpcserver.Register(gs, srv)
+		go gs.Serve(lis)
+		defer gs.GracefulStop()
+		fmt.Fprintf(os.Stderr, "aether serve: gRPC 监听 %s\n", *grpcAddr)
+	}
+
+	fmt.Fprintf(os.Stderr, "aether serve: 监听 %s,引擎池大小 %d,IO 权限 %v\n", *addr, srv.Pool().Stats().Size, *allowIO)
+	if err := hs.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/xiaozuhui/aether-go/aethertest"
+)
+
+func runTest(args []string) int {
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: aether test [参数] [路径...]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "执行路径(默认为当前目录,递归查找)中 *_test.aether 文件的 Func TEST_* 函数。")
+		fmt.Fprintln(fs.Output(), "测试中可以使用 ASSERT_EQ(实际, 期望) 与 ASSERT_TRUE(条件)。")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	run := fs.String("run", "", "只执行名称匹配此正则表达式的测试")
+	verbose := fs.Bool("v", false, "输出所有测试及其追踪条目")
+	format := fs.String("format", "text", "输出格式: text、json 或 junit")
+	output := fs.String("o", "", "把结果写入文件而不是标准输出")
+	timeout := fs.Duration("timeout", 0, "每个测试的最长执行时间,0 表示不限制")
+	allowIO := fs.Bool("allow-io", false, "启用 IO 权限")
+	fs.Parse(args)
+
+	var filter *regexp.Regexp
+	if *run != "" {
+		re, err := regexp.Compile(*run)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "无效的 -run: %v\n", err)
+			return 2
+		}
+		filter = re
+	}
+	if *format != "text" && *format != "json" && *format != "junit" {
+		fmt.Fprintf(os.Stderr, "未知的输出格式: %s\n", *format)
+		return 2
+	}
+
+	files, err := aethertest.DiscoverTests(fs.Args()...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "没有找到 %s 文件\n", aethertest.TestFileSuffix)
+		return 2
+	}
+
+	engine, err := newEngine(*allowIO)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer engine.Close()
+
+	// 只修改执行时间限制,步数与递归深度保留引擎的默认值
+	if err := applyLimits(engine, fs, 0, 0, *timeout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	report := aethertest.RunTests(engine, files, filter)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "json":
+		err = report.WriteJSON(w)
+	case "junit":
+		err = report.WriteJUnit(w)
+	default:
+		report.WriteText(w, files, *verbose)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/xiaozuhui/aether-go/worker"
+)
+
+func runWorker(args []string) int {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: aether worker [参数]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "在标准输入输出上提供 JSON-RPC 2.0 接口,每行一条消息,供 worker.Client 在子进程中执行脚本。\n脚本的打印输出写入标准错误,标准输入关闭后退出。")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	allowIO := fs.Bool("allow-io", false, "启用 IO 权限")
+	fs.Parse(args)
+
+	// 在加载原生库前改变标准输出,避免打印的内容混入协议消息
+	out, err := protocolOutput()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	engine, err := newEngine(*allowIO)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer engine.Close()
+
+	if err := worker.Serve(engine, os.Stdin, out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
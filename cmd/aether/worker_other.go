@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// protocolOutput 返回用于协议消息的标准输出,此平台上不重定向原生库的输出
+func protocolOutput() (*os.File, error) {
+	return os.Stdout, nil
+}
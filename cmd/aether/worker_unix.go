@@ -0,0 +1,25 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// protocolOutput 复制标准输出用于协议消息,并把文件描述符 1 指向标准错误,
+// 使原生库与脚本直接写入标准输出的内容进入标准错误
+func protocolOutput() (*os.File, error) {
+	fd, err := unix.Dup(int(os.Stdout.Fd()))
+	if err != nil {
+		return nil, fmt.Errorf("无法复制标准输出: %w", err)
+	}
+	if err := unix.Dup2(int(os.Stderr.Fd()), int(os.Stdout.Fd())); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("无法重定向标准输出: %w", err)
+	}
+	unix.CloseOnExec(fd)
+	return os.NewFile(uintptr(fd), "/dev/stdout"), nil
+}
@@ -0,0 +1,42 @@
+// Aether 环境诊断工具
+//
+// 检查 cgo、工具链、预编译库与 aether.lock,并给出修复方法。它不链接 Aether 库,
+// 因此在库文件缺失、aether 命令无法编译时也能运行:
+//
+//	go run github.com/xiaozuhui/aether-go/cmd/doctor [flags]
+//
+// 参数:
+//
+//	--shared          检查 -tags aether_dynamic 构建使用的动态库
+//	--json            以 JSON 输出
+//
+// 有未通过的检查时退出码为 1
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/xiaozuhui/aether-go/internal/doctor"
+)
+
+func main() {
+	shared := flag.Bool("shared", false, "检查 -tags aether_dynamic 构建使用的动态库")
+	jsonOut := flag.Bool("json", false, "以 JSON 输出")
+	flag.Parse()
+
+	report := doctor.Diagnose(doctor.Options{Shared: *shared})
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		doctor.Print(os.Stdout, report)
+	}
+
+	if report.Failed() {
+		os.Exit(1)
+	}
+}
@@ -1,116 +1,157 @@
 // Aether 库文件下载工具
+//
+// 用法:
+//
+//	go run github.com/xiaozuhui/aether-go/cmd/fetch [flags]
+//
+// 参数:
+//
+//	--dir DIR         库文件保存目录(默认 lib)
+//	--version VER     Release 版本,默认与 aether-go 模块版本一致
+//	--platform P      目标平台,如 linux-arm64-musl(默认为当前平台)
+//	--mirror URL      镜像地址,支持 http(s):// 与 file://,{version} 会被替换为版本号
+//	--shared          下载动态库,用于 -tags aether_dynamic 构建
+//	--force           库文件已存在时也重新获取
+//	--insecure-skip-verify
+//	                  Release 没有发布 SHA256SUMS 时仍然下载,锁文件标记为未校验
+//	--dry-run         只显示将要进行的操作
+//	--json            以 JSON 输出结果
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
-	"runtime"
+
+	"github.com/xiaozuhui/aether-go/internal/fetch"
 )
 
 func main() {
-	fmt.Println("Aether 预编译库下载工具")
-	fmt.Println()
+	dir := flag.String("dir", "lib", "库文件保存目录")
+	version := flag.String("version", fetch.ModuleVersion(), "要下载的 Release 版本(如 v0.4.4),默认与 aether-go 模块版本一致,未知时为 latest")
+	platform := flag.String("platform", "", "目标平台(如 linux-arm64-musl),默认为当前平台")
+	mirror := flag.String("mirror", "", "镜像地址,支持 http(s):// 与 file://,{version} 会被替换为版本号")
+	shared := flag.Bool("shared", false, "下载动态库(libaether.so / libaether.dylib),用于 -tags aether_dynamic 构建")
+	force := flag.Bool("force", false, "库文件已存在时也重新获取")
+	insecure := flag.Bool("insecure-skip-verify", false, "Release 没有发布 SHA256SUMS 时仍然下载未经校验的库文件")
+	dryRun := flag.Bool("dry-run", false, "只显示将要进行的操作,不写入任何文件")
+	jsonOut := flag.Bool("json", false, "以 JSON 输出结果")
+	flag.Parse()
+
+	// JSON 模式下 stdout 只输出结果,提示信息写入 stderr
+	var out io.Writer = os.Stdout
+	if *jsonOut {
+		out = os.Stderr
+	}
+
+	fmt.Fprintln(out, "Aether 预编译库下载工具")
+	fmt.Fprintln(out)
+
+	res, err := fetchLibrary(out, fetchOptions{
+		dir:      *dir,
+		version:  *version,
+		platform: *platform,
+		mirror:   *mirror,
+		shared:   *shared,
+		force:    *force,
+		dryRun:   *dryRun,
+		insecure: *insecure,
+	})
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err != nil {
+			enc.Encode(map[string]string{"error": err.Error()})
+			os.Exit(1)
+		}
+		enc.Encode(res)
+		return
+	}
 
-	// 调用下载函数
-	if err := fetchLibrary(); err != nil {
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
 		os.Exit(1)
 	}
-
+	if res.DryRun {
+		fmt.Println("\n(dry-run) 未写入任何文件。")
+		return
+	}
 	fmt.Println("\n下载完成！现在可以使用 aether-go 了。")
 }
 
-func fetchLibrary() error {
-	fmt.Println("检测平台...")
-
-	// 检测当前平台
-	platform := detectPlatform()
-	fmt.Printf("平台: %s\n", platform)
-
-	// 检查是否为 macOS 平台
-	if !isMacOS(platform) {
-		fmt.Printf("\n注意: 当前仅提供 macOS 平台的预编译库。\n")
-		fmt.Printf("其他平台需要从源码编译 Aether Rust 库。\n")
-		fmt.Printf("\n编译步骤:\n")
-		fmt.Printf("  1. 克隆 Aether 仓库: git clone https://github.com/xiaozuhui/aether.git\n")
-		fmt.Printf("  2. 构建 Rust 库: cd aether && cargo build --release\n")
-		fmt.Printf("  3. 复制库文件: mkdir -p lib/ && cp target/release/libaether.a lib/\n")
-		return fmt.Errorf("不支持的平台: %s (仅支持 macOS)", platform)
-	}
+// fetchOptions 为命令行参数
+type fetchOptions struct {
+	dir      string
+	version  string
+	platform string
+	mirror   string
+	shared   bool
+	force    bool
+	dryRun   bool
+	insecure bool
+}
 
-	// 创建 lib 目录
-	libDir := "lib"
-	if err := os.MkdirAll(libDir, 0755); err != nil {
-		return fmt.Errorf("无法创建 lib 目录: %w", err)
+func fetchLibrary(out io.Writer, o fetchOptions) (*fetch.Result, error) {
+	if o.version == "" {
+		o.version = "latest"
 	}
-
-	// 构建下载文件名（包含架构信息）
-	// 文件名格式: libaether-darwin-arm64.a 或 libaether-darwin-amd64.a
-	libName := fmt.Sprintf("libaether-%s.a", platform)
-	url := fmt.Sprintf("https://github.com/xiaozuhui/aether-go/releases/latest/download/%s", libName)
-
-	// 本地保存路径（统一命名为 libaether.a）
-	outputFile := fmt.Sprintf("%s/libaether.a", libDir)
-
-	// 检查是否已存在
-	if _, err := os.Stat(outputFile); err == nil {
-		fmt.Printf("库文件已存在: %s\n", outputFile)
-		return nil
+	if err := fetch.ValidateVersion(o.version); err != nil {
+		return nil, err
 	}
 
-	// 下载文件
-	fmt.Printf("\n正在从 GitHub 下载...\n")
-	fmt.Printf("URL: %s\n", url)
-
-	// 使用 curl 或 wget 下载
-	if err := downloadFile(url, outputFile); err != nil {
-		return err
+	var platform fetch.Platform
+	if o.platform == "" {
+		fmt.Fprintln(out, "检测平台...")
+		platform = fetch.Detect()
+	} else {
+		p, err := fetch.ParsePlatform(o.platform)
+		if err != nil {
+			return nil, err
+		}
+		platform = p
 	}
-
-	fmt.Printf("\n库文件已下载到: %s\n", outputFile)
-	return nil
-}
-
-func detectPlatform() string {
-	os := runtime.GOOS
-	arch := runtime.GOARCH
-
-	// 转换架构名称
-	switch arch {
-	case "amd64":
-		// 保持不变
-	case "arm64":
-		// 保持不变
-	default:
-		arch = "amd64" // 默认
+	fmt.Fprintf(out, "平台: %s\n", platform)
+	fmt.Fprintf(out, "版本: %s\n", o.version)
+
+	// 检查是否提供预编译库
+	if err := platform.Supported(); err != nil {
+		fmt.Fprintf(out, "\n注意: 当前平台没有预编译库,需要从源码编译 Aether Rust 库。\n")
+		fmt.Fprintf(out, "\n编译步骤:\n")
+		fmt.Fprintf(out, "  1. 克隆 Aether 仓库: git clone https://github.com/xiaozuhui/aether.git\n")
+		fmt.Fprintf(out, "  2. 构建 Rust 库: cd aether && cargo build --release\n")
+		fmt.Fprintf(out, "  3. 复制库文件: mkdir -p %s/ && cp target/release/%s %s/\n", o.dir, platform.LibFileName(), o.dir)
+		return nil, err
 	}
 
-	return fmt.Sprintf("%s-%s", os, arch)
-}
-
-func isMacOS(platform string) bool {
-	return platform == "darwin-amd64" || platform == "darwin-arm64"
-}
-
-func downloadFile(url, outputFile string) error {
-	// 尝试使用 curl
-	if _, err := exec.LookPath("curl"); err == nil {
-		cmd := exec.Command("curl", "-L", "-f", "--progress-bar", url, "-o", outputFile)
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		return cmd.Run()
+	// 设置了 AETHER_MINISIGN_PUBKEY 时要求校验清单带有有效签名
+	publicKey, err := fetch.PublicKeyFromEnv()
+	if err != nil {
+		return nil, err
 	}
 
-	// 尝试使用 wget
-	if _, err := exec.LookPath("wget"); err == nil {
-		cmd := exec.Command("wget", "--show-progress", "-O", outputFile, url)
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		return cmd.Run()
+	fmt.Fprintln(out)
+	res, err := fetch.Library(fetch.Options{
+		Platform:  platform,
+		Version:   o.version,
+		BaseURL:   o.mirror,
+		LibDir:    o.dir,
+		Shared:    o.shared,
+		CacheDir:  fetch.DefaultCacheDir(),
+		Force:     o.force,
+		DryRun:    o.dryRun,
+		PublicKey: publicKey,
+		// 只有显式指定时才允许跳过校验
+		InsecureSkipVerify: o.insecure,
+		Stdout:             out,
+		Stderr:             os.Stderr,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return fmt.Errorf("需要 curl 或 wget 来下载文件")
+	fmt.Fprintf(out, "\n库文件位于: %s\n", res.Path)
+	return res, nil
 }
@@ -0,0 +1,184 @@
+package aether
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// maxRecentErrors 为每个引擎保留的最近错误数
+const maxRecentErrors = 20
+
+// ErrorRecord 表示一次失败的 Eval 调用
+type ErrorRecord struct {
+	Time     time.Time     `json:"time"`
+	Code     ErrorCode     `json:"code"`
+	Message  string        `json:"message"`
+	Duration time.Duration `json:"duration"`
+}
+
+// EngineSnapshot 表示引擎在某一时刻的诊断信息
+type EngineSnapshot struct {
+	Name         string        `json:"name"`
+	Version      string        `json:"version"`
+	Closed       bool          `json:"closed"`
+	Limits       *Limits       `json:"limits,omitempty"`
+	Cache        *CacheStats   `json:"cache,omitempty"`
+	Trace        *TraceStats   `json:"trace,omitempty"`
+	Optimization *Optimization `json:"optimization,omitempty"`
+	Globals      []string      `json:"globals"`
+	RecentErrors []ErrorRecord `json:"recent_errors"`
+}
+
+// recordError 记录一次失败的执行,只保留最近 maxRecentErrors 条
+func (e *Engine) recordError(ev EvalEvent) {
+	e.errMu.Lock()
+	defer e.errMu.Unlock()
+
+	e.recentErrors = append(e.recentErrors, ErrorRecord{
+		Time:     time.Now(),
+		Code:     ev.Code,
+		Message:  ev.Err.Error(),
+		Duration: ev.Duration,
+	})
+	if n := len(e.recentErrors); n > maxRecentErrors {
+		e.recentErrors = append(e.recentErrors[:0], e.recentErrors[n-maxRecentErrors:]...)
+	}
+}
+
+// RecentErrors 返回最近失败的 Eval 调用,按时间从旧到新排列
+//
+// 此方法是线程安全的
+func (e *Engine) RecentErrors() []ErrorRecord {
+	e.errMu.Lock()
+	defer e.errMu.Unlock()
+
+	records := make([]ErrorRecord, len(e.recentErrors))
+	copy(records, e.recentErrors)
+	return records
+}
+
+// Globals 返回通过 SetGlobal 设置的全局变量名,按字母顺序排列
+//
+// 原生库不提供变量枚举,脚本中用 Set 定义的变量不会出现在结果中。
+// ResetEnv 会清空该列表。
+// 此方法是线程安全的
+func (e *Engine) Globals() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	names := make([]string, 0, len(e.globals))
+	for name := range e.globals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Snapshot 返回引擎当前的诊断信息
+//
+// 此方法是线程安全的
+func (e *Engine) Snapshot(name string) EngineSnapshot {
+	snap := EngineSnapshot{
+		Name:         name,
+		Version:      Version(),
+		Globals:      e.Globals(),
+		RecentErrors: e.RecentErrors(),
+	}
+
+	var err error
+	if snap.Limits, err = e.GetExecutionLimits(); err != nil {
+		snap.Closed = true
+		return snap
+	}
+	snap.Cache, _ = e.CacheStats()
+	snap.Trace, _ = e.TraceStats()
+	snap.Optimization, _ = e.GetOptimization()
+	return snap
+}
+
+// snapshots 返回一组引擎的诊断信息,引擎按位置命名为 engine-0、engine-1 ...
+func snapshots(engines []*Engine) []EngineSnapshot {
+	snaps := make([]EngineSnapshot, len(engines))
+	for i, e := range engines {
+		snaps[i] = e.Snapshot(fmt.Sprintf("engine-%d", i))
+	}
+	return snaps
+}
+
+var debugTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Aether 引擎</title></head>
+<body>
+<h1>Aether 引擎</h1>
+<p>版本: {{.Version}} · <a href="?format=json">JSON</a></p>
+{{range .Engines}}
+<h2>{{.Name}}{{if .Closed}} (已关闭){{end}}</h2>
+{{if not .Closed}}
+<table border="1" cellpadding="4">
+<tr><th>最大步数</th><td>{{.Limits.MaxSteps}}</td></tr>
+<tr><th>最大递归深度</th><td>{{.Limits.MaxRecursionDepth}}</td></tr>
+<tr><th>最大执行时间(毫秒)</th><td>{{.Limits.MaxDurationMs}}</td></tr>
+{{with .Cache}}<tr><th>缓存</th><td>命中={{.Hits}} 未命中={{.Misses}} 大小={{.Size}} 淘汰={{.Evictions}} 字节={{.Bytes}}</td></tr>{{end}}
+{{with .Trace}}<tr><th>追踪</th><td>条目={{.TotalEntries}} 缓冲区={{.BufferSize}} 已满={{.BufferFull}}</td></tr>{{end}}
+{{with .Optimization}}<tr><th>优化</th><td>{{.Profile}} (常量折叠={{.ConstantFolding}} 死代码消除={{.DeadCodeElimination}} 尾递归={{.TailRecursion}})</td></tr>{{end}}
+<tr><th>全局变量</th><td>{{range .Globals}}<code>{{.}}</code> {{else}}-{{end}}</td></tr>
+</table>
+{{end}}
+<h3>最近错误</h3>
+{{if .RecentErrors}}
+<table border="1" cellpadding="4">
+<tr><th>时间</th><th>代码</th><th>耗时</th><th>信息</th></tr>
+{{range .RecentErrors}}<tr><td>{{.Time.Format "2006-01-02 15:04:05.000"}}</td><td>{{.Code}}</td><td>{{.Duration}}</td><td><pre>{{.Message}}</pre></td></tr>
+{{end}}
+</table>
+{{else}}<p>无</p>{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// DebugHandler 返回展示引擎诊断信息的 http.Handler
+//
+// 默认输出 HTML 页面;请求带有 ?format=json 或 Accept: application/json 时输出 JSON。
+// 可以挂载到任意 http.ServeMux:
+//
+//	mux.Handle("/debug/aether", aether.DebugHandler(engine))
+func DebugHandler(engines ...*Engine) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := struct {
+			Version string           `json:"version"`
+			Engines []EngineSnapshot `json:"engines"`
+		}{
+			Version: Version(),
+			Engines: snapshots(engines),
+		}
+
+		if r.URL.Query().Get("format") == "json" || r.Header.Get("Accept") == "application/json" {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			enc.Encode(data)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := debugTemplate.Execute(w, data); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// PublishExpvar 以 name 为键将引擎诊断信息发布到 expvar
+//
+// 发布后可以通过 /debug/vars 查看。与 expvar.Publish 一样,
+// 同一个 name 重复发布会 panic。
+func PublishExpvar(name string, engines ...*Engine) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return snapshots(engines)
+	}))
+}
@@ -0,0 +1,189 @@
+//go:build aether_dynamic && !windows
+
+package aether
+
+// 使用 -tags aether_dynamic 构建时,不在编译期链接 libaether.a,而是在运行时通过 dlopen
+// 加载 libaether.so (macOS 为 libaether.dylib)。这样库文件不必放在只读的模块缓存中。
+//
+// 下面的 C 代码为 aether.h 中的每个函数定义同名的跳板函数,调用 dlsym 解析到的函数指针。
+// 由于 aether.h 末尾有 cbindgen 生成的无效声明,这里不直接包含它。
+
+/*
+#cgo linux LDFLAGS: -ldl
+#include <dlfcn.h>
+#include <stdlib.h>
+#include <string.h>
+
+struct AetherHandle;
+struct AetherLimits;
+struct AetherCacheStats;
+
+// 有返回值的函数: X(返回类型, 函数名, 参数列表, 实参列表)
+#define AETHER_FUNCS(X) \
+	X(struct AetherHandle*, aether_new, (void), ()) \
+	X(struct AetherHandle*, aether_new_with_permissions, (void), ()) \
+	X(int, aether_eval, (struct AetherHandle* h, const char* code, char** result, char** error), (h, code, result, error)) \
+	X(const char*, aether_version, (void), ()) \
+	X(int, aether_set_global, (struct AetherHandle* h, const char* name, const char* value_json), (h, name, value_json)) \
+	X(int, aether_get_global, (struct AetherHandle* h, const char* name, char** value_json), (h, name, value_json)) \
+	X(int, aether_take_trace, (struct AetherHandle* h, char** trace_json), (h, trace_json)) \
+	X(int, aether_trace_records, (struct AetherHandle* h, char** trace_json), (h, trace_json)) \
+	X(int, aether_trace_stats, (struct AetherHandle* h, char** stats_json), (h, stats_json))
+
+// 无返回值的函数: X(函数名, 参数列表, 实参列表)
+#define AETHER_VOID_FUNCS(X) \
+	X(aether_free, (struct AetherHandle* h), (h)) \
+	X(aether_free_string, (char* s), (s)) \
+	X(aether_reset_env, (struct AetherHandle* h), (h)) \
+	X(aether_clear_trace, (struct AetherHandle* h), (h)) \
+	X(aether_set_limits, (struct AetherHandle* h, const struct AetherLimits* limits), (h, limits)) \
+	X(aether_get_limits, (struct AetherHandle* h, struct AetherLimits* limits), (h, limits)) \
+	X(aether_clear_cache, (struct AetherHandle* h), (h)) \
+	X(aether_cache_stats, (struct AetherHandle* h, struct AetherCacheStats* stats), (h, stats)) \
+	X(aether_set_optimization, (struct AetherHandle* h, int constant_folding, int dead_code, int tail_recursion), (h, constant_folding, dead_code, tail_recursion))
+
+#define AETHER_PTR(ret, name, params, args) static ret (*p_##name) params;
+#define AETHER_VOID_PTR(name, params, args) static void (*p_##name) params;
+AETHER_FUNCS(AETHER_PTR)
+AETHER_VOID_FUNCS(AETHER_VOID_PTR)
+
+#define AETHER_TRAMPOLINE(ret, name, params, args) ret name params { return p_##name args; }
+#define AETHER_VOID_TRAMPOLINE(name, params, args) void name params { p_##name args; }
+AETHER_FUNCS(AETHER_TRAMPOLINE)
+AETHER_VOID_FUNCS(AETHER_VOID_TRAMPOLINE)
+
+static void* aether_dl;
+
+// aether_dl_open 加载 path 并解析所有符号
+//
+// 成功返回 0;dlopen 失败返回 1,错误信息写入 msg;
+// 缺少符号返回 2,缺少的符号名以逗号分隔写入 msg
+static int aether_dl_open(const char* path, char* msg, size_t len) {
+	msg[0] = '\0';
+	void* h = dlopen(path, RTLD_NOW | RTLD_LOCAL);
+	if (h == NULL) {
+		const char* err = dlerror();
+		strncpy(msg, err ? err : "dlopen failed", len - 1);
+		msg[len - 1] = '\0';
+		return 1;
+	}
+
+#define AETHER_CHECK(name) \
+	if (dlsym(h, #name) == NULL) { \
+		if (msg[0] != '\0') strncat(msg, ", ", len - strlen(msg) - 1); \
+		strncat(msg, #name, len - strlen(msg) - 1); \
+	}
+#define AETHER_CHECK_FUNC(ret, name, params, args) AETHER_CHECK(name)
+#define AETHER_CHECK_VOID_FUNC(name, params, args) AETHER_CHECK(name)
+	AETHER_FUNCS(AETHER_CHECK_FUNC)
+	AETHER_VOID_FUNCS(AETHER_CHECK_VOID_FUNC)
+	if (msg[0] != '\0') {
+		dlclose(h);
+		return 2;
+	}
+
+#define AETHER_LOAD(ret, name, params, args) p_##name = (ret (*) params) dlsym(h, #name);
+#define AETHER_VOID_LOAD(name, params, args) p_##name = (void (*) params) dlsym(h, #name);
+	AETHER_FUNCS(AETHER_LOAD)
+	AETHER_VOID_FUNCS(AETHER_VOID_LOAD)
+	aether_dl = h;
+	return 0;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"unsafe"
+
+	"github.com/xiaozuhui/aether-go/internal/fetch"
+)
+
+// dynamicLibrary 表示是否在运行时加载动态库
+const dynamicLibrary = true
+
+var (
+	// dlMu 保护动态库的加载状态
+	dlMu sync.Mutex
+	// dlPath 为已加载的动态库路径,为空表示尚未加载
+	dlPath string
+)
+
+// loadLibrary 确保动态库已加载,尚未加载时从默认位置加载
+//
+// 默认位置为 AETHER_LIB_DIR 或模块根目录下 lib 中的 libaether.so (macOS 为 libaether.dylib)
+func loadLibrary() error {
+	dlMu.Lock()
+	defer dlMu.Unlock()
+
+	if dlPath != "" {
+		return nil
+	}
+	dir, _ := locateLibDir()
+	return openLibrary(filepath.Join(dir, libFileName()))
+}
+
+// libraryDir 返回已加载的动态库所在的目录,尚未加载时返回默认目录
+func libraryDir() string {
+	dlMu.Lock()
+	defer dlMu.Unlock()
+
+	if dlPath != "" {
+		return filepath.Dir(dlPath)
+	}
+	dir, _ := locateLibDir()
+	return dir
+}
+
+// LoadLibrary 从 path 加载动态库
+//
+// 只在使用 -tags aether_dynamic 构建时可用。必须在创建任何 Engine 之前调用;
+// 不调用时,第一次创建 Engine 或调用 Version 会从 AETHER_LIB_DIR 或模块根目录的 lib 加载。
+// 库文件与所在目录的 aether.lock 记录的 SHA-256 不一致时返回包装了 ErrLibraryModified 的错误;
+// 库文件缺少 aether.h 中的任何函数,或版本与本绑定的 ABI 不兼容时,返回包装了
+// ErrIncompatibleLibrary 的错误;版本不在兼容表中时返回包装了 ErrUnknownLibraryVersion
+// 的错误,此时库已加载,可以继续使用。已加载其他库时返回错误,同一路径重复加载返回 nil
+func LoadLibrary(path string) error {
+	if err := openLibraryOnce(path); err != nil {
+		return err
+	}
+	return CheckABI()
+}
+
+// openLibraryOnce 加载 path,已加载同一路径时直接返回
+func openLibraryOnce(path string) error {
+	dlMu.Lock()
+	defer dlMu.Unlock()
+
+	if dlPath != "" {
+		if dlPath == path {
+			return nil
+		}
+		return fmt.Errorf("aether: 已加载 %s,不能再加载 %s", dlPath, path)
+	}
+	return openLibrary(path)
+}
+
+// openLibrary 按 aether.lock 校验 path 后调用 dlopen 加载,调用者必须持有 dlMu
+func openLibrary(path string) error {
+	if err := verifyLibrary(path, fetch.Detect().SharedAssetName()); err != nil {
+		return err
+	}
+
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+
+	var msg [1024]C.char
+	switch C.aether_dl_open(cPath, &msg[0], C.size_t(len(msg))) {
+	case 0:
+		dlPath = path
+		return nil
+	case 2:
+		return fmt.Errorf("%w: %s 缺少符号: %s", ErrIncompatibleLibrary, path, C.GoString(&msg[0]))
+	default:
+		return fmt.Errorf("aether: 无法加载 %s: %s\n解决方法: 运行 go run github.com/xiaozuhui/aether-go/cmd/fetch --shared,或设置 AETHER_LIB_DIR",
+			path, C.GoString(&msg[0]))
+	}
+}
@@ -0,0 +1,60 @@
+//go:build aether_dynamic && !windows
+
+package aether
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/xiaozuhui/aether-go/internal/fetch"
+)
+
+// TestLoadLibrary 测试运行时加载动态库
+func TestLoadLibrary(t *testing.T) {
+	if err := loadLibrary(); err != nil {
+		t.Fatalf("加载默认动态库失败: %v", err)
+	}
+	dir, _ := locateLibDir()
+	if err := LoadLibrary(filepath.Join(dir, libFileName())); err != nil {
+		t.Errorf("重复加载同一个库应返回 nil: %v", err)
+	}
+	if err := LoadLibrary("/other/libaether.so"); err == nil {
+		t.Error("已加载后加载其他库应报错")
+	}
+
+	// 直接调用 openLibrary 检查错误信息,不影响已加载的库
+	dlMu.Lock()
+	defer dlMu.Unlock()
+
+	err := openLibrary(filepath.Join(t.TempDir(), "missing.so"))
+	if err == nil || !strings.Contains(err.Error(), "无法加载") {
+		t.Errorf("不存在的文件应报告无法加载: %v", err)
+	}
+
+	// 与 aether.lock 不一致的库文件在 dlopen 之前被拒绝
+	tmp := t.TempDir()
+	tampered := filepath.Join(tmp, libFileName())
+	if err := os.WriteFile(tampered, []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lock := &fetch.Lock{Version: "v0.4.4", Asset: fetch.Detect().SharedAssetName(), SHA256: strings.Repeat("0", 64)}
+	if err := fetch.WriteLock(tmp, lock); err != nil {
+		t.Fatal(err)
+	}
+	if err := openLibrary(tampered); !errors.Is(err, ErrLibraryModified) {
+		t.Errorf("期望 ErrLibraryModified,得到 %v", err)
+	}
+
+	libc := "libc.so.6"
+	if runtime.GOOS == "darwin" {
+		libc = "/usr/lib/libSystem.B.dylib"
+	}
+	err = openLibrary(libc)
+	if err == nil || !strings.Contains(err.Error(), "缺少符号") || !strings.Contains(err.Error(), "aether_eval") {
+		t.Errorf("非 Aether 库应列出缺少的符号: %v", err)
+	}
+}
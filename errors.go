@@ -0,0 +1,43 @@
+package aether
+
+import "github.com/xiaozuhui/aether-go/aethertypes"
+
+// ErrorCode 对应原生库返回的 AetherErrorCode
+type ErrorCode = aethertypes.ErrorCode
+
+const (
+	// CodeSuccess 执行成功
+	CodeSuccess = aethertypes.CodeSuccess
+	// CodeParseError 解析错误
+	CodeParseError = aethertypes.CodeParseError
+	// CodeRuntimeError 运行时错误
+	CodeRuntimeError = aethertypes.CodeRuntimeError
+	// CodeNullPointer 空指针(引擎已关闭等)
+	CodeNullPointer = aethertypes.CodeNullPointer
+	// CodePanic 原生库内部 panic
+	CodePanic = aethertypes.CodePanic
+	// CodeInvalidJSON 无效的 JSON
+	CodeInvalidJSON = aethertypes.CodeInvalidJSON
+	// CodeVariableNotFound 变量未找到
+	CodeVariableNotFound = aethertypes.CodeVariableNotFound
+)
+
+// Error 表示带有错误代码的 Aether 错误
+//
+// 使用 errors.As 获取错误代码:
+//
+//	var aerr *aether.Error
+//	if errors.As(err, &aerr) && aerr.Code == aether.CodeParseError {
+//	    // 语法错误
+//	}
+type Error = aethertypes.Error
+
+// ErrClosed 在引擎关闭后调用其方法时返回
+var ErrClosed = aethertypes.ErrClosed
+
+// CodeOf 返回错误对应的错误代码
+//
+// err 为 nil 时返回 CodeSuccess; 不是 *Error 的错误视为 CodeRuntimeError
+func CodeOf(err error) ErrorCode {
+	return aethertypes.CodeOf(err)
+}
@@ -0,0 +1,18 @@
+package aether
+
+import "github.com/xiaozuhui/aether-go/aethertypes"
+
+// Evaluator 是 Aether 引擎的方法集
+//
+// *Engine、*Pool 与 aetherfake 中的测试替身都实现了此接口。库代码应接受
+// Evaluator 而不是 *Engine,以便调用者替换后端或在测试中注入替身:
+//
+//	func Render(ev aether.Evaluator, tmpl string) (string, error) {
+//	    return ev.Eval(tmpl)
+//	}
+type Evaluator = aethertypes.Evaluator
+
+var (
+	_ Evaluator = (*Engine)(nil)
+	_ Evaluator = (*Pool)(nil)
+)
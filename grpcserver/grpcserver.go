@@ -0,0 +1,249 @@
+// Package grpcserver 在 server.Server 之上实现 aetherpb.AetherServer
+//
+// 基本用法:
+//
+//	srv, err := server.New(server.Config{PoolSize: runtime.NumCPU()})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer srv.Close()
+//
+//	gs := grpc.NewServer()
+//	grpcserver.Register(gs, srv)
+//	gs.Serve(lis)
+//
+// 与 HTTP 接口共享引擎池、已注册的脚本与执行限制。调用的截止时间会缩短
+// Limits.MaxDurationMs,脚本错误按下表映射为 gRPC 错误代码:
+//
+//	parse_error, invalid_json   InvalidArgument
+//	runtime_error               FailedPrecondition (超过调用截止时间时为 DeadlineExceeded)
+//	variable_not_found          NotFound
+//	null_pointer, panic         Internal
+//
+// 错误详情中附带 aetherpb.EvalResponse,可以通过 status.FromError(err).Details() 读取
+// 出错前产生的追踪条目
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	aether "github.com/xiaozuhui/aether-go"
+	"github.com/xiaozuhui/aether-go/aetherpb"
+	"github.com/xiaozuhui/aether-go/server"
+)
+
+// Service 实现 aetherpb.AetherServer
+type Service struct {
+	aetherpb.UnimplementedAetherServer
+	srv *server.Server
+}
+
+// New 创建使用 srv 执行脚本的服务
+func New(srv *server.Server) *Service {
+	return &Service{srv: srv}
+}
+
+// Register 在 gs 上注册使用 srv 的 Aether 服务
+func Register(gs *grpc.Server, srv *server.Server) {
+	aetherpb.RegisterAetherServer(gs, New(srv))
+}
+
+// Eval 实现 aetherpb.AetherServer
+func (s *Service) Eval(ctx context.Context, req *aetherpb.EvalRequest) (*aetherpb.EvalResponse, error) {
+	resp, err := s.srv.Eval(ctx, server.EvalRequest{
+		Code:    req.GetCode(),
+		Globals: globals(req.GetGlobals()),
+		Limits:  limits(req.GetLimits()),
+	})
+	return result(ctx, resp, err)
+}
+
+// RegisterProgram 实现 aetherpb.AetherServer
+func (s *Service) RegisterProgram(ctx context.Context, req *aetherpb.RegisterProgramRequest) (*aetherpb.RegisterProgramResponse, error) {
+	created, err := s.srv.RegisterProgram(server.Program{Name: req.GetName(), Code: req.GetCode()})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &aetherpb.RegisterProgramResponse{Name: req.GetName(), Created: created}, nil
+}
+
+// RunProgram 实现 aetherpb.AetherServer
+func (s *Service) RunProgram(ctx context.Context, req *aetherpb.RunProgramRequest) (*aetherpb.EvalResponse, error) {
+	resp, err := s.srv.RunProgram(ctx, req.GetName(), server.RunRequest{
+		Globals: globals(req.GetGlobals()),
+		Limits:  limits(req.GetLimits()),
+	})
+	return result(ctx, resp, err)
+}
+
+// StreamTrace 实现 aetherpb.AetherServer
+func (s *Service) StreamTrace(req *aetherpb.StreamTraceRequest, stream aetherpb.Aether_StreamTraceServer) error {
+	ctx := stream.Context()
+
+	var resp *server.EvalResponse
+	var err error
+	switch src := req.GetSource().(type) {
+	case *aetherpb.StreamTraceRequest_Code:
+		resp, err = s.srv.Eval(ctx, server.EvalRequest{
+			Code:    src.Code,
+			Globals: globals(req.GetGlobals()),
+			Limits:  limits(req.GetLimits()),
+		})
+	case *aetherpb.StreamTraceRequest_Program:
+		resp, err = s.srv.RunProgram(ctx, src.Program, server.RunRequest{
+			Globals: globals(req.GetGlobals()),
+			Limits:  limits(req.GetLimits()),
+		})
+	default:
+		return status.Error(codes.InvalidArgument, "必须指定 code 或 program")
+	}
+	if err != nil {
+		return statusError(err)
+	}
+
+	for _, entry := range resp.Trace {
+		event := &aetherpb.StreamTraceResponse{Event: &aetherpb.StreamTraceResponse_Entry{Entry: traceEntry(entry)}}
+		if err := stream.Send(event); err != nil {
+			return err
+		}
+	}
+
+	resp.Trace = nil
+	final, err := result(ctx, resp, nil)
+	if err != nil {
+		return err
+	}
+	return stream.Send(&aetherpb.StreamTraceResponse{Event: &aetherpb.StreamTraceResponse_Result{Result: final}})
+}
+
+// result 把执行结果转换为响应,脚本出错时返回带有详情的 gRPC 错误
+func result(ctx context.Context, resp *server.EvalResponse, err error) (*aetherpb.EvalResponse, error) {
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	pb := evalResponse(resp)
+	if resp.Error == nil {
+		return pb, nil
+	}
+
+	code := errorCode(resp.Error.Code)
+	grpcCode := Code(code)
+	if code == aether.CodeRuntimeError && deadlineExceeded(ctx, resp.Usage) {
+		grpcCode = codes.DeadlineExceeded
+	}
+	st := status.New(grpcCode, resp.Error.Message)
+	if withDetails, err := st.WithDetails(pb); err == nil {
+		st = withDetails
+	}
+	return nil, st.Err()
+}
+
+// deadlineExceeded 返回运行时错误是否由调用的截止时间引起
+//
+// 执行时间限制按毫秒向下取整,脚本超时返回时截止时间通常还没有到,
+// 因此除了 ctx.Err() 还要检查由截止时间得出的限制是否已经用尽
+func deadlineExceeded(ctx context.Context, usage server.Usage) bool {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return true
+	}
+	return usage.Deadline && usage.DurationMs >= float64(usage.Limits.TimeoutMs)
+}
+
+// Code 返回 Aether 错误代码对应的 gRPC 错误代码
+func Code(code aether.ErrorCode) codes.Code {
+	switch code {
+	case aether.CodeSuccess:
+		return codes.OK
+	case aether.CodeParseError, aether.CodeInvalidJSON:
+		return codes.InvalidArgument
+	case aether.CodeRuntimeError:
+		return codes.FailedPrecondition
+	case aether.CodeVariableNotFound:
+		return codes.NotFound
+	case aether.CodeNullPointer, aether.CodePanic:
+		return codes.Internal
+	default:
+		return codes.Unknown
+	}
+}
+
+// statusError 把 server 包返回的请求错误转换为 gRPC 错误
+func statusError(err error) error {
+	switch {
+	case errors.Is(err, server.ErrProgramNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, server.ErrInvalidName):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, server.ErrCodeTooLarge), errors.Is(err, server.ErrTooManyPrograms):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, aether.ErrPoolClosed):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// errorCode 由错误代码的名称还原 aether.ErrorCode
+func errorCode(name string) aether.ErrorCode {
+	for c := aether.CodeSuccess; c <= aether.CodeVariableNotFound; c++ {
+		if c.String() == name {
+			return c
+		}
+	}
+	return aether.CodeRuntimeError
+}
+
+func globals(s *structpb.Struct) map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
+
+func limits(l *aetherpb.Limits) *server.Limits {
+	if l == nil {
+		return nil
+	}
+	return &server.Limits{MaxSteps: int(l.MaxSteps), MaxDepth: int(l.MaxDepth), TimeoutMs: int(l.TimeoutMs)}
+}
+
+func evalResponse(resp *server.EvalResponse) *aetherpb.EvalResponse {
+	pb := &aetherpb.EvalResponse{
+		Result: resp.Result,
+		Usage: &aetherpb.Usage{
+			DurationMs: resp.Usage.DurationMs,
+			WaitMs:     resp.Usage.WaitMs,
+			Limits: &aetherpb.Limits{
+				MaxSteps:  int32(resp.Usage.Limits.MaxSteps),
+				MaxDepth:  int32(resp.Usage.Limits.MaxDepth),
+				TimeoutMs: int32(resp.Usage.Limits.TimeoutMs),
+			},
+		},
+	}
+	if resp.Error != nil {
+		pb.Error = &aetherpb.Error{Code: aetherpb.ErrorCode(errorCode(resp.Error.Code)), Message: resp.Error.Message}
+	}
+	for _, entry := range resp.Trace {
+		pb.Trace = append(pb.Trace, traceEntry(entry))
+	}
+	return pb
+}
+
+func traceEntry(e aether.TraceEntry) *aetherpb.TraceEntry {
+	return &aetherpb.TraceEntry{
+		Level:     e.Level,
+		Category:  e.Category,
+		Timestamp: e.Timestamp,
+		Values:    e.Values,
+		Label:     e.Label,
+	}
+}
@@ -0,0 +1,307 @@
+package grpcserver
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	aether "github.com/xiaozuhui/aether-go"
+	"github.com/xiaozuhui/aether-go/aetherpb"
+	"github.com/xiaozuhui/aether-go/server"
+)
+
+// dial 在内存连接上启动服务并返回客户端
+func dial(t *testing.T, cfg server.Config) aetherpb.AetherClient {
+	t.Helper()
+	srv, err := server.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dialServer(t, srv)
+}
+
+// dialServer 在内存连接上启动 srv 并返回客户端,测试结束时关闭 srv
+func dialServer(t *testing.T, srv *server.Server) aetherpb.AetherClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	Register(gs, srv)
+	go gs.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		gs.Stop()
+		srv.Close()
+	})
+	return aetherpb.NewAetherClient(conn)
+}
+
+func mustStruct(t *testing.T, m map[string]interface{}) *structpb.Struct {
+	t.Helper()
+	s, err := structpb.NewStruct(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// details 返回 gRPC 错误详情中的 EvalResponse
+func details(t *testing.T, err error) *aetherpb.EvalResponse {
+	t.Helper()
+	st, _ := status.FromError(err)
+	for _, d := range st.Details() {
+		if resp, ok := d.(*aetherpb.EvalResponse); ok {
+			return resp
+		}
+	}
+	t.Fatalf("错误 %v 中没有 EvalResponse 详情", err)
+	return nil
+}
+
+// TestEval 测试执行代码并返回结果与追踪条目
+func TestEval(t *testing.T) {
+	client := dial(t, server.Config{PoolSize: 1})
+
+	resp, err := client.Eval(context.Background(), &aetherpb.EvalRequest{
+		Code:    "Set X (A + 1)\nTRACE_INFO(\"calc\", X)\nX",
+		Globals: mustStruct(t, map[string]interface{}{"A": 41}),
+	})
+	if err != nil {
+		t.Fatalf("Eval 失败: %v", err)
+	}
+	if resp.Result != "42" {
+		t.Errorf("期望 42,得到 %q", resp.Result)
+	}
+	if len(resp.Trace) != 1 || resp.Trace[0].Category != "calc" || resp.Trace[0].Values[0] != "42" {
+		t.Errorf("追踪条目不正确: %v", resp.Trace)
+	}
+	if resp.Error != nil {
+		t.Errorf("成功时期望没有错误,得到 %v", resp.Error)
+	}
+}
+
+// TestEvalError 测试脚本错误映射为 gRPC 错误并附带详情
+func TestEvalError(t *testing.T) {
+	client := dial(t, server.Config{PoolSize: 1})
+
+	_, err := client.Eval(context.Background(), &aetherpb.EvalRequest{Code: "TRACE_INFO(\"before\", 1)\nUNDEFINED_VAR"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("期望 FailedPrecondition,得到 %v", err)
+	}
+	resp := details(t, err)
+	if resp.Error.GetCode() != aetherpb.ErrorCode_RUNTIME_ERROR {
+		t.Errorf("期望 RUNTIME_ERROR,得到 %v", resp.Error.GetCode())
+	}
+	if len(resp.Trace) != 1 || resp.Trace[0].Category != "before" {
+		t.Errorf("期望详情中包含出错前的追踪条目,得到 %v", resp.Trace)
+	}
+}
+
+// TestCode 测试错误代码映射
+func TestCode(t *testing.T) {
+	tests := map[aether.ErrorCode]codes.Code{
+		aether.CodeSuccess:          codes.OK,
+		aether.CodeParseError:       codes.InvalidArgument,
+		aether.CodeRuntimeError:     codes.FailedPrecondition,
+		aether.CodeNullPointer:      codes.Internal,
+		aether.CodePanic:            codes.Internal,
+		aether.CodeInvalidJSON:      codes.InvalidArgument,
+		aether.CodeVariableNotFound: codes.NotFound,
+		aether.ErrorCode(99):        codes.Unknown,
+	}
+	for code, want := range tests {
+		if got := Code(code); got != want {
+			t.Errorf("%s: 期望 %v,得到 %v", code, want, got)
+		}
+	}
+
+	for c := aether.CodeSuccess; c <= aether.CodeVariableNotFound; c++ {
+		if got := errorCode(c.String()); got != c {
+			t.Errorf("errorCode(%q) = %v", c.String(), got)
+		}
+		if name := aetherpb.ErrorCode(c).String(); name == "" {
+			t.Errorf("aetherpb.ErrorCode 缺少 %v", c)
+		}
+	}
+}
+
+// TestDeadlinePropagation 测试调用的截止时间缩短执行时间限制
+func TestDeadlinePropagation(t *testing.T) {
+	client := dial(t, server.Config{Limits: aether.Limits{MaxSteps: -1, MaxRecursionDepth: -1, MaxDurationMs: 60000}})
+
+	resp, err := client.Eval(context.Background(), &aetherpb.EvalRequest{Code: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Usage.Limits.TimeoutMs; got != 60000 {
+		t.Errorf("没有截止时间时期望 60000ms,得到 %d", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	resp, err = client.Eval(ctx, &aetherpb.EvalRequest{Code: "1", Limits: &aetherpb.Limits{TimeoutMs: 30000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Usage.Limits.TimeoutMs; got <= 0 || got > 500 {
+		t.Errorf("期望执行时间限制不超过截止时间 500ms,得到 %d", got)
+	}
+}
+
+// TestDeadlineAfterWait 测试在引擎池中排队的调用按借出引擎后的剩余时间限制执行时间
+func TestDeadlineAfterWait(t *testing.T) {
+	srv, err := server.New(server.Config{PoolSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := dialServer(t, srv)
+
+	e, err := srv.Pool().Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		srv.Pool().Release(e)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	resp, err := client.Eval(ctx, &aetherpb.EvalRequest{Code: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Usage.WaitMs < 150 {
+		t.Fatalf("期望在引擎池中等待,等待了 %.1fms", resp.Usage.WaitMs)
+	}
+	if got := resp.Usage.Limits.TimeoutMs; got <= 0 || got > 500-150 {
+		t.Errorf("期望执行时间限制扣除等待时间,得到 %dms", got)
+	}
+}
+
+// TestDeadlineExceeded 测试由截止时间得出的执行时间限制用尽时返回 DeadlineExceeded
+func TestDeadlineExceeded(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	timeout := &server.EvalResponse{
+		Error: &server.ErrorBody{Code: aether.CodeRuntimeError.String(), Message: "timeout"},
+		Usage: server.Usage{DurationMs: 120.5, Limits: server.Limits{TimeoutMs: 120}, Deadline: true},
+	}
+	if _, err := result(ctx, timeout, nil); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("截止时间内超时: 期望 DeadlineExceeded,得到 %v", err)
+	}
+
+	// 执行时间限制来自服务配置时仍为 FailedPrecondition
+	timeout.Usage.Deadline = false
+	if _, err := result(ctx, timeout, nil); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("服务限制超时: 期望 FailedPrecondition,得到 %v", err)
+	}
+
+	// 限制没有用尽时的运行时错误不是超时
+	timeout.Usage = server.Usage{DurationMs: 3, Limits: server.Limits{TimeoutMs: 120}, Deadline: true}
+	if _, err := result(ctx, timeout, nil); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("其他运行时错误: 期望 FailedPrecondition,得到 %v", err)
+	}
+}
+
+// TestPrograms 测试注册与执行命名脚本
+func TestPrograms(t *testing.T) {
+	client := dial(t, server.Config{MaxPrograms: 1})
+	ctx := context.Background()
+
+	reg, err := client.RegisterProgram(ctx, &aetherpb.RegisterProgramRequest{Name: "double", Code: "(A * 2)"})
+	if err != nil || !reg.Created {
+		t.Fatalf("期望新注册,得到 %v %v", reg, err)
+	}
+	reg, err = client.RegisterProgram(ctx, &aetherpb.RegisterProgramRequest{Name: "double", Code: "(A * 3)"})
+	if err != nil || reg.Created {
+		t.Fatalf("期望替换,得到 %v %v", reg, err)
+	}
+
+	resp, err := client.RunProgram(ctx, &aetherpb.RunProgramRequest{Name: "double", Globals: mustStruct(t, map[string]interface{}{"A": 5})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Result != "15" {
+		t.Errorf("期望 15,得到 %q", resp.Result)
+	}
+
+	_, errMissing := client.RunProgram(ctx, &aetherpb.RunProgramRequest{Name: "missing"})
+	_, errName := client.RegisterProgram(ctx, &aetherpb.RegisterProgramRequest{Name: "a/b", Code: "1"})
+	_, errFull := client.RegisterProgram(ctx, &aetherpb.RegisterProgramRequest{Name: "other", Code: "1"})
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{errMissing, codes.NotFound},
+		{errName, codes.InvalidArgument},
+		{errFull, codes.ResourceExhausted},
+	}
+	for _, tt := range tests {
+		if got := status.Code(tt.err); got != tt.want {
+			t.Errorf("期望 %v,得到 %v", tt.want, tt.err)
+		}
+	}
+}
+
+// TestStreamTrace 测试逐条发送追踪条目,最后发送结果
+func TestStreamTrace(t *testing.T) {
+	client := dial(t, server.Config{})
+	ctx := context.Background()
+
+	stream, err := client.StreamTrace(ctx, &aetherpb.StreamTraceRequest{
+		Source: &aetherpb.StreamTraceRequest_Code{Code: "TRACE_INFO(\"a\", 1)\nTRACE_WARN(\"b\", 2)\n(1 + 2)"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var categories []string
+	var final *aetherpb.EvalResponse
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if final != nil {
+			t.Fatal("结果之后还有消息")
+		}
+		if entry := msg.GetEntry(); entry != nil {
+			categories = append(categories, entry.Category)
+		}
+		final = msg.GetResult()
+	}
+	if len(categories) != 2 || categories[0] != "a" || categories[1] != "b" {
+		t.Errorf("期望追踪类别 [a b],得到 %v", categories)
+	}
+	if final == nil || final.Result != "3" || len(final.Trace) != 0 {
+		t.Errorf("期望最后的结果为 3 且不重复追踪条目,得到 %v", final)
+	}
+
+	stream, err = client.StreamTrace(ctx, &aetherpb.StreamTraceRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("没有 code 与 program 时期望 InvalidArgument,得到 %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+// Package doctor 检查 cgo、工具链与预编译库,并给出修复方法
+//
+// 所有检查只读取文件与 go env,不依赖 cgo,也不引用根包 aether,因此在库文件缺失、
+// 程序无法链接时也能运行。cmd/doctor 直接输出这里的报告;aether doctor 在此基础上
+// 加入链接进程序的库的检查
+package doctor
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/xiaozuhui/aether-go/internal/fetch"
+	"github.com/xiaozuhui/aether-go/internal/libfile"
+)
+
+// 检查结果状态
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// Check 为一项检查的结果
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	// Fix 为检查未通过时的修复方法
+	Fix string `json:"fix,omitempty"`
+}
+
+// Library 描述预编译库文件,与 aether.LibraryInfo 为同一类型
+type Library = libfile.Library
+
+// Report 为一次诊断的完整结果
+type Report struct {
+	Library *Library `json:"library"`
+	Checks  []Check  `json:"checks"`
+}
+
+// Add 追加一项检查结果
+func (r *Report) Add(c Check) {
+	r.Checks = append(r.Checks, c)
+}
+
+// Failed 返回是否有未通过的检查
+func (r *Report) Failed() bool {
+	for _, c := range r.Checks {
+		if c.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Options 配置诊断
+type Options struct {
+	// Shared 为 true 时检查 -tags aether_dynamic 构建加载的动态库,否则检查静态库
+	Shared bool
+}
+
+// Diagnose 检查工具链、平台、库文件与 aether.lock
+func Diagnose(opts Options) *Report {
+	platform := fetch.Detect()
+	dir, source := libfile.Locate()
+	lib := &Library{
+		Dir:      dir,
+		Source:   source,
+		Backend:  "static",
+		Path:     filepath.Join(dir, platform.LibFileName()),
+		Platform: platform.String(),
+	}
+	if opts.Shared {
+		lib.Backend = "dynamic"
+		lib.Path = filepath.Join(dir, platform.SharedLibFileName())
+	}
+	r := &Report{Library: lib}
+
+	r.Add(Check{Name: "Go", Status: StatusOK, Detail: fmt.Sprintf("%s %s/%s", runtime.Version(), runtime.GOOS, runtime.GOARCH)})
+	for _, c := range toolchainChecks() {
+		r.Add(c)
+	}
+
+	if err := platform.Supported(); err != nil {
+		r.Add(Check{Name: "平台", Status: StatusWarn, Detail: err.Error(),
+			Fix: "从源码编译 Aether 库: git clone https://github.com/xiaozuhui/aether.git && cd aether && cargo build --release," +
+				"然后把 target/release/" + platform.LibFileName() + " 复制到 " + dir})
+	} else {
+		r.Add(Check{Name: "平台", Status: StatusOK, Detail: platform.String()})
+	}
+
+	lock, lockErr := fetch.ReadLock(dir)
+	if lock != nil {
+		lib.LockVersion = lock.Version
+		lib.LockSHA256 = lock.SHA256
+	}
+
+	f, err := libfile.Inspect(lib.Path)
+	if err != nil {
+		r.Add(Check{Name: "库文件", Status: StatusFail, Detail: fmt.Sprintf("%v (来源: %s)", err, source), Fix: FetchFix(lib)})
+		return r
+	}
+	lib.SetFile(f)
+	r.Add(Check{Name: "库文件", Status: StatusOK,
+		Detail: fmt.Sprintf("%s (%d 字节, 来源: %s)", lib.Path, lib.Size, source)})
+
+	if len(lib.MissingSymbols) > 0 {
+		r.Add(Check{Name: "ABI 符号", Status: StatusFail,
+			Detail: "缺少 " + strings.Join(lib.MissingSymbols, ", "),
+			Fix:    "库文件过旧或不是 Aether 库," + FetchFix(lib) + " --force"})
+	} else {
+		r.Add(Check{Name: "ABI 符号", Status: StatusOK, Detail: fmt.Sprintf("%d 个符号齐全", len(lib.Symbols))})
+	}
+
+	switch {
+	case lockErr != nil:
+		r.Add(Check{Name: "aether.lock", Status: StatusFail, Detail: lockErr.Error(),
+			Fix: FetchFix(lib) + " --force"})
+	case lock == nil:
+		r.Add(Check{Name: "aether.lock", Status: StatusWarn, Detail: "没有锁文件,无法确认库版本",
+			Fix: "使用 fetch 下载库文件会生成 aether.lock: " + FetchFix(lib)})
+	case lock.SHA256 != "" && lock.SHA256 != lib.SHA256 && lock.Asset == assetName(platform, opts.Shared):
+		r.Add(Check{Name: "aether.lock", Status: StatusFail,
+			Detail: fmt.Sprintf("库文件 SHA-256 %s 与锁文件记录的 %s 不一致", lib.SHA256, lock.SHA256),
+			Fix:    "库文件在下载后被修改或替换," + FetchFix(lib) + " --force"})
+	case lock.Unverified:
+		r.Add(Check{Name: "aether.lock", Status: StatusWarn, Detail: "版本 " + lock.Version + ",库文件下载时未经 SHA256SUMS 校验",
+			Fix: "从发布了 SHA256SUMS 的 Release 重新下载: " + FetchFix(lib) + " --force"})
+	default:
+		r.Add(Check{Name: "aether.lock", Status: StatusOK, Detail: "版本 " + lock.Version})
+	}
+
+	// fetch 默认下载与项目依赖的 aether-go 版本一致的 Release
+	if release := fetch.ProjectVersion(libfile.ModuleRoot()); release != "" && lock != nil && lock.Pinned() && !fetch.SameVersion(release, lock.Version) {
+		r.Add(Check{Name: "库版本", Status: StatusFail,
+			Detail: fmt.Sprintf("aether.lock 记录的库为 %s,aether-go 模块为 %s", lock.Version, release),
+			Fix:    FetchFix(lib) + " --version " + release + " --force"})
+	}
+	return r
+}
+
+// assetName 返回库文件对应的 Release 文件名
+func assetName(p fetch.Platform, shared bool) string {
+	if shared {
+		return p.SharedAssetName()
+	}
+	return p.AssetName()
+}
+
+// toolchainChecks 检查 go 命令、cgo 与 C 编译器
+func toolchainChecks() []Check {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		return []Check{{Name: "go 命令", Status: StatusWarn, Detail: "PATH 中没有 go 命令",
+			Fix: "安装 Go: https://go.dev/dl/"}}
+	}
+
+	out, err := exec.Command(goBin, "env", "CGO_ENABLED", "CC").Output()
+	if err != nil {
+		return []Check{{Name: "go env", Status: StatusWarn, Detail: err.Error()}}
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	for len(lines) < 2 {
+		lines = append(lines, "")
+	}
+	cgoEnabled, cc := strings.TrimSpace(lines[0]), strings.TrimSpace(lines[1])
+
+	checks := []Check{}
+	if cgoEnabled != "1" {
+		checks = append(checks, Check{Name: "cgo", Status: StatusFail, Detail: "CGO_ENABLED=" + cgoEnabled,
+			Fix: "aether-go 通过 cgo 调用 Aether 库,请设置 CGO_ENABLED=1 (go env -w CGO_ENABLED=1)"})
+	} else {
+		checks = append(checks, Check{Name: "cgo", Status: StatusOK, Detail: "CGO_ENABLED=1"})
+	}
+
+	ccName := strings.Fields(cc)
+	if len(ccName) == 0 {
+		checks = append(checks, Check{Name: "C 编译器", Status: StatusFail, Detail: "go env CC 为空",
+			Fix: "安装 C 编译器并设置 CC"})
+	} else if path, err := exec.LookPath(ccName[0]); err != nil {
+		checks = append(checks, Check{Name: "C 编译器", Status: StatusFail, Detail: "找不到 " + ccName[0],
+			Fix: ccFix()})
+	} else {
+		checks = append(checks, Check{Name: "C 编译器", Status: StatusOK, Detail: path})
+	}
+	return checks
+}
+
+// ccFix 返回安装 C 编译器的方法
+func ccFix() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "安装 Xcode 命令行工具: xcode-select --install"
+	case "windows":
+		return "安装 MinGW-w64 (例如 MSYS2 的 mingw-w64-x86_64-gcc) 并将其加入 PATH"
+	default:
+		return "安装 gcc (Debian/Ubuntu: apt install build-essential; Alpine: apk add build-base)"
+	}
+}
+
+// FetchFix 返回下载库文件的命令
+func FetchFix(lib *Library) string {
+	cmd := "go run github.com/xiaozuhui/aether-go/cmd/fetch"
+	if lib.Backend == "dynamic" {
+		cmd += " --shared"
+	}
+	if lib.Source == libfile.SourceEnv {
+		cmd += " --dir " + lib.Dir
+	}
+	return "运行 " + cmd
+}
+
+// Print 以文本形式输出报告
+func Print(w io.Writer, r *Report) {
+	marks := map[string]string{StatusOK: "✓", StatusWarn: "!", StatusFail: "✗"}
+
+	lib := r.Library
+	fmt.Fprintln(w, "库信息:")
+	fmt.Fprintf(w, "  目录:     %s (来源: %s)\n", lib.Dir, lib.Source)
+	fmt.Fprintf(w, "  平台:     %s\n", lib.Platform)
+	fmt.Fprintf(w, "  后端:     %s\n", lib.Backend)
+	if lib.Exists {
+		fmt.Fprintf(w, "  大小:     %d 字节\n", lib.Size)
+		fmt.Fprintf(w, "  SHA-256:  %s\n", lib.SHA256)
+	}
+	if lib.LinkedVersion != "" {
+		fmt.Fprintf(w, "  链接版本: %s\n", lib.LinkedVersion)
+	}
+	if lib.LockVersion != "" {
+		fmt.Fprintf(w, "  锁定版本: %s\n", lib.LockVersion)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "检查:")
+	for _, c := range r.Checks {
+		fmt.Fprintf(w, "  [%s] %s: %s\n", marks[c.Status], c.Name, c.Detail)
+		if c.Fix != "" && c.Status != StatusOK {
+			fmt.Fprintf(w, "      修复: %s\n", c.Fix)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package doctor
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xiaozuhui/aether-go/internal/fetch"
+	"github.com/xiaozuhui/aether-go/internal/libfile"
+)
+
+// findCheck 返回报告中名为 name 的检查
+func findCheck(r *Report, name string) *Check {
+	for i := range r.Checks {
+		if r.Checks[i].Name == name {
+			return &r.Checks[i]
+		}
+	}
+	return nil
+}
+
+// TestMissingLibraryBinary 测试库文件缺失时 cmd/doctor 仍能编译并报告修复方法
+func TestMissingLibraryBinary(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("PATH 中没有 go 命令")
+	}
+
+	// 不使用 cgo 编译,证明 doctor 不需要链接 Aether 库
+	bin := filepath.Join(t.TempDir(), "doctor")
+	build := exec.Command(goBin, "build", "-o", bin, "../../cmd/doctor")
+	build.Env = append(os.Environ(), "CGO_ENABLED=0")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("编译 cmd/doctor 失败: %v\n%s", err, out)
+	}
+
+	libDir := t.TempDir()
+	cmd := exec.Command(bin, "--json")
+	cmd.Env = append(os.Environ(), "AETHER_LIB_DIR="+libDir)
+	out, err := cmd.Output()
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) || exit.ExitCode() != 1 {
+		t.Fatalf("期望退出码 1,得到 %v\n%s", err, out)
+	}
+
+	var report Report
+	if err := json.Unmarshal(out, &report); err != nil {
+		t.Fatalf("无效的 JSON 输出: %v\n%s", err, out)
+	}
+	if report.Library.Exists || report.Library.Source != libfile.SourceEnv || report.Library.Dir != libDir {
+		t.Errorf("库信息不正确: %+v", report.Library)
+	}
+	c := findCheck(&report, "库文件")
+	if c == nil || c.Status != StatusFail || !strings.Contains(c.Fix, "cmd/fetch") || !strings.Contains(c.Fix, "--dir "+libDir) {
+		t.Errorf("期望库文件检查失败并给出 fetch 命令,得到 %+v", c)
+	}
+}
+
+// TestDiagnoseLock 测试符号与 aether.lock 的检查
+func TestDiagnoseLock(t *testing.T) {
+	libDir := t.TempDir()
+	t.Setenv("AETHER_LIB_DIR", libDir)
+
+	platform := fetch.Detect()
+	path := filepath.Join(libDir, platform.LibFileName())
+	if err := os.WriteFile(path, []byte("!<arch>\naether_new\x00aether_eval\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lock := &fetch.Lock{Version: "v0.4.4", Platform: platform.String(), Asset: platform.AssetName(), SHA256: strings.Repeat("0", 64)}
+	if err := fetch.WriteLock(libDir, lock); err != nil {
+		t.Fatal(err)
+	}
+
+	r := Diagnose(Options{})
+	if !r.Failed() || !r.Library.Exists || r.Library.LockVersion != "v0.4.4" {
+		t.Fatalf("报告不正确: %+v", r.Library)
+	}
+	if c := findCheck(r, "ABI 符号"); c == nil || c.Status != StatusFail || len(r.Library.MissingSymbols) != len(libfile.ABISymbols)-2 {
+		t.Errorf("期望缺少 ABI 符号,得到 %+v", c)
+	}
+	if c := findCheck(r, "aether.lock"); c == nil || c.Status != StatusFail || !strings.Contains(c.Fix, "--force") {
+		t.Errorf("期望哈希不一致,得到 %+v", c)
+	}
+
+	// 锁文件记录的是动态库时不比较静态库的哈希
+	lock.Asset = platform.SharedAssetName()
+	if err := fetch.WriteLock(libDir, lock); err != nil {
+		t.Fatal(err)
+	}
+	if c := findCheck(Diagnose(Options{}), "aether.lock"); c == nil || c.Status != StatusOK {
+		t.Errorf("期望锁文件检查通过,得到 %+v", c)
+	}
+}
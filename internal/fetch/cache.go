@@ -0,0 +1,67 @@
+package fetch
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DefaultCacheDir 返回用户级共享缓存目录
+//
+// Linux 上为 $XDG_CACHE_HOME/aether-go(默认 ~/.cache/aether-go),
+// macOS 上为 ~/Library/Caches/aether-go,Windows 上为 %LocalAppData%\aether-go。
+// 无法确定时返回空字符串
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "aether-go")
+}
+
+// cachePath 返回哈希为 sum 的库文件在缓存中的路径,cacheDir 为空时返回空字符串
+//
+// 缓存按内容寻址,不同版本、平台的同一文件只保存一份
+func cachePath(cacheDir, sum string) string {
+	if cacheDir == "" {
+		return ""
+	}
+	return filepath.Join(cacheDir, "sha256", sum)
+}
+
+// saveToCache 将 src 复制到缓存路径 dest,先写入临时文件再重命名
+func saveToCache(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", dest, os.Getpid())
+	if err := copyFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// copyFile 复制 src 到 dest,覆盖已有文件
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,65 @@
+package fetch
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Release 中的校验文件名
+const (
+	// ChecksumsFile 为 sha256sum 格式的校验清单
+	ChecksumsFile = "SHA256SUMS"
+	// SignatureFile 为校验清单的 minisign 签名
+	SignatureFile = ChecksumsFile + ".minisig"
+)
+
+// ErrChecksumMismatch 在下载的库文件与校验清单不一致时返回
+var ErrChecksumMismatch = errors.New("库文件 SHA-256 校验失败")
+
+// ErrNoChecksums 表示 Release 没有发布校验清单,无法校验库文件
+var ErrNoChecksums = errors.New("Release 没有发布 " + ChecksumsFile + ",无法校验库文件")
+
+// ParseChecksums 解析 sha256sum 格式的校验清单,返回文件名到十六进制哈希的映射
+func ParseChecksums(data []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("无效的校验清单行: %q", line)
+		}
+		sum, name := strings.ToLower(fields[0]), strings.TrimPrefix(fields[1], "*")
+		if b, err := hex.DecodeString(sum); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("无效的 SHA-256: %q", fields[0])
+		}
+		sums[name] = sum
+	}
+	return sums, scanner.Err()
+}
+
+// FileSHA256 计算文件的 SHA-256,返回十六进制表示
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
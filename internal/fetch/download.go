@@ -0,0 +1,293 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 下载器的默认参数
+const (
+	DefaultTimeout = 5 * time.Minute
+	DefaultRetries = 3
+	DefaultBackoff = time.Second
+)
+
+// Downloader 是基于 net/http 的文件下载器
+//
+// 代理通过标准环境变量 HTTPS_PROXY、HTTP_PROXY、NO_PROXY 配置。
+// 下载先写入 <目标文件>.part,完成后重命名为目标文件;中断后再次下载
+// 同一文件时使用 Range 请求从已下载的位置继续。继续下载时以 If-Range 携带
+// 上次响应的 ETag 或 Last-Modified,服务器上的文件已变化时从头下载;
+// 没有可用的验证器时不继续下载。
+// 网络错误、5xx 和 429 响应会按指数退避重试,其他 4xx 响应立即失败。
+//
+// 零值 Downloader 使用默认参数
+type Downloader struct {
+	// Client 为 HTTP 客户端,为 nil 时使用支持 file:// 地址的默认客户端
+	Client *http.Client
+	// Timeout 为单次尝试的超时时间,为 0 时使用 DefaultTimeout
+	Timeout time.Duration
+	// Retries 为失败后的最大重试次数,为 0 时使用 DefaultRetries,小于 0 时不重试
+	Retries int
+	// Backoff 为第一次重试前的等待时间,之后每次翻倍;为 0 时使用 DefaultBackoff
+	Backoff time.Duration
+	// Progress 接收下载进度,为 nil 时不输出
+	Progress io.Writer
+}
+
+// defaultClient 在 http.DefaultTransport 的基础上支持 file:// 地址,用于离线镜像
+var defaultClient = func() *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	return &http.Client{Transport: t}
+}()
+
+// statusError 表示非预期的 HTTP 状态码
+type statusError struct {
+	code int
+	url  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.code, e.url)
+}
+
+// notFound 判断错误是否表示文件不存在
+func notFound(err error) bool {
+	var se *statusError
+	return errors.As(err, &se) && se.code == http.StatusNotFound
+}
+
+// retryable 判断错误是否值得重试
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= 500 || se.code == http.StatusTooManyRequests
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// Download 下载 url 到 dest
+func (d *Downloader) Download(ctx context.Context, url, dest string) error {
+	return d.retry(ctx, func(ctx context.Context) error {
+		return d.downloadOnce(ctx, url, dest)
+	})
+}
+
+// Bytes 下载 url 并返回其内容,用于校验清单等小文件
+func (d *Downloader) Bytes(ctx context.Context, url string) ([]byte, error) {
+	var data []byte
+	err := d.retry(ctx, func(ctx context.Context) error {
+		resp, err := d.get(ctx, url, 0, "")
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		data, err = io.ReadAll(resp.Body)
+		return err
+	})
+	return data, err
+}
+
+// retry 按指数退避重试 fn,每次尝试有独立的超时
+func (d *Downloader) retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	retries := d.Retries
+	if retries == 0 {
+		retries = DefaultRetries
+	}
+	backoff := d.Backoff
+	if backoff == 0 {
+		backoff = DefaultBackoff
+	}
+	timeout := d.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := fn(attemptCtx)
+		cancel()
+
+		if err == nil || attempt >= retries || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		if d.Progress != nil {
+			fmt.Fprintf(d.Progress, "下载失败: %v, %v 后重试 (%d/%d)\n", err, backoff, attempt+1, retries)
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// get 发送 GET 请求, offset > 0 时请求从 offset 开始的内容,并以 If-Range
+// 携带 validator,文件已变化时服务器返回完整内容
+//
+// 返回的响应状态码为 200 或 206
+func (d *Downloader) get(ctx context.Context, url string, offset int64, validator string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		req.Header.Set("If-Range", validator)
+	}
+
+	client := d.Client
+	if client == nil {
+		client = defaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, &statusError{code: resp.StatusCode, url: url}
+	}
+	return resp, nil
+}
+
+// validatorOf 返回可用于 If-Range 的验证器:强 ETag,其次为 Last-Modified
+func validatorOf(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+// downloadOnce 进行一次下载尝试,从 dest.part 中已有的内容继续
+//
+// dest.part.validator 保存 dest.part 所属响应的验证器
+func (d *Downloader) downloadOnce(ctx context.Context, url, dest string) error {
+	part := dest + ".part"
+	meta := part + ".validator"
+
+	var offset int64
+	var validator string
+	if fi, err := os.Stat(part); err == nil {
+		if v, err := os.ReadFile(meta); err == nil && len(v) > 0 {
+			offset, validator = fi.Size(), string(v)
+		} else {
+			// 无法确认已下载的部分属于同一个文件,从头开始
+			os.Remove(part)
+		}
+	}
+
+	resp, err := d.get(ctx, url, offset, validator)
+	if err != nil {
+		var se *statusError
+		if offset > 0 && errors.As(err, &se) && se.code == http.StatusRequestedRangeNotSatisfiable {
+			// 已下载的部分与服务器上的文件不一致,从头开始
+			os.Remove(part)
+			os.Remove(meta)
+			return d.downloadOnce(ctx, url, dest)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if resp.StatusCode != http.StatusPartialContent {
+		// 服务器不支持 Range 或文件已变化,从头开始
+		offset = 0
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if v := validatorOf(resp); v != "" {
+			if err := os.WriteFile(meta, []byte(v), 0644); err != nil {
+				return fmt.Errorf("无法写入文件: %w", err)
+			}
+		} else {
+			os.Remove(meta)
+		}
+	}
+
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("无法写入文件: %w", err)
+	}
+
+	var w io.Writer = f
+	if d.Progress != nil {
+		total := int64(-1)
+		if resp.ContentLength >= 0 {
+			total = offset + resp.ContentLength
+		}
+		p := &progress{out: d.Progress, done: offset, total: total}
+		defer p.finish()
+		w = io.MultiWriter(f, p)
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("无法写入文件: %w", err)
+	}
+
+	if err := os.Rename(part, dest); err != nil {
+		return err
+	}
+	os.Remove(meta)
+	return nil
+}
+
+// progress 将下载进度写入 out,每变化 1% (大小未知时每 1 MiB)刷新一次
+type progress struct {
+	out      io.Writer
+	done     int64
+	total    int64
+	reported int64
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+
+	step := int64(1 << 20)
+	if p.total > 0 {
+		step = p.total / 100
+	}
+	if p.done-p.reported >= step || p.done == p.total {
+		p.reported = p.done
+		if p.total > 0 {
+			fmt.Fprintf(p.out, "\r%s / %s (%d%%)", formatBytes(p.done), formatBytes(p.total), p.done*100/p.total)
+		} else {
+			fmt.Fprintf(p.out, "\r%s", formatBytes(p.done))
+		}
+	}
+	return len(b), nil
+}
+
+// finish 结束进度行
+func (p *progress) finish() {
+	if p.reported > 0 {
+		fmt.Fprintln(p.out)
+	}
+}
+
+// formatBytes 将字节数格式化为易读的形式
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
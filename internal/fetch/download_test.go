@@ -0,0 +1,155 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDownloaderResume 测试从 .part 文件继续下载
+func TestDownloaderResume(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 1000))
+	var ranged atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			ranged.Store(true)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "lib", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "lib")
+	if err := os.WriteFile(dest+".part", content[:4000], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dest+".part.validator", []byte(`"v1"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var progress bytes.Buffer
+	d := &Downloader{Progress: &progress}
+	if err := d.Download(context.Background(), srv.URL, dest); err != nil {
+		t.Fatalf("下载失败: %v", err)
+	}
+	if !ranged.Load() {
+		t.Error("期望使用 Range 请求继续下载")
+	}
+	data, _ := os.ReadFile(dest)
+	if !bytes.Equal(data, content) {
+		t.Errorf("下载内容不正确,长度 %d", len(data))
+	}
+	for _, name := range []string{dest + ".part", dest + ".part.validator"} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("下载完成后不应留下 %s", filepath.Base(name))
+		}
+	}
+	if !strings.Contains(progress.String(), "(100%)") {
+		t.Errorf("进度输出不正确: %q", progress.String())
+	}
+
+	// .part 比服务器上的文件还大时从头下载
+	if err := os.WriteFile(dest+".part", append(content, 'x'), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dest+".part.validator", []byte(`"v1"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Download(context.Background(), srv.URL, dest); err != nil {
+		t.Fatalf("重新下载失败: %v", err)
+	}
+	if data, _ := os.ReadFile(dest); !bytes.Equal(data, content) {
+		t.Errorf("重新下载内容不正确,长度 %d", len(data))
+	}
+}
+
+// TestDownloaderResumeValidator 测试服务器上的文件变化或缺少验证器时不拼接旧的 .part
+func TestDownloaderResumeValidator(t *testing.T) {
+	content := []byte(strings.Repeat("abcdefghij", 1000))
+	stale := []byte(strings.Repeat("0123456789", 400))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "lib", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		validator string
+	}{
+		{"文件已变化", `"v1"`},
+		{"没有验证器", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "lib")
+			if err := os.WriteFile(dest+".part", stale, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.validator != "" {
+				if err := os.WriteFile(dest+".part.validator", []byte(tt.validator), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			d := &Downloader{}
+			if err := d.Download(context.Background(), srv.URL, dest); err != nil {
+				t.Fatalf("下载失败: %v", err)
+			}
+			if data, _ := os.ReadFile(dest); !bytes.Equal(data, content) {
+				t.Errorf("下载内容不正确,长度 %d", len(data))
+			}
+		})
+	}
+}
+
+// TestDownloaderRetry 测试 5xx 重试与 4xx 立即失败
+func TestDownloaderRetry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		switch {
+		case r.URL.Path == "/missing":
+			http.NotFound(w, r)
+		case n < 3:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer srv.Close()
+
+	d := &Downloader{Backoff: time.Millisecond}
+	data, err := d.Bytes(context.Background(), srv.URL+"/flaky")
+	if err != nil {
+		t.Fatalf("重试后应成功: %v", err)
+	}
+	if string(data) != "ok" || calls.Load() != 3 {
+		t.Errorf("得到 %q,请求 %d 次", data, calls.Load())
+	}
+
+	calls.Store(10)
+	if _, err := d.Bytes(context.Background(), srv.URL+"/missing"); err == nil {
+		t.Fatal("期望 404 错误")
+	}
+	if calls.Load() != 11 {
+		t.Errorf("404 不应重试,请求 %d 次", calls.Load()-10)
+	}
+
+	// 超过重试次数
+	calls.Store(-100)
+	d = &Downloader{Retries: 2, Backoff: time.Millisecond}
+	if _, err := d.Bytes(context.Background(), srv.URL+"/flaky"); err == nil {
+		t.Fatal("期望重试耗尽后失败")
+	}
+	if calls.Load() != -97 {
+		t.Errorf("期望请求 3 次,实际 %d 次", calls.Load()+100)
+	}
+}
@@ -0,0 +1,318 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultBaseURL 为预编译库的默认下载地址(最新 Release)
+const DefaultBaseURL = "https://github.com/xiaozuhui/aether-go/releases/latest/download"
+
+// VersionBaseURL 返回指定版本 Release 的下载地址,版本为空或 latest 时返回 DefaultBaseURL
+func VersionBaseURL(version string) string {
+	if version == "" || version == "latest" {
+		return DefaultBaseURL
+	}
+	return "https://github.com/xiaozuhui/aether-go/releases/download/" + version
+}
+
+// Options 配置一次预编译库下载
+type Options struct {
+	// Platform 为目标平台
+	Platform Platform
+	// Version 为要下载的 Release 版本(如 v0.4.4),为空时下载最新版本
+	Version string
+	// BaseURL 为 Release 下载地址,为空时使用 VersionBaseURL(Version)
+	//
+	// 可以是 http(s):// 或 file:// 地址(用于离线镜像),其中的 {version} 会被替换为版本号
+	BaseURL string
+	// LibDir 为库文件保存目录
+	LibDir string
+	// Shared 为 true 时下载动态库(libaether.so / libaether.dylib),
+	// 用于 -tags aether_dynamic 构建;Windows 不支持
+	Shared bool
+	// PublicKey 为校验清单签名的 minisign 公钥
+	//
+	// 不为 nil 时必须下载并验证 SHA256SUMS.minisig
+	PublicKey *PublicKey
+	// InsecureSkipVerify 为 true 时允许从没有发布 SHA256SUMS 的 Release 下载未经校验的库文件,
+	// 锁文件会标记为未校验;配置了 PublicKey 时不生效
+	InsecureSkipVerify bool
+	// CacheDir 为按 SHA-256 保存库文件的共享缓存目录,为空时不使用缓存
+	CacheDir string
+	// Force 为 true 时即使库文件已存在也重新获取
+	Force bool
+	// DryRun 为 true 时只报告将要进行的操作,不写入任何文件
+	//
+	// 仍会下载并验证校验清单,以确定是否命中缓存
+	DryRun bool
+	// Downloader 用于下载文件,为 nil 时使用默认参数并将进度写入 Stderr
+	Downloader *Downloader
+	// Stdout 接收状态信息, Stderr 接收下载进度,为 nil 时丢弃
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// AssetURL 返回平台预编译库的下载地址
+func AssetURL(baseURL string, p Platform) string {
+	return releaseURL(baseURL, p.AssetName())
+}
+
+// baseURL 返回本次下载使用的 Release 地址
+func (o Options) baseURL() string {
+	if o.BaseURL != "" {
+		return strings.ReplaceAll(o.BaseURL, "{version}", o.version())
+	}
+	return VersionBaseURL(o.Version)
+}
+
+// assetName 返回 Release 中要下载的文件名
+func (o Options) assetName() string {
+	if o.Shared {
+		return o.Platform.SharedAssetName()
+	}
+	return o.Platform.AssetName()
+}
+
+// libFileName 返回本地保存的文件名
+func (o Options) libFileName() string {
+	if o.Shared {
+		return o.Platform.SharedLibFileName()
+	}
+	return o.Platform.LibFileName()
+}
+
+// version 返回写入锁文件的版本
+func (o Options) version() string {
+	if o.Version == "" {
+		return "latest"
+	}
+	return o.Version
+}
+
+// releaseURL 返回 Release 中文件的下载地址
+func releaseURL(baseURL, name string) string {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/" + name
+}
+
+// 下载结果状态
+const (
+	StatusDownloaded = "downloaded" // 从 Release 下载
+	StatusCached     = "cached"     // 从共享缓存复制
+	StatusExists     = "exists"     // 库文件已存在,未做任何操作
+)
+
+// Result 描述一次下载的结果
+type Result struct {
+	Platform string `json:"platform"`
+	Version  string `json:"version"`
+	URL      string `json:"url"`
+	Path     string `json:"path"`
+	SHA256   string `json:"sha256,omitempty"`
+	// Status 为 StatusDownloaded、StatusCached 或 StatusExists;
+	// DryRun 时表示实际运行将会进行的操作
+	Status string `json:"status"`
+	DryRun bool   `json:"dry_run,omitempty"`
+	// Unverified 为 true 时库文件没有经过 SHA256SUMS 校验
+	Unverified bool `json:"unverified,omitempty"`
+}
+
+// Library 下载预编译库到 opts.LibDir
+//
+// 下载前先获取 Release 中的 SHA256SUMS 校验清单(配置了 PublicKey 时同时验证签名),
+// 库文件先下载到临时文件,校验通过后才原子地重命名为最终文件名;
+// 校验失败时返回 ErrChecksumMismatch,不会留下任何文件。Release 没有发布 SHA256SUMS 时
+// 返回 ErrNoChecksums;设置 InsecureSkipVerify 后输出警告并下载,锁文件标记为未校验。
+// 成功后在 opts.LibDir 写入记录版本与哈希的 aether.lock。
+// 库文件已存在且未设置 Force 时不会重新下载,但会按锁文件校验其 SHA-256,不一致时返回
+// ErrChecksumMismatch;锁文件记录的版本与 opts.Version 不同时也返回错误。
+//
+// 设置了 CacheDir 时,库文件按 SHA-256 保存在共享缓存中,命中缓存时不再下载。
+func Library(opts Options) (*Result, error) {
+	return LibraryContext(context.Background(), opts)
+}
+
+// LibraryContext 与 Library 相同,下载可以通过 ctx 取消
+func LibraryContext(ctx context.Context, opts Options) (*Result, error) {
+	stdout := opts.Stdout
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	d := opts.Downloader
+	if d == nil {
+		d = &Downloader{Progress: opts.Stderr}
+	}
+
+	if err := opts.Platform.Supported(); err != nil {
+		return nil, err
+	}
+	if opts.Shared && opts.Platform.OS == "windows" {
+		return nil, fmt.Errorf("%s 不提供动态库", opts.Platform)
+	}
+	if opts.Version != "" {
+		if err := ValidateVersion(opts.Version); err != nil {
+			return nil, err
+		}
+	}
+
+	// 本地保存路径统一为链接器查找的文件名
+	outputFile := filepath.Join(opts.LibDir, opts.libFileName())
+	res := &Result{
+		Platform: opts.Platform.String(),
+		Version:  opts.version(),
+		URL:      releaseURL(opts.baseURL(), opts.assetName()),
+		Path:     outputFile,
+		DryRun:   opts.DryRun,
+	}
+
+	if _, err := os.Stat(outputFile); err == nil && !opts.Force {
+		lock, err := ReadLock(opts.LibDir)
+		if err != nil {
+			return nil, err
+		}
+		if lock != nil && opts.version() != "latest" && lock.Version != opts.Version {
+			return nil, fmt.Errorf("%s 中的库版本为 %s,与请求的 %s 不一致,请使用 --force 重新下载",
+				LockFile, lock.Version, opts.Version)
+		}
+		if err := VerifyLock(opts.LibDir, opts.assetName(), outputFile); err != nil {
+			return nil, fmt.Errorf("%w\n库文件在下载后被修改或替换,请使用 --force 重新下载", err)
+		}
+		if lock != nil {
+			res.SHA256 = lock.SHA256
+			res.Unverified = lock.Unverified
+		}
+		fmt.Fprintf(stdout, "库文件已存在: %s\n", outputFile)
+		if res.Unverified {
+			fmt.Fprintf(stdout, "警告: %s 记录该库文件未经 %s 校验\n", LockFile, ChecksumsFile)
+		}
+		res.Status = StatusExists
+		return res, nil
+	}
+
+	sums, err := fetchChecksums(ctx, d, opts, stdout)
+	if err != nil {
+		return nil, err
+	}
+	// want 为空表示 Release 没有校验清单且允许跳过校验
+	var want string
+	if sums != nil {
+		var ok bool
+		if want, ok = sums[opts.assetName()]; !ok {
+			return nil, fmt.Errorf("%s 中没有 %s 的校验和", ChecksumsFile, opts.assetName())
+		}
+		res.SHA256 = want
+	}
+
+	cached := cachePath(opts.CacheDir, want)
+	res.Status = StatusDownloaded
+	if want != "" && cached != "" {
+		if got, err := FileSHA256(cached); err == nil && got == want {
+			res.Status = StatusCached
+		}
+	}
+
+	if opts.DryRun {
+		if res.Status == StatusCached {
+			fmt.Fprintf(stdout, "将从缓存复制 %s 到 %s\n", cached, outputFile)
+		} else {
+			fmt.Fprintf(stdout, "将下载 %s 到 %s\n", res.URL, outputFile)
+		}
+		return res, nil
+	}
+
+	if err := os.MkdirAll(opts.LibDir, 0755); err != nil {
+		return nil, fmt.Errorf("无法创建 lib 目录: %w", err)
+	}
+
+	// 下载到同一目录下的临时文件,中断后再次运行会从 .part 继续
+	tmp := filepath.Join(opts.LibDir, "."+opts.assetName()+"-"+opts.version()+".download")
+	defer os.Remove(tmp)
+
+	if res.Status == StatusCached {
+		fmt.Fprintf(stdout, "使用缓存: %s\n", cached)
+		if err := copyFile(cached, tmp); err != nil {
+			return nil, fmt.Errorf("无法读取缓存: %w", err)
+		}
+	} else {
+		fmt.Fprintf(stdout, "正在下载 %s\n", res.URL)
+		if err := d.Download(ctx, res.URL, tmp); err != nil {
+			return nil, fmt.Errorf("下载失败: %w\n请检查网络连接或手动下载: %s", err, res.URL)
+		}
+	}
+
+	got, err := FileSHA256(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("计算 SHA-256 失败: %w", err)
+	}
+	if want == "" {
+		res.SHA256 = got
+		res.Unverified = true
+		// 共享缓存只保存校验过的库文件
+		cached = ""
+		fmt.Fprintf(stdout, "警告: SHA-256 %s 未经校验,%s 将标记为未校验\n", got, LockFile)
+	} else if got != want {
+		return nil, fmt.Errorf("%w: %s 期望 %s,实际 %s", ErrChecksumMismatch, opts.assetName(), want, got)
+	} else {
+		fmt.Fprintf(stdout, "SHA-256 校验通过: %s\n", got)
+	}
+
+	// 缓存只是加速手段,写入失败不影响本次下载
+	if cached != "" && res.Status == StatusDownloaded {
+		if err := saveToCache(tmp, cached); err != nil {
+			fmt.Fprintf(stdout, "警告: 无法写入缓存: %v\n", err)
+		}
+	}
+
+	if err := os.Rename(tmp, outputFile); err != nil {
+		return nil, fmt.Errorf("无法保存库文件: %w", err)
+	}
+	lock := &Lock{
+		Version:    opts.version(),
+		Platform:   opts.Platform.String(),
+		Asset:      opts.assetName(),
+		SHA256:     got,
+		Unverified: want == "",
+	}
+	if err := WriteLock(opts.LibDir, lock); err != nil {
+		return nil, fmt.Errorf("无法写入 %s: %w", LockFile, err)
+	}
+	return res, nil
+}
+
+// fetchChecksums 下载并解析校验清单,配置了公钥时先验证签名
+//
+// Release 没有发布校验清单时返回 ErrNoChecksums;设置了 InsecureSkipVerify
+// 且未配置公钥时输出警告并返回 nil, nil
+func fetchChecksums(ctx context.Context, d *Downloader, opts Options, stdout io.Writer) (map[string]string, error) {
+	data, err := d.Bytes(ctx, releaseURL(opts.baseURL(), ChecksumsFile))
+	if notFound(err) {
+		if opts.InsecureSkipVerify && opts.PublicKey == nil {
+			fmt.Fprintf(stdout, "警告: Release 没有发布 %s,跳过校验(--insecure-skip-verify)\n", ChecksumsFile)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w\n确认下载来源可信时可以使用 --insecure-skip-verify 跳过校验", ErrNoChecksums)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("下载 %s 失败: %w", ChecksumsFile, err)
+	}
+
+	if opts.PublicKey != nil {
+		sig, err := d.Bytes(ctx, releaseURL(opts.baseURL(), SignatureFile))
+		if err != nil {
+			return nil, fmt.Errorf("下载 %s 失败: %w", SignatureFile, err)
+		}
+		if err := VerifySignature(*opts.PublicKey, data, sig); err != nil {
+			return nil, fmt.Errorf("%s 签名无效: %w", ChecksumsFile, err)
+		}
+		fmt.Fprintf(stdout, "%s 签名验证通过\n", ChecksumsFile)
+	}
+
+	return ParseChecksums(data)
+}
@@ -0,0 +1,469 @@
+package fetch
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// TestParsePlatform 测试平台名称解析与文件名
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Platform
+		asset   string
+		libFile string
+	}{
+		{"darwin-arm64", Platform{OS: "darwin", Arch: "arm64"}, "libaether-darwin-arm64.a", "libaether.a"},
+		{"linux-amd64", Platform{OS: "linux", Arch: "amd64", Libc: "gnu"}, "libaether-linux-amd64.a", "libaether.a"},
+		{"linux-amd64-gnu", Platform{OS: "linux", Arch: "amd64", Libc: "gnu"}, "libaether-linux-amd64.a", "libaether.a"},
+		{"linux-arm64-musl", Platform{OS: "linux", Arch: "arm64", Libc: "musl"}, "libaether-linux-arm64-musl.a", "libaether.a"},
+		{"windows-amd64", Platform{OS: "windows", Arch: "amd64"}, "aether-windows-amd64.lib", "aether.lib"},
+	}
+
+	for _, tt := range tests {
+		p, err := ParsePlatform(tt.name)
+		if err != nil {
+			t.Errorf("ParsePlatform(%q) 失败: %v", tt.name, err)
+			continue
+		}
+		if p != tt.want {
+			t.Errorf("ParsePlatform(%q) = %+v,期望 %+v", tt.name, p, tt.want)
+		}
+		if err := p.Supported(); err != nil {
+			t.Errorf("%s 应受支持: %v", p, err)
+		}
+		if p.AssetName() != tt.asset || p.LibFileName() != tt.libFile {
+			t.Errorf("%s: 文件名 %s/%s,期望 %s/%s", p, p.AssetName(), p.LibFileName(), tt.asset, tt.libFile)
+		}
+	}
+
+	for _, name := range []string{"linux", "darwin-arm64-musl", "a-b-c-d"} {
+		if _, err := ParsePlatform(name); err == nil {
+			t.Errorf("ParsePlatform(%q) 期望错误", name)
+		}
+	}
+
+	if err := (Platform{OS: "freebsd", Arch: "amd64"}).Supported(); err == nil {
+		t.Error("freebsd-amd64 不应受支持")
+	}
+}
+
+// releaseFiles 返回所有平台的预编译库及其 SHA256SUMS
+func releaseFiles() map[string][]byte {
+	files := make(map[string][]byte)
+	var sums bytes.Buffer
+	for _, p := range SupportedPlatforms {
+		body := []byte("archive for " + p.String())
+		files[p.AssetName()] = body
+		fmt.Fprintf(&sums, "%x  %s\n", sha256.Sum256(body), p.AssetName())
+	}
+	files[ChecksumsFile] = sums.Bytes()
+	return files
+}
+
+// newReleaseServer 启动一个提供 files 的本地 Release 服务器
+func newReleaseServer(t *testing.T, files map[string][]byte) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[strings.TrimPrefix(r.URL.Path, "/releases/latest/download/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/releases/latest/download"
+}
+
+// TestLibrary 测试从本地 Release 服务器下载各平台的库
+func TestLibrary(t *testing.T) {
+	baseURL := newReleaseServer(t, releaseFiles())
+
+	for _, p := range SupportedPlatforms {
+		libDir := filepath.Join(t.TempDir(), "lib")
+		res, err := Library(Options{Platform: p, BaseURL: baseURL, LibDir: libDir})
+		if err != nil {
+			t.Errorf("%s: 下载失败: %v", p, err)
+			continue
+		}
+		if filepath.Base(res.Path) != p.LibFileName() || res.Status != StatusDownloaded {
+			t.Errorf("%s: 期望下载并保存为 %s,得到 %+v", p, p.LibFileName(), res)
+		}
+		data, _ := os.ReadFile(res.Path)
+		if string(data) != "archive for "+p.String() {
+			t.Errorf("%s: 下载内容不正确: %q", p, data)
+		}
+
+		// 已存在时不重新下载
+		var out bytes.Buffer
+		if _, err := Library(Options{Platform: p, BaseURL: baseURL, LibDir: libDir, Stdout: &out}); err != nil {
+			t.Errorf("%s: 第二次下载失败: %v", p, err)
+		}
+		if !bytes.Contains(out.Bytes(), []byte("库文件已存在")) {
+			t.Errorf("%s: 期望跳过下载,输出: %s", p, out.String())
+		}
+	}
+
+	// 缺失的资源不应留下文件
+	libDir := t.TempDir()
+	_, err := Library(Options{Platform: SupportedPlatforms[0], BaseURL: baseURL + "/missing", LibDir: libDir})
+	if err == nil {
+		t.Fatal("期望下载失败")
+	}
+	if entries, _ := os.ReadDir(libDir); len(entries) != 0 {
+		t.Errorf("下载失败后不应留下文件: %v", entries)
+	}
+}
+
+// TestLibraryChecksumMismatch 测试校验和不匹配时拒绝安装
+func TestLibraryChecksumMismatch(t *testing.T) {
+	files := releaseFiles()
+	p := SupportedPlatforms[0]
+	files[p.AssetName()] = []byte("tampered")
+	baseURL := newReleaseServer(t, files)
+
+	libDir := t.TempDir()
+	_, err := Library(Options{Platform: p, BaseURL: baseURL, LibDir: libDir})
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("期望 ErrChecksumMismatch,得到 %v", err)
+	}
+	if entries, _ := os.ReadDir(libDir); len(entries) != 0 {
+		t.Errorf("校验失败后不应留下文件: %v", entries)
+	}
+
+	// 清单中没有该平台
+	files = releaseFiles()
+	files[ChecksumsFile] = nil
+	baseURL = newReleaseServer(t, files)
+	if _, err := Library(Options{Platform: p, BaseURL: baseURL, LibDir: t.TempDir()}); err == nil {
+		t.Error("校验清单缺少条目时期望错误")
+	}
+
+	// 下载后被替换的库文件不能通过锁文件校验
+	baseURL = newReleaseServer(t, releaseFiles())
+	res, err := Library(Options{Platform: p, BaseURL: baseURL, LibDir: libDir})
+	if err != nil {
+		t.Fatalf("下载失败: %v", err)
+	}
+	if err := VerifyLock(libDir, p.AssetName(), res.Path); err != nil {
+		t.Errorf("未修改的库文件应通过校验: %v", err)
+	}
+	if err := os.WriteFile(res.Path, []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyLock(libDir, p.AssetName(), res.Path); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("期望 ErrChecksumMismatch,得到 %v", err)
+	}
+	if _, err := Library(Options{Platform: p, BaseURL: baseURL, LibDir: libDir}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("已存在的库文件被替换时期望 ErrChecksumMismatch,得到 %v", err)
+	}
+	if err := VerifyLock(libDir, p.SharedAssetName(), res.Path); err != nil {
+		t.Errorf("锁文件记录其他文件时不应校验: %v", err)
+	}
+}
+
+// TestLibraryWithoutChecksums 测试 Release 没有校验清单时拒绝下载,只有显式跳过校验时才下载
+func TestLibraryWithoutChecksums(t *testing.T) {
+	files := releaseFiles()
+	delete(files, ChecksumsFile)
+	baseURL := newReleaseServer(t, files)
+	p := SupportedPlatforms[0]
+
+	libDir := t.TempDir()
+	if _, err := Library(Options{Platform: p, BaseURL: baseURL, LibDir: libDir}); !errors.Is(err, ErrNoChecksums) {
+		t.Fatalf("期望 ErrNoChecksums,得到 %v", err)
+	}
+	if entries, _ := os.ReadDir(libDir); len(entries) != 0 {
+		t.Errorf("拒绝下载后不应留下文件: %v", entries)
+	}
+
+	var out bytes.Buffer
+	cacheDir := t.TempDir()
+	res, err := Library(Options{Platform: p, BaseURL: baseURL, LibDir: libDir, CacheDir: cacheDir, InsecureSkipVerify: true, Stdout: &out})
+	if err != nil {
+		t.Fatalf("InsecureSkipVerify 时应下载: %v", err)
+	}
+	if !strings.Contains(out.String(), "警告") {
+		t.Errorf("期望输出警告: %s", out.String())
+	}
+	want := fmt.Sprintf("%x", sha256.Sum256(files[p.AssetName()]))
+	lock, _ := ReadLock(libDir)
+	if !res.Unverified || lock == nil || !lock.Unverified || lock.SHA256 != want {
+		t.Errorf("期望锁文件标记为未校验,得到 %+v, %+v", res, lock)
+	}
+	if _, err := os.Stat(cachePath(cacheDir, want)); !os.IsNotExist(err) {
+		t.Errorf("未校验的库文件不应写入共享缓存: %v", err)
+	}
+
+	pk, _ := testKey(t)
+	if _, err := Library(Options{Platform: p, BaseURL: baseURL, LibDir: t.TempDir(), PublicKey: &pk}); err == nil {
+		t.Error("配置了公钥时缺少校验清单应拒绝下载")
+	}
+}
+
+// testKey 生成测试用的 minisign 密钥
+func testKey(t *testing.T) (PublicKey, ed25519.PrivateKey) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := PublicKey{KeyID: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}, Key: pub}
+	return pk, priv
+}
+
+// encodePublicKey 将公钥编码为 .pub 文件格式
+func encodePublicKey(pk PublicKey) string {
+	raw := append(append(algEd[:], pk.KeyID[:]...), pk.Key...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(raw) + "\n"
+}
+
+// sign 生成 minisign 签名文件, prehash 为 true 时使用 BLAKE2b 预哈希
+func sign(pk PublicKey, priv ed25519.PrivateKey, message []byte, prehash bool) []byte {
+	alg, signed := algEd, message
+	if prehash {
+		sum := blake2b.Sum512(message)
+		alg, signed = algPrehashE, sum[:]
+	}
+	sig := ed25519.Sign(priv, signed)
+	trusted := "timestamp:1700000000\tfile:SHA256SUMS"
+	global := ed25519.Sign(priv, append(append([]byte{}, sig...), trusted...))
+
+	raw := append(append(alg[:], pk.KeyID[:]...), sig...)
+	return []byte("untrusted comment: signature\n" +
+		base64.StdEncoding.EncodeToString(raw) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+}
+
+// TestVerifySignature 测试 minisign 签名验证
+func TestVerifySignature(t *testing.T) {
+	pk, priv := testKey(t)
+	parsed, err := ParsePublicKey(encodePublicKey(pk))
+	if err != nil {
+		t.Fatalf("ParsePublicKey 失败: %v", err)
+	}
+	message := []byte("hello")
+
+	for _, prehash := range []bool{false, true} {
+		sig := sign(pk, priv, message, prehash)
+		if err := VerifySignature(parsed, message, sig); err != nil {
+			t.Errorf("prehash=%v: 验证失败: %v", prehash, err)
+		}
+		if err := VerifySignature(parsed, []byte("hellO"), sig); err == nil {
+			t.Errorf("prehash=%v: 篡改的内容应验证失败", prehash)
+		}
+	}
+
+	other, _ := testKey(t)
+	other.KeyID = pk.KeyID
+	if err := VerifySignature(other, message, sign(pk, priv, message, false)); err == nil {
+		t.Error("错误的公钥应验证失败")
+	}
+
+	sig := sign(pk, priv, message, false)
+	tampered := bytes.Replace(sig, []byte("file:SHA256SUMS"), []byte("file:OTHER"), 1)
+	if err := VerifySignature(parsed, message, tampered); err == nil {
+		t.Error("篡改的可信注释应验证失败")
+	}
+}
+
+// TestLibrarySignature 测试配置公钥时验证校验清单签名
+func TestLibrarySignature(t *testing.T) {
+	pk, priv := testKey(t)
+	files := releaseFiles()
+	files[SignatureFile] = sign(pk, priv, files[ChecksumsFile], true)
+	baseURL := newReleaseServer(t, files)
+
+	p := SupportedPlatforms[0]
+	if _, err := Library(Options{Platform: p, BaseURL: baseURL, LibDir: t.TempDir(), PublicKey: &pk}); err != nil {
+		t.Fatalf("有效签名下载失败: %v", err)
+	}
+
+	other, _ := testKey(t)
+	libDir := t.TempDir()
+	if _, err := Library(Options{Platform: p, BaseURL: baseURL, LibDir: libDir, PublicKey: &other}); err == nil {
+		t.Fatal("错误的公钥应拒绝下载")
+	}
+	if entries, _ := os.ReadDir(libDir); len(entries) != 0 {
+		t.Errorf("签名无效时不应留下文件: %v", entries)
+	}
+
+	// 配置了公钥但缺少签名文件
+	baseURL = newReleaseServer(t, releaseFiles())
+	if _, err := Library(Options{Platform: p, BaseURL: baseURL, LibDir: t.TempDir(), PublicKey: &pk}); err == nil {
+		t.Error("缺少签名文件时应拒绝下载")
+	}
+}
+
+// TestLibraryVersion 测试固定版本下载与 aether.lock
+func TestLibraryVersion(t *testing.T) {
+	baseURL := newReleaseServer(t, releaseFiles())
+	p := SupportedPlatforms[0]
+	libDir := t.TempDir()
+
+	if _, err := Library(Options{Platform: p, Version: "v1.2", BaseURL: baseURL, LibDir: libDir}); err == nil {
+		t.Fatal("无效版本号应报错")
+	}
+
+	if _, err := Library(Options{Platform: p, Version: "v1.2.0", BaseURL: baseURL, LibDir: libDir}); err != nil {
+		t.Fatalf("下载失败: %v", err)
+	}
+	lock, err := ReadLock(libDir)
+	if err != nil || lock == nil {
+		t.Fatalf("读取 aether.lock 失败: %v", err)
+	}
+	want := fmt.Sprintf("%x", sha256.Sum256(releaseFiles()[p.AssetName()]))
+	if lock.Version != "v1.2.0" || lock.Platform != p.String() || lock.Asset != p.AssetName() || lock.SHA256 != want {
+		t.Errorf("aether.lock 内容不正确: %+v", lock)
+	}
+
+	// 相同版本跳过下载,不同版本报错
+	if _, err := Library(Options{Platform: p, Version: "v1.2.0", BaseURL: baseURL, LibDir: libDir}); err != nil {
+		t.Errorf("相同版本应跳过下载: %v", err)
+	}
+	if _, err := Library(Options{Platform: p, Version: "v1.3.0", BaseURL: baseURL, LibDir: libDir}); err == nil {
+		t.Error("版本与 aether.lock 不一致时应报错")
+	}
+
+	if got := VersionBaseURL("v1.2.0"); got != "https://github.com/xiaozuhui/aether-go/releases/download/v1.2.0" {
+		t.Errorf("VersionBaseURL = %s", got)
+	}
+	if VersionBaseURL("") != DefaultBaseURL || VersionBaseURL("latest") != DefaultBaseURL {
+		t.Error("未指定版本时应使用 DefaultBaseURL")
+	}
+	if !SameVersion("v0.4.4", "0.4.4") || SameVersion("v0.4.4", "0.4.5") {
+		t.Error("SameVersion 结果不正确")
+	}
+}
+
+// TestRequiredVersion 测试从项目的 go.mod 读取依赖的 aether-go 版本
+func TestRequiredVersion(t *testing.T) {
+	tests := []struct {
+		name  string
+		gomod string
+		want  string
+	}{
+		{"单行", "module example.com/app\n\nrequire github.com/xiaozuhui/aether-go v0.4.4\n", "v0.4.4"},
+		{"require 块", "module example.com/app\n\nrequire (\n\tgithub.com/foo/bar v1.0.0\n\tgithub.com/xiaozuhui/aether-go v0.4.3 // indirect\n)\n", "v0.4.3"},
+		{"伪版本", "require github.com/xiaozuhui/aether-go v0.0.0-20240101000000-0123456789ab\n", ""},
+		{"replace", "require github.com/xiaozuhui/aether-go v0.4.4\nreplace github.com/xiaozuhui/aether-go => ../aether-go\n", ""},
+		{"没有依赖", "module example.com/app\n", ""},
+	}
+	for _, tt := range tests {
+		if got := requiredVersion([]byte(tt.gomod)); got != tt.want {
+			t.Errorf("%s: requiredVersion = %q,期望 %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestLibraryCache 测试共享缓存、--force 与 --dry-run
+func TestLibraryCache(t *testing.T) {
+	files := releaseFiles()
+	var assetRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Base(r.URL.Path)
+		if name != ChecksumsFile {
+			assetRequests.Add(1)
+		}
+		w.Write(files[name])
+	}))
+	defer srv.Close()
+
+	p := SupportedPlatforms[0]
+	cacheDir := t.TempDir()
+	opts := Options{Platform: p, BaseURL: srv.URL, CacheDir: cacheDir}
+
+	// dry-run 不写入任何文件
+	opts.LibDir = filepath.Join(t.TempDir(), "lib")
+	opts.DryRun = true
+	res, err := Library(opts)
+	if err != nil {
+		t.Fatalf("dry-run 失败: %v", err)
+	}
+	if !res.DryRun || res.Status != StatusDownloaded || assetRequests.Load() != 0 {
+		t.Errorf("dry-run 结果不正确: %+v,下载 %d 次", res, assetRequests.Load())
+	}
+	if _, err := os.Stat(opts.LibDir); !os.IsNotExist(err) {
+		t.Error("dry-run 不应创建 lib 目录")
+	}
+	opts.DryRun = false
+
+	if res, err := Library(opts); err != nil || res.Status != StatusDownloaded {
+		t.Fatalf("第一次下载: %+v, %v", res, err)
+	}
+
+	// 另一个项目命中缓存
+	opts.LibDir = t.TempDir()
+	res, err = Library(opts)
+	if err != nil || res.Status != StatusCached {
+		t.Fatalf("期望命中缓存: %+v, %v", res, err)
+	}
+	if assetRequests.Load() != 1 {
+		t.Errorf("命中缓存时不应下载,共下载 %d 次", assetRequests.Load())
+	}
+	if data, _ := os.ReadFile(res.Path); !bytes.Equal(data, files[p.AssetName()]) {
+		t.Errorf("缓存内容不正确: %q", data)
+	}
+
+	if res, err := Library(opts); err != nil || res.Status != StatusExists {
+		t.Errorf("期望跳过: %+v, %v", res, err)
+	}
+
+	// 损坏的缓存条目会被忽略,--force 重新获取
+	cached := filepath.Join(cacheDir, "sha256", res.SHA256)
+	if err := os.WriteFile(cached, []byte("corrupt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts.Force = true
+	res, err = Library(opts)
+	if err != nil || res.Status != StatusDownloaded {
+		t.Fatalf("--force 应重新下载: %+v, %v", res, err)
+	}
+	if data, _ := os.ReadFile(cached); !bytes.Equal(data, files[p.AssetName()]) {
+		t.Error("重新下载后应修复缓存")
+	}
+}
+
+// TestLibraryFileMirror 测试 file:// 离线镜像
+func TestLibraryFileMirror(t *testing.T) {
+	mirror := t.TempDir()
+	releaseDir := filepath.Join(mirror, "v1.2.0")
+	if err := os.MkdirAll(releaseDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range releaseFiles() {
+		if err := os.WriteFile(filepath.Join(releaseDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	baseURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(mirror)}).String() + "/{version}"
+	p := SupportedPlatforms[0]
+	res, err := Library(Options{Platform: p, Version: "v1.2.0", BaseURL: baseURL, LibDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("从 file:// 镜像下载失败: %v", err)
+	}
+	if data, _ := os.ReadFile(res.Path); string(data) != "archive for "+p.String() {
+		t.Errorf("下载内容不正确: %q", data)
+	}
+
+	if _, err := Library(Options{Platform: p, Version: "v9.9.9", BaseURL: baseURL, LibDir: t.TempDir()}); err == nil {
+		t.Error("镜像中不存在的版本应报错")
+	}
+}
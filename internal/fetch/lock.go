@@ -0,0 +1,186 @@
+package fetch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strings"
+)
+
+// ModulePath 为 aether-go 的模块路径
+const ModulePath = "github.com/xiaozuhui/aether-go"
+
+// LockFile 为 lib 目录下记录库版本与哈希的锁文件名
+const LockFile = "aether.lock"
+
+// Lock 记录已下载的预编译库
+type Lock struct {
+	// Version 为 Release 版本,未固定版本时为 "latest"
+	Version  string `json:"version"`
+	Platform string `json:"platform"`
+	Asset    string `json:"asset"`
+	// SHA256 为库文件的哈希;Unverified 为 true 时它只是下载后计算的值,
+	// 没有与 SHA256SUMS 比对,只能发现下载后的修改
+	SHA256     string `json:"sha256"`
+	Unverified bool   `json:"unverified,omitempty"`
+}
+
+// Pinned 返回锁文件是否记录了具体版本
+func (l *Lock) Pinned() bool {
+	return l.Version != "" && l.Version != "latest"
+}
+
+// ReadLock 读取 libDir 中的锁文件,不存在时返回 nil, nil
+func ReadLock(libDir string) (*Lock, error) {
+	data, err := os.ReadFile(filepath.Join(libDir, LockFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var l Lock
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("无效的 %s: %w", LockFile, err)
+	}
+	return &l, nil
+}
+
+// VerifyLock 按 libDir 中的锁文件校验库文件 path 的 SHA-256
+//
+// asset 为 path 对应的 Release 文件名。没有锁文件,或锁文件记录的是其他文件时
+// 返回 nil;哈希不一致时返回包装了 ErrChecksumMismatch 的错误
+func VerifyLock(libDir, asset, path string) error {
+	lock, err := ReadLock(libDir)
+	if err != nil {
+		return err
+	}
+	if lock == nil || lock.SHA256 == "" || lock.Asset != asset {
+		return nil
+	}
+	got, err := FileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if got != lock.SHA256 {
+		return fmt.Errorf("%w: %s 的 SHA-256 为 %s,%s 记录为 %s",
+			ErrChecksumMismatch, path, got, LockFile, lock.SHA256)
+	}
+	return nil
+}
+
+// WriteLock 将锁文件写入 libDir
+func WriteLock(libDir string, l *Lock) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(libDir, LockFile), append(data, '\n'), 0644)
+}
+
+var (
+	releaseVersion = regexp.MustCompile(`^v\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?$`)
+	pseudoVersion  = regexp.MustCompile(`\d{14}-[0-9a-f]{12}$`)
+)
+
+// ValidateVersion 检查 Release 版本号格式(如 v0.4.4),"latest" 也是有效的
+func ValidateVersion(v string) error {
+	if v == "latest" || releaseVersion.MatchString(v) {
+		return nil
+	}
+	return fmt.Errorf("无效的版本号 %q,应为 vX.Y.Z 或 latest", v)
+}
+
+// ModuleVersion 返回当前程序构建信息中 aether-go 模块的版本
+//
+// 本地开发(devel)、replace 到本地目录或伪版本没有对应的 Release,此时返回空字符串
+func ModuleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	mod := &info.Main
+	if mod.Path != ModulePath {
+		mod = nil
+		for _, dep := range info.Deps {
+			if dep.Path == ModulePath {
+				mod = dep
+				break
+			}
+		}
+	}
+	if mod == nil || (mod.Replace != nil && mod.Replace.Version == "") {
+		return ""
+	}
+	if !releaseVersion.MatchString(mod.Version) || pseudoVersion.MatchString(mod.Version) {
+		return ""
+	}
+	return mod.Version
+}
+
+// ProjectVersion 返回 root 目录中项目依赖的 aether-go Release 版本
+//
+// 通过 go run github.com/xiaozuhui/aether-go/cmd/doctor@latest 等方式运行时,构建信息中的
+// 主模块是 aether-go 本身,其版本与项目依赖的版本无关,此时从 root 中的 go.mod 读取;
+// 其他情况与 ModuleVersion 相同。没有对应的 Release 时返回空字符串
+func ProjectVersion(root string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Path != ModulePath || info.Main.Version == "" || info.Main.Version == "(devel)" {
+		return ModuleVersion()
+	}
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	return requiredVersion(data)
+}
+
+// requiredVersion 返回 go.mod 内容中 aether-go 的 Release 版本
+//
+// 没有依赖 aether-go、依赖伪版本或 replace 了 aether-go 时返回空字符串
+func requiredVersion(gomod []byte) string {
+	var version, block string
+	for _, line := range strings.Split(string(gomod), "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case block != "" && fields[0] == ")":
+			block = ""
+			continue
+		case block == "" && len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+			continue
+		}
+
+		directive := block
+		if directive == "" {
+			directive, fields = fields[0], fields[1:]
+		}
+		if len(fields) < 2 || fields[0] != ModulePath {
+			continue
+		}
+		switch directive {
+		case "require":
+			version = fields[1]
+		case "replace":
+			return ""
+		}
+	}
+	if !releaseVersion.MatchString(version) || pseudoVersion.MatchString(version) {
+		return ""
+	}
+	return version
+}
+
+// SameVersion 比较 Release 版本与 Version() 返回的库版本,忽略 v 前缀
+func SameVersion(release, lib string) bool {
+	return strings.TrimPrefix(release, "v") == strings.TrimPrefix(lib, "v")
+}
@@ -0,0 +1,112 @@
+package fetch
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// minisign 签名算法标识
+var (
+	algEd       = [2]byte{'E', 'd'} // 直接签名文件内容
+	algPrehashE = [2]byte{'E', 'D'} // 签名文件的 BLAKE2b-512 哈希
+)
+
+// PublicKey 表示 minisign 公钥
+type PublicKey struct {
+	KeyID [8]byte
+	Key   ed25519.PublicKey
+}
+
+// ParsePublicKey 解析 minisign 公钥
+//
+// s 可以是 .pub 文件的完整内容,也可以只是其中的 base64 行
+func ParsePublicKey(s string) (PublicKey, error) {
+	var line string
+	for _, l := range strings.Split(strings.TrimSpace(s), "\n") {
+		l = strings.TrimSpace(l)
+		if l != "" && !strings.HasPrefix(l, "untrusted comment:") {
+			line = l
+			break
+		}
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || !bytes.Equal(raw[:2], algEd[:]) {
+		return PublicKey{}, errors.New("无效的 minisign 公钥")
+	}
+
+	var pk PublicKey
+	copy(pk.KeyID[:], raw[2:10])
+	pk.Key = ed25519.PublicKey(raw[10:])
+	return pk, nil
+}
+
+// VerifySignature 使用 minisign 公钥验证 message 的签名文件(.minisig)
+//
+// 同时验证签名本身和可信注释(trusted comment)的全局签名
+func VerifySignature(pk PublicKey, message, sigFile []byte) error {
+	lines := strings.Split(strings.TrimSpace(string(sigFile)), "\n")
+	if len(lines) != 4 ||
+		!strings.HasPrefix(lines[0], "untrusted comment:") ||
+		!strings.HasPrefix(lines[2], "trusted comment: ") {
+		return errors.New("无效的 minisign 签名文件")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return errors.New("无效的 minisign 签名")
+	}
+	var alg [2]byte
+	copy(alg[:], raw[:2])
+	keyID, sig := raw[2:10], raw[10:]
+
+	if !bytes.Equal(keyID, pk.KeyID[:]) {
+		return fmt.Errorf("签名密钥 %X 与公钥 %X 不匹配", keyID, pk.KeyID)
+	}
+
+	signed := message
+	switch alg {
+	case algEd:
+	case algPrehashE:
+		sum := blake2b.Sum512(message)
+		signed = sum[:]
+	default:
+		return fmt.Errorf("不支持的签名算法: %q", alg[:])
+	}
+	if !ed25519.Verify(pk.Key, signed, sig) {
+		return errors.New("签名验证失败")
+	}
+
+	trusted := strings.TrimPrefix(strings.TrimRight(lines[2], "\r"), "trusted comment: ")
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return errors.New("无效的 minisign 全局签名")
+	}
+	if !ed25519.Verify(pk.Key, append(append([]byte{}, sig...), trusted...), globalSig) {
+		return errors.New("可信注释签名验证失败")
+	}
+	return nil
+}
+
+// PublicKeyEnv 为指定 minisign 公钥的环境变量
+const PublicKeyEnv = "AETHER_MINISIGN_PUBKEY"
+
+// PublicKeyFromEnv 从 AETHER_MINISIGN_PUBKEY 读取公钥,未设置时返回 nil
+func PublicKeyFromEnv() (*PublicKey, error) {
+	s := os.Getenv(PublicKeyEnv)
+	if s == "" {
+		return nil, nil
+	}
+	pk, err := ParsePublicKey(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", PublicKeyEnv, err)
+	}
+	return &pk, nil
+}
@@ -0,0 +1,132 @@
+// Package fetch 实现预编译库的平台检测与下载,供 cmd/fetch 和 aether.FetchLibrary 共用
+package fetch
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Platform 描述一个预编译库的目标平台
+type Platform struct {
+	OS   string
+	Arch string
+	// Libc 仅用于 Linux: "gnu"(glibc) 或 "musl"
+	Libc string
+}
+
+// SupportedPlatforms 为提供预编译库的平台
+var SupportedPlatforms = []Platform{
+	{OS: "darwin", Arch: "amd64"},
+	{OS: "darwin", Arch: "arm64"},
+	{OS: "linux", Arch: "amd64", Libc: "gnu"},
+	{OS: "linux", Arch: "amd64", Libc: "musl"},
+	{OS: "linux", Arch: "arm64", Libc: "gnu"},
+	{OS: "linux", Arch: "arm64", Libc: "musl"},
+	{OS: "windows", Arch: "amd64"},
+}
+
+// Detect 检测当前平台
+//
+// 在 Linux 上通过查找 musl 动态链接器区分 glibc 与 musl
+func Detect() Platform {
+	p := Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+	if p.OS == "linux" {
+		p.Libc = "gnu"
+		if isMusl() {
+			p.Libc = "musl"
+		}
+	}
+	return p
+}
+
+// isMusl 判断当前系统是否使用 musl libc
+func isMusl() bool {
+	matches, _ := filepath.Glob("/lib/ld-musl-*.so.1")
+	return len(matches) > 0
+}
+
+// ParsePlatform 解析 "linux-amd64"、"linux-arm64-musl"、"windows-amd64" 等平台名称
+//
+// Linux 未指定 libc 时默认为 glibc
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Platform{}, fmt.Errorf("无效的平台名称: %s (格式: os-arch[-libc])", s)
+	}
+
+	p := Platform{OS: parts[0], Arch: parts[1]}
+	if p.OS == "linux" {
+		p.Libc = "gnu"
+		if len(parts) == 3 {
+			p.Libc = parts[2]
+		}
+	} else if len(parts) == 3 {
+		return Platform{}, fmt.Errorf("无效的平台名称: %s (仅 Linux 可指定 libc)", s)
+	}
+	return p, nil
+}
+
+// String 返回平台名称,如 "darwin-arm64"、"linux-amd64"、"linux-amd64-musl"
+//
+// glibc 是 Linux 的默认值,不出现在名称中
+func (p Platform) String() string {
+	name := p.OS + "-" + p.Arch
+	if p.Libc != "" && p.Libc != "gnu" {
+		name += "-" + p.Libc
+	}
+	return name
+}
+
+// Supported 检查平台是否提供预编译库
+func (p Platform) Supported() error {
+	for _, sp := range SupportedPlatforms {
+		if sp == p {
+			return nil
+		}
+	}
+
+	names := make([]string, len(SupportedPlatforms))
+	for i, sp := range SupportedPlatforms {
+		names[i] = sp.String()
+	}
+	return fmt.Errorf("不支持的平台: %s (支持: %s)", p, strings.Join(names, ", "))
+}
+
+// AssetName 返回 GitHub Release 中的预编译库文件名
+//
+// 如 libaether-darwin-arm64.a、libaether-linux-amd64-musl.a、aether-windows-amd64.lib
+func (p Platform) AssetName() string {
+	if p.OS == "windows" {
+		return fmt.Sprintf("aether-%s.lib", p)
+	}
+	return fmt.Sprintf("libaether-%s.a", p)
+}
+
+// LibFileName 返回本地保存的库文件名,即链接器查找的文件名
+func (p Platform) LibFileName() string {
+	if p.OS == "windows" {
+		return "aether.lib"
+	}
+	return "libaether.a"
+}
+
+// sharedExt 返回平台动态库的扩展名
+func (p Platform) sharedExt() string {
+	if p.OS == "darwin" {
+		return "dylib"
+	}
+	return "so"
+}
+
+// SharedAssetName 返回 Release 中该平台动态库的文件名
+// (例如 libaether-linux-amd64.so, libaether-darwin-arm64.dylib)
+func (p Platform) SharedAssetName() string {
+	return fmt.Sprintf("libaether-%s.%s", p, p.sharedExt())
+}
+
+// SharedLibFileName 返回本地保存的动态库文件名,即 -tags aether_dynamic 构建时加载的文件名
+func (p Platform) SharedLibFileName() string {
+	return "libaether." + p.sharedExt()
+}
@@ -0,0 +1,169 @@
+// Package libfile 查找并检查预编译库文件,不依赖 cgo
+//
+// 根包 aether 与 doctor 共用这里的查找规则,因此即使库文件缺失、程序无法链接 aether,
+// doctor 也能给出相同的诊断
+package libfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// ABISymbols 为绑定使用的全部 C 函数,与 aether.h 一致
+var ABISymbols = []string{
+	"aether_new",
+	"aether_new_with_permissions",
+	"aether_eval",
+	"aether_version",
+	"aether_free",
+	"aether_free_string",
+	"aether_set_global",
+	"aether_get_global",
+	"aether_reset_env",
+	"aether_take_trace",
+	"aether_clear_trace",
+	"aether_trace_records",
+	"aether_trace_stats",
+	"aether_set_limits",
+	"aether_get_limits",
+	"aether_clear_cache",
+	"aether_cache_stats",
+	"aether_set_optimization",
+}
+
+// Source 表示库目录的来源
+type Source string
+
+const (
+	// SourceEnv 表示库目录来自 AETHER_LIB_DIR 环境变量
+	SourceEnv Source = "AETHER_LIB_DIR"
+	// SourceModule 表示库目录为模块根目录下的 lib
+	SourceModule Source = "module"
+)
+
+// Locate 返回库目录及其来源
+//
+// 优先使用 AETHER_LIB_DIR 环境变量,否则使用模块根目录(包含 go.mod 的目录)下的 lib
+func Locate() (dir string, source Source) {
+	if dir := os.Getenv("AETHER_LIB_DIR"); dir != "" {
+		return dir, SourceEnv
+	}
+	return filepath.Join(ModuleRoot(), "lib"), SourceModule
+}
+
+// ModuleRoot 查找模块根目录
+func ModuleRoot() string {
+	// 方法1: 从当前工作目录向上查找 go.mod（适用于用户项目）
+	if wd, err := os.Getwd(); err == nil {
+		if dir, ok := findGoMod(wd); ok {
+			return dir
+		}
+	}
+
+	// 方法2: 从当前包的文件路径查找（适用于模块缓存）
+	if _, filename, _, ok := runtime.Caller(0); ok {
+		if dir, ok := findGoMod(filepath.Dir(filename)); ok {
+			return dir
+		}
+	}
+
+	// 如果都找不到，返回当前目录
+	return "."
+}
+
+// findGoMod 从 dir 向上查找包含 go.mod 的目录
+func findGoMod(dir string) (string, bool) {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			// 到达根目录
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// File 描述一个库文件
+type File struct {
+	Size    int64
+	ModTime time.Time
+	SHA256  string
+	// Symbols 为库文件中找到的 ABI 符号, MissingSymbols 为缺少的符号
+	Symbols        []string
+	MissingSymbols []string
+}
+
+// Inspect 读取 path 并检查其中的 ABI 符号
+func Inspect(path string) (*File, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法访问库文件: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取库文件: %w", err)
+	}
+
+	f := &File{
+		Size:    fi.Size(),
+		ModTime: fi.ModTime(),
+		SHA256:  fmt.Sprintf("%x", sha256.Sum256(data)),
+	}
+	// 静态库的符号表与目标文件的字符串表中,符号名都以 NUL 结尾
+	for _, sym := range ABISymbols {
+		if bytes.Contains(data, []byte(sym+"\x00")) {
+			f.Symbols = append(f.Symbols, sym)
+		} else {
+			f.MissingSymbols = append(f.MissingSymbols, sym)
+		}
+	}
+	return f, nil
+}
+
+// Library 描述预编译库文件
+//
+// 根包的 aether.LibraryInfo 与 doctor 的报告都使用此类型
+type Library struct {
+	// Dir 为库目录, Source 说明该目录是如何确定的
+	Dir    string `json:"dir"`
+	Source Source `json:"source"`
+	// Backend 为使用的后端: "static" 或 "dynamic"(-tags aether_dynamic)
+	Backend string `json:"backend"`
+	// Path 为库文件路径
+	Path     string `json:"path"`
+	Platform string `json:"platform"`
+	Exists   bool   `json:"exists"`
+
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modified"`
+	SHA256  string    `json:"sha256,omitempty"`
+
+	// LinkedVersion 为当前程序链接的库的版本,库无法加载或只检查文件时为空
+	LinkedVersion string `json:"linked_version"`
+	// LockVersion 与 LockSHA256 来自 aether.lock,没有锁文件时为空
+	LockVersion string `json:"lock_version,omitempty"`
+	LockSHA256  string `json:"lock_sha256,omitempty"`
+
+	// Symbols 为库文件中找到的 ABI 符号, MissingSymbols 为缺少的符号
+	Symbols        []string `json:"symbols,omitempty"`
+	MissingSymbols []string `json:"missing_symbols,omitempty"`
+}
+
+// SetFile 用 Inspect 的结果填充库文件的信息
+func (l *Library) SetFile(f *File) {
+	l.Exists = true
+	l.Size = f.Size
+	l.ModTime = f.ModTime
+	l.SHA256 = f.SHA256
+	l.Symbols = f.Symbols
+	l.MissingSymbols = f.MissingSymbols
+}
@@ -0,0 +1,43 @@
+package aether
+
+import "time"
+
+// Allocation 描述一个尚未释放的原生资源
+type Allocation struct {
+	// Kind 为资源类型: "handle" 为引擎句柄, "string" 为 C 字符串
+	Kind string `json:"kind"`
+	// ID 为资源的序号,按创建顺序递增
+	ID uint64 `json:"id"`
+	// Created 为创建时间
+	Created time.Time `json:"created"`
+	// Stack 为创建时的调用栈
+	Stack string `json:"stack"`
+}
+
+// DebugLiveHandles 返回尚未关闭的引擎句柄,按创建顺序排列
+//
+// 只有使用 -tags aether_debug 构建时才记录,否则总是返回 nil,见 DebugEnabled。
+// 此方法是线程安全的
+func DebugLiveHandles() []Allocation {
+	return liveAllocations("handle")
+}
+
+// DebugLiveStrings 返回尚未释放的 C 字符串,包括传给原生库的参数与原生库返回的结果
+//
+// 只有使用 -tags aether_debug 构建时才记录,否则总是返回 nil。此方法是线程安全的
+func DebugLiveStrings() []Allocation {
+	return liveAllocations("string")
+}
+
+// openHandle 创建原生引擎并记录句柄
+func openHandle(permissions bool) *handle {
+	h := newHandle(permissions)
+	trackAllocation("handle", h)
+	return h
+}
+
+// closeHandle 释放原生引擎
+func closeHandle(h *handle) {
+	untrackAllocation(h)
+	h.free()
+}
@@ -0,0 +1,74 @@
+//go:build aether_debug
+
+package aether
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DebugEnabled 表示是否使用 -tags aether_debug 构建
+//
+// 为 true 时记录每个引擎句柄与 C 字符串的创建调用栈,供 DebugLiveHandles 与
+// DebugLiveStrings 查询。记录会使每次调用变慢,只应在测试中使用
+const DebugEnabled = true
+
+var (
+	allocMu  sync.Mutex
+	allocSeq uint64
+	allocs   = make(map[interface{}]Allocation)
+)
+
+// trackAllocation 记录 key 对应的资源,key 为句柄或字符串指针
+func trackAllocation(kind string, key interface{}) {
+	a := Allocation{Kind: kind, Created: time.Now(), Stack: callerStack(3)}
+
+	allocMu.Lock()
+	defer allocMu.Unlock()
+	allocSeq++
+	a.ID = allocSeq
+	allocs[key] = a
+}
+
+// untrackAllocation 删除 key 的记录,必须在释放资源之前调用,以免地址被重新分配
+func untrackAllocation(key interface{}) {
+	allocMu.Lock()
+	defer allocMu.Unlock()
+	delete(allocs, key)
+}
+
+// liveAllocations 返回类型为 kind 的所有记录,按创建顺序排列
+func liveAllocations(kind string) []Allocation {
+	allocMu.Lock()
+	defer allocMu.Unlock()
+
+	var live []Allocation
+	for _, a := range allocs {
+		if a.Kind == kind {
+			live = append(live, a)
+		}
+	}
+	sort.Slice(live, func(i, j int) bool { return live[i].ID < live[j].ID })
+	return live
+}
+
+// callerStack 返回调用栈,skip 的含义与 runtime.Callers 相同
+func callerStack(skip int) string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var b strings.Builder
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
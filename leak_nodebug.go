@@ -0,0 +1,16 @@
+//go:build !aether_debug
+
+package aether
+
+// DebugEnabled 表示是否使用 -tags aether_debug 构建
+//
+// 为 false 时不记录原生资源, DebugLiveHandles 与 DebugLiveStrings 总是返回 nil
+const DebugEnabled = false
+
+func trackAllocation(kind string, key interface{}) {}
+
+func untrackAllocation(key interface{}) {}
+
+func liveAllocations(kind string) []Allocation {
+	return nil
+}
@@ -1,15 +1,21 @@
 package aether
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"runtime"
-	"syscall"
-	"unsafe"
+
+	"github.com/xiaozuhui/aether-go/internal/fetch"
+	"github.com/xiaozuhui/aether-go/internal/libfile"
 )
 
+// ErrLibraryVersionMismatch 表示链接的库版本与 aether.lock 记录的版本不一致
+var ErrLibraryVersionMismatch = errors.New("aether: 库版本与 aether.lock 记录的版本不一致")
+
+// ErrLibraryModified 表示库文件与 aether.lock 记录的 SHA-256 不一致,即下载后被修改或替换
+var ErrLibraryModified = errors.New("aether: 库文件与 aether.lock 记录的 SHA-256 不一致")
+
 // 自动检查预编译库（静默检查，不打印警告）
 // 库文件缺失时，CGO 链接阶段会自动报错
 func init() {
@@ -18,52 +24,100 @@ func init() {
 	_ = IsLibraryAvailable()
 }
 
-// getLibDir 获取库文件目录
-func getLibDir() (string, error) {
-	// 优先使用环境变量
-	if dir := os.Getenv("AETHER_LIB_DIR"); dir != "" {
-		return dir, nil
+// CheckLibraryVersion 比较 Version() 与库目录中 aether.lock 记录的版本
+//
+// 修改 aether.lock 或重新下载库文件后没有重新编译时,链接进程序的库可能与锁定的版本不同。
+// 没有锁文件或锁文件没有固定版本时返回 nil;版本不一致时返回包装了
+// ErrLibraryVersionMismatch 的错误。第一次创建 Engine 时会自动执行此检查,
+// 版本不一致时在标准错误输出警告
+func CheckLibraryVersion() error {
+	if err := loadLibrary(); err != nil {
+		return err
+	}
+	return checkLibraryVersion(libraryDir())
+}
+
+// checkLibraryVersion 比较 Version() 与 libDir 中 aether.lock 记录的版本
+func checkLibraryVersion(libDir string) error {
+	lock, err := fetch.ReadLock(libDir)
+	if err != nil {
+		return fmt.Errorf("aether: 无法读取 %s: %w", fetch.LockFile, err)
+	}
+	if lock == nil || !lock.Pinned() {
+		return nil
 	}
+	if v := Version(); !fetch.SameVersion(lock.Version, v) {
+		return fmt.Errorf("%w: 链接的库为 %s,aether.lock 记录为 %s,请运行 go clean -cache 后重新编译,"+
+			"或运行 go run github.com/xiaozuhui/aether-go/cmd/fetch --version %s --force",
+			ErrLibraryVersionMismatch, v, lock.Version, v)
+	}
+	return nil
+}
 
-	// 查找项目根目录（包含 go.mod 的目录）
-	moduleRoot := findModuleRoot()
+// LibrarySource 表示库目录的来源
+type LibrarySource = libfile.Source
 
-	// 使用项目内的 lib 目录
-	libDir := filepath.Join(moduleRoot, "lib")
+const (
+	// LibrarySourceEnv 表示库目录来自 AETHER_LIB_DIR 环境变量
+	LibrarySourceEnv = libfile.SourceEnv
+	// LibrarySourceModule 表示库目录为模块根目录下的 lib
+	LibrarySourceModule = libfile.SourceModule
+)
+
+// locateLibDir 返回库目录及其来源
+//
+// 优先使用 AETHER_LIB_DIR 环境变量,否则使用模块根目录(包含 go.mod 的目录)下的 lib。
+// 所有查找库文件的函数都通过这里确定目录
+func locateLibDir() (string, LibrarySource) {
+	return libfile.Locate()
+}
+
+// getLibDir 获取库文件目录,库文件不存在时返回说明解决方法的错误
+func getLibDir() (string, error) {
+	libDir, source := locateLibDir()
 
 	// 检查库文件是否存在
-	libFile := filepath.Join(libDir, "libaether.a")
+	libFile := filepath.Join(libDir, libFileName())
 	if _, err := os.Stat(libFile); err != nil {
 		if os.IsNotExist(err) {
-			return "", fmt.Errorf("库文件未下载,请运行: go run github.com/xiaozuhui/aether-go/cmd/fetch@latest")
+			if source == LibrarySourceEnv {
+				return "", fmt.Errorf("库文件不存在: %s (目录来自 AETHER_LIB_DIR)\n解决方法: 检查 AETHER_LIB_DIR,或运行 go run github.com/xiaozuhui/aether-go/cmd/fetch --dir %s", libFile, libDir)
+			}
+			return "", fmt.Errorf("库文件不存在: %s\n解决方法: 运行 go run github.com/xiaozuhui/aether-go/cmd/fetch", libFile)
 		}
-		return "", fmt.Errorf("无法访问库目录: %w", err)
+		return "", fmt.Errorf("无法访问库文件: %w", err)
 	}
 
 	return libDir, nil
 }
 
-// detectPlatform 检测当前平台
-func detectPlatform() string {
-	os := runtime.GOOS
-	arch := runtime.GOARCH
-
-	// 转换架构名称
-	switch arch {
-	case "amd64":
-		// 保持不变
-	case "arm64":
-		// 保持不变
+// verifyLibrary 按 path 所在目录的 aether.lock 校验库文件, asset 为其对应的 Release 文件名
+//
+// 没有锁文件、锁文件记录其他文件或库文件不存在时不校验,由加载时报告错误
+func verifyLibrary(path, asset string) error {
+	err := fetch.VerifyLock(filepath.Dir(path), asset, path)
+	switch {
+	case err == nil || errors.Is(err, os.ErrNotExist):
+		return nil
+	case errors.Is(err, fetch.ErrChecksumMismatch):
+		return fmt.Errorf("%w: %v\n解决方法: 运行 go run github.com/xiaozuhui/aether-go/cmd/fetch --force 重新下载", ErrLibraryModified, err)
 	default:
-		arch = "amd64" // 默认
+		return fmt.Errorf("aether: 无法校验 %s: %w", path, err)
 	}
+}
 
-	return fmt.Sprintf("%s-%s", os, arch)
+// detectPlatform 检测当前平台
+func detectPlatform() string {
+	return fetch.Detect().String()
 }
 
-// isMacOS 检查是否为 macOS 平台
-func isMacOS(platform string) bool {
-	return len(platform) >= 6 && platform[:6] == "darwin"
+// libFileName 返回当前平台的库文件名(Windows 为 aether.lib,其他平台为 libaether.a;
+// -tags aether_dynamic 构建时为 libaether.so 或 libaether.dylib)
+func libFileName() string {
+	if dynamicLibrary {
+		return fetch.Detect().SharedLibFileName()
+	}
+	return fetch.Detect().LibFileName()
 }
 
 // ensureLibrary 确保库文件存在
@@ -73,15 +127,6 @@ func ensureLibrary() error {
 		return err
 	}
 
-	// 检查库文件是否存在
-	libFile := filepath.Join(libDir, "libaether.a")
-	if _, err := os.Stat(libFile); err != nil {
-		if os.IsNotExist(err) {
-			return fmt.Errorf("库文件不存在: %s", libFile)
-		}
-		return fmt.Errorf("无法访问库文件: %w", err)
-	}
-
 	// 设置 AETHER_LIB_DIR 环境变量供 CGO 使用
 	os.Setenv("AETHER_LIB_DIR", libDir)
 
@@ -89,170 +134,66 @@ func ensureLibrary() error {
 }
 
 // FetchLibrary 下载预编译库
+//
+// 支持 darwin-amd64、darwin-arm64、linux-amd64、linux-arm64(glibc 与 musl)
+// 和 windows-amd64,库文件保存到模块根目录的 lib 目录。
+// 下载的库文件必须与 Release 中的 SHA256SUMS 一致(Release 没有发布时返回错误);
+// 设置了 AETHER_MINISIGN_PUBKEY 时还会验证 SHA256SUMS 的 minisign 签名。
+// 下载的版本与当前程序依赖的 aether-go 模块版本一致(本地开发时为最新版本),
+// 已下载过的库文件从用户级共享缓存复制
 func FetchLibrary() error {
-	// 检测平台
-	platform := detectPlatform()
-
-	// 检查是否为 macOS 平台
-	if !isMacOS(platform) {
-		return fmt.Errorf("当前仅提供 macOS 平台的预编译库。其他平台需要从源码编译")
+	publicKey, err := fetch.PublicKeyFromEnv()
+	if err != nil {
+		return err
 	}
 
 	// 查找项目根目录
-	moduleRoot := findModuleRoot()
-	libDir := filepath.Join(moduleRoot, "lib")
-
-	// 创建 lib 目录
-	if err := os.MkdirAll(libDir, 0755); err != nil {
-		return fmt.Errorf("无法创建 lib 目录: %w", err)
-	}
-
-	// 构建下载文件名（包含架构信息）
-	// 文件名格式: libaether-darwin-arm64.a 或 libaether-darwin-amd64.a
-	libName := fmt.Sprintf("libaether-%s.a", platform)
-	url := fmt.Sprintf("https://github.com/xiaozuhui/aether-go/releases/latest/download/%s", libName)
-
-	// 本地保存路径（统一命名为 libaether.a）
-	outputFile := filepath.Join(libDir, "libaether.a")
-
-	// 检查是否已存在
-	if _, err := os.Stat(outputFile); err == nil {
-		fmt.Printf("库文件已存在: %s\n", outputFile)
-		return nil
-	}
-
-	// 下载文件
-	fmt.Printf("正在从 %s 下载...\n", url)
-	cmd := exec.Command("curl", "-L", "-f", "--progress-bar", url, "-o", outputFile)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("下载失败: %w\n请检查网络连接或手动下载: %s", err, url)
-	}
-
-	fmt.Printf("\n库文件已下载到: %s\n", outputFile)
-	return nil
-}
-
-// findModuleRoot 查找模块根目录
-func findModuleRoot() string {
-	// 方法1: 从当前工作目录向上查找 go.mod（适用于用户项目）
-	if wd, err := os.Getwd(); err == nil {
-		dir := wd
-		for {
-			if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
-				return dir
-			}
-
-			parent := filepath.Dir(dir)
-			if parent == dir {
-				// 到达根目录
-				break
-			}
-			dir = parent
-		}
-	}
-
-	// 方法2: 从当前包的文件路径查找（适用于模块缓存）
-	_, filename, _, ok := runtime.Caller(0)
-	if ok {
-		dir := filepath.Dir(filename)
-		for {
-			if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
-				return dir
-			}
-
-			parent := filepath.Dir(dir)
-			if parent == dir {
-				// 到达根目录
-				break
-			}
-			dir = parent
-		}
-	}
-
-	// 如果都找不到，返回当前目录
-	return "."
+	moduleRoot := libfile.ModuleRoot()
+
+	_, err = fetch.Library(fetch.Options{
+		Platform:  fetch.Detect(),
+		Version:   fetch.ModuleVersion(),
+		LibDir:    filepath.Join(moduleRoot, "lib"),
+		Shared:    dynamicLibrary,
+		CacheDir:  fetch.DefaultCacheDir(),
+		PublicKey: publicKey,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	})
+	return err
 }
 
 // GetLibraryInfo 获取库文件信息
+//
+// Deprecated: 使用 InspectLibrary,它返回类型化的 LibraryInfo
 func GetLibraryInfo() map[string]interface{} {
-	moduleRoot := findModuleRoot()
-	libDir := filepath.Join(moduleRoot, "lib")
-	libFile := filepath.Join(libDir, "libaether.a")
+	info, err := InspectLibrary()
 
-	info := map[string]interface{}{
-		"lib_dir": libDir,
-		"platform": detectPlatform(),
+	m := map[string]interface{}{
+		"lib_dir":  info.Dir,
+		"platform": info.Platform,
+		"exists":   info.Exists,
 	}
-
-	// 检查库文件是否存在
-	if fi, err := os.Stat(libFile); err == nil {
-		info["size"] = fi.Size()
-		info["modified"] = fi.ModTime()
-		info["exists"] = true
-	} else {
-		info["exists"] = false
-		info["error"] = err.Error()
+	if info.Exists {
+		m["size"] = info.Size
+		m["modified"] = info.ModTime
 	}
-
-	return info
+	if err != nil {
+		m["error"] = err.Error()
+	}
+	return m
 }
 
 // IsLibraryAvailable 检查库是否可用
 func IsLibraryAvailable() bool {
-	libDir, err := getLibDir()
-	if err != nil {
-		return false
-	}
-
-	libFile := filepath.Join(libDir, "libaether.a")
-	_, err = os.Stat(libFile)
+	_, err := getLibDir()
 	return err == nil
 }
 
 // EnsureLibraryError 提供更详细的库错误信息
+//
+// 库文件可用时返回 nil,否则返回包含解决方法的错误
 func EnsureLibraryError() error {
-	if IsLibraryAvailable() {
-		return nil
-	}
-
-	moduleRoot := findModuleRoot()
-	libDir := filepath.Join(moduleRoot, "lib")
-	libFile := filepath.Join(libDir, "libaether.a")
-
-	if _, err := os.Stat(libFile); err != nil {
-		if os.IsNotExist(err) {
-			return fmt.Errorf("库文件不存在: %s\n解决方法: 运行 go run github.com/xiaozuhui/aether-go/cmd/fetch@latest", libFile)
-		}
-		return fmt.Errorf("无法访问库文件: %w", err)
-	}
-
-	return nil
-}
-
-// SyscallStat 用于检查库文件
-type SyscallStat_t struct {
-	Dev uint64
-	Ino uint64
-	Mode uint32
-	Nlink uint32
-	Uid uint32
-	Gid uint32
-	Rdev uint64
-	Size int64
-	Atim int64
-	Mtim int64
-	Ctim int64
-	Blksize int64
-	Blocks int64
-}
-
-// getLibFileStat 获取库文件状态 (使用 syscall,避免 CGO 递归)
-func getLibFileStat(path string) (*SyscallStat_t, error) {
-	var stat SyscallStat_t
-	err := syscall.Stat(path, (*syscall.Stat_t)(unsafe.Pointer(&stat)))
-	return &stat, err
+	_, err := getLibDir()
+	return err
 }
@@ -0,0 +1,58 @@
+package aether
+
+import (
+	"path/filepath"
+
+	"github.com/xiaozuhui/aether-go/internal/fetch"
+	"github.com/xiaozuhui/aether-go/internal/libfile"
+)
+
+// abiSymbols 为绑定使用的全部 C 函数,与 aether.h 一致
+var abiSymbols = libfile.ABISymbols
+
+// LibraryInfo 描述预编译库文件,与 doctor 报告中的库信息为同一类型
+//
+// Backend 为当前构建使用的后端, LinkedVersion 为当前程序链接的库的 Version()
+type LibraryInfo = libfile.Library
+
+// InspectLibrary 检查库文件并返回其信息
+//
+// 库目录的查找规则与 IsLibraryAvailable、EnsureLibraryError 相同。
+// 库文件不存在或无法读取时返回的 LibraryInfo 仍包含目录与平台等信息,
+// 同时返回说明解决方法的错误
+func InspectLibrary() (*LibraryInfo, error) {
+	dir, source := locateLibDir()
+	info := &LibraryInfo{
+		Dir:      dir,
+		Source:   source,
+		Backend:  libraryBackend(),
+		Path:     filepath.Join(dir, libFileName()),
+		Platform: detectPlatform(),
+	}
+	if err := loadLibrary(); err == nil {
+		info.LinkedVersion = Version()
+	}
+
+	if lock, err := fetch.ReadLock(dir); err == nil && lock != nil {
+		info.LockVersion = lock.Version
+		info.LockSHA256 = lock.SHA256
+	}
+
+	if _, err := getLibDir(); err != nil {
+		return info, err
+	}
+	f, err := libfile.Inspect(info.Path)
+	if err != nil {
+		return info, err
+	}
+	info.SetFile(f)
+	return info, nil
+}
+
+// libraryBackend 返回当前构建使用的后端
+func libraryBackend() string {
+	if dynamicLibrary {
+		return "dynamic"
+	}
+	return "static"
+}
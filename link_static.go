@@ -0,0 +1,43 @@
+//go:build !aether_dynamic || windows
+
+package aether
+
+// 默认构建静态链接 lib 目录中的 libaether.a (Windows 为 aether.lib)
+//
+// 非 Windows 平台直接指定 .a 文件的路径而不是 -laether,否则 lib 中同时存在
+// libaether.so 时链接器会优先选择动态库。
+//
+// 使用 -tags aether_dynamic 构建时改为在运行时加载动态库,见 dynamic.go
+
+/*
+#cgo darwin,arm64 LDFLAGS: ${SRCDIR}/lib/libaether.a -ldl -lm -lpthread
+#cgo darwin,amd64 LDFLAGS: ${SRCDIR}/lib/libaether.a -ldl -lm -lpthread
+#cgo linux,arm64 LDFLAGS: ${SRCDIR}/lib/libaether.a -ldl -lm -lpthread
+#cgo linux,amd64 LDFLAGS: ${SRCDIR}/lib/libaether.a -ldl -lm -lpthread
+#cgo windows,amd64 LDFLAGS: -L${SRCDIR}/lib -laether -lws2_32 -luserenv -lbcrypt -lntdll
+#cgo darwin LDFLAGS: -framework Security -framework CoreFoundation
+*/
+import "C"
+
+import "errors"
+
+// dynamicLibrary 表示是否在运行时加载动态库
+const dynamicLibrary = false
+
+// loadLibrary 确保库已加载,静态链接时库总是可用
+func loadLibrary() error {
+	return nil
+}
+
+// libraryDir 返回库目录,静态链接时即 AETHER_LIB_DIR 或模块根目录下的 lib
+func libraryDir() string {
+	dir, _ := locateLibDir()
+	return dir
+}
+
+// LoadLibrary 从 path 加载动态库
+//
+// 只在使用 -tags aether_dynamic 构建时可用,静态链接时总是返回错误
+func LoadLibrary(path string) error {
+	return errors.New("aether: 当前为静态链接构建,LoadLibrary 需要 -tags aether_dynamic")
+}
@@ -0,0 +1,355 @@
+// Package metrics 为 Aether 引擎和引擎池提供 Prometheus 指标采集器
+//
+// 基本用法:
+//
+//	engine := aether.New()
+//	defer engine.Close()
+//
+//	collector := metrics.NewCollector()
+//	collector.AddEngine("rules", engine)
+//	prometheus.MustRegister(collector)
+//
+// 所有指标都带有 engine 标签,取值为注册时提供的名称。
+// 注册为引擎池时,缓存与追踪指标是池中所有引擎的总和。
+// 同一个引擎只能注册一次(包括作为引擎池的成员),重复注册返回 ErrAlreadyRegistered。
+// 关闭引擎或引擎池后调用 RemoveEngine 或 RemovePool 注销,注销后不再导出其指标。
+//
+// 步数直方图只记录 EvalEvent.Steps 不为 -1 的执行;当前的原生库不报告步数,
+// 因此该直方图在原生库提供步数之前没有样本。
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+const namespace = "aether"
+
+// DefaultLatencyBuckets 是执行耗时直方图的默认分桶(秒)
+var DefaultLatencyBuckets = []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}
+
+// DefaultStepBuckets 是执行步数直方图的默认分桶
+var DefaultStepBuckets = prometheus.ExponentialBuckets(10, 10, 7)
+
+var (
+	// ErrAlreadyRegistered 在同一个引擎被注册多次时返回,重复注册会使每次执行被计数多次
+	ErrAlreadyRegistered = errors.New("metrics: 引擎已注册")
+	// ErrNotRegistered 在注销未注册的引擎或引擎池时返回
+	ErrNotRegistered = errors.New("metrics: 引擎未注册")
+)
+
+// Collector 实现 prometheus.Collector 接口
+//
+// Collector 是线程安全的
+type Collector struct {
+	evals   *prometheus.CounterVec
+	latency *prometheus.HistogramVec
+	steps   *prometheus.HistogramVec
+
+	cacheHits    *prometheus.Desc
+	cacheMisses  *prometheus.Desc
+	cacheSize    *prometheus.Desc
+	cacheEvicted *prometheus.Desc
+	cacheBytes   *prometheus.Desc
+	traceEntries *prometheus.Desc
+	traceBuffer  *prometheus.Desc
+	traceFull    *prometheus.Desc
+	traceDropped *prometheus.Desc
+	poolSize     *prometheus.Desc
+	poolInUse    *prometheus.Desc
+	poolAcquired *prometheus.Desc
+	poolWaits    *prometheus.Desc
+
+	mu         sync.RWMutex
+	engines    map[string][]*aether.Engine
+	pools      map[string]*aether.Pool
+	registered map[*aether.Engine]*registration
+}
+
+// registration 记录引擎的一次注册
+//
+// 引擎无法移除观察者,注销后观察者仍会被调用;观察者只在注册仍然有效时记录执行
+type registration struct {
+	name string
+	pool *aether.Pool
+}
+
+// NewCollector 创建一个使用默认分桶的采集器
+func NewCollector() *Collector {
+	return NewCollectorWithBuckets(DefaultLatencyBuckets)
+}
+
+// NewCollectorWithBuckets 创建一个使用指定耗时分桶(秒)的采集器
+func NewCollectorWithBuckets(buckets []float64) *Collector {
+	engineLabel := []string{"engine"}
+	return &Collector{
+		evals: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "evaluations_total",
+			Help:      "Total number of Eval calls by outcome and error code.",
+		}, []string{"engine", "outcome", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "evaluation_duration_seconds",
+			Help:      "Latency of Eval calls in seconds.",
+			Buckets:   buckets,
+		}, engineLabel),
+		steps: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "evaluation_steps",
+			Help:      "Execution steps consumed by Eval calls, for engines that report them.",
+			Buckets:   DefaultStepBuckets,
+		}, engineLabel),
+
+		cacheHits: prometheus.NewDesc(namespace+"_cache_hits_total",
+			"Total number of AST cache hits.", engineLabel, nil),
+		cacheMisses: prometheus.NewDesc(namespace+"_cache_misses_total",
+			"Total number of AST cache misses.", engineLabel, nil),
+		cacheSize: prometheus.NewDesc(namespace+"_cache_entries",
+			"Number of entries in the AST cache.", engineLabel, nil),
+		cacheEvicted: prometheus.NewDesc(namespace+"_cache_evictions_total",
+			"Total number of AST cache entries evicted by capacity or TTL.", engineLabel, nil),
+		cacheBytes: prometheus.NewDesc(namespace+"_cache_bytes",
+			"Total source size in bytes of cached scripts.", engineLabel, nil),
+		traceEntries: prometheus.NewDesc(namespace+"_trace_entries",
+			"Number of entries in the trace buffer.", engineLabel, nil),
+		traceBuffer: prometheus.NewDesc(namespace+"_trace_buffer_size",
+			"Capacity of the trace buffer.", engineLabel, nil),
+		traceFull: prometheus.NewDesc(namespace+"_trace_buffer_full",
+			"Number of trace buffers that are full; new entries are being dropped.", engineLabel, nil),
+		traceDropped: prometheus.NewDesc(namespace+"_trace_dropped_entries",
+			"Number of trace entries counted by the engine but no longer retained in the buffer since it was last cleared.", engineLabel, nil),
+		poolSize: prometheus.NewDesc(namespace+"_pool_engines",
+			"Number of engines in the pool.", engineLabel, nil),
+		poolInUse: prometheus.NewDesc(namespace+"_pool_engines_in_use",
+			"Number of engines currently acquired from the pool.", engineLabel, nil),
+		poolAcquired: prometheus.NewDesc(namespace+"_pool_acquired_total",
+			"Total number of engines acquired from the pool.", engineLabel, nil),
+		poolWaits: prometheus.NewDesc(namespace+"_pool_waits_total",
+			"Total number of acquisitions that had to wait for an idle engine.", engineLabel, nil),
+
+		engines:    make(map[string][]*aether.Engine),
+		pools:      make(map[string]*aether.Pool),
+		registered: make(map[*aether.Engine]*registration),
+	}
+}
+
+// AddEngine 以 name 为标签注册一个引擎
+//
+// 注册后该引擎的每次 Eval 都会被计数。引擎已经注册过(单独或作为引擎池的成员)时返回 ErrAlreadyRegistered
+func (c *Collector) AddEngine(name string, e *aether.Engine) error {
+	return c.add(name, nil, []*aether.Engine{e})
+}
+
+// AddPool 以 name 为标签注册一个引擎池
+//
+// 池中所有引擎的指标都汇总到同一个标签下,并额外导出池的使用率。
+// 池中任一引擎已经注册过,或 name 已被其他引擎池使用时返回错误,此时不注册任何引擎
+func (c *Collector) AddPool(name string, p *aether.Pool) error {
+	return c.add(name, p, p.Engines())
+}
+
+// add 检查重复后注册引擎与引擎池
+func (c *Collector) add(name string, p *aether.Pool, engines []*aether.Engine) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if p != nil {
+		if _, ok := c.pools[name]; ok {
+			return fmt.Errorf("%w: 名称 %q 已被其他引擎池使用", ErrAlreadyRegistered, name)
+		}
+	}
+	for _, e := range engines {
+		if prev, ok := c.registered[e]; ok {
+			return fmt.Errorf("%w: 已以 %q 注册", ErrAlreadyRegistered, prev.name)
+		}
+	}
+
+	for _, e := range engines {
+		reg := &registration{name: name, pool: p}
+		c.registered[e] = reg
+		c.observe(e, reg)
+	}
+	c.engines[name] = append(c.engines[name], engines...)
+	if p != nil {
+		c.pools[name] = p
+	}
+	return nil
+}
+
+// RemoveEngine 注销通过 AddEngine 注册的引擎
+//
+// 该标签下没有其他引擎时同时删除其执行次数与耗时指标。
+// 引擎未注册时返回 ErrNotRegistered;引擎池中的引擎需要通过 RemovePool 注销
+func (c *Collector) RemoveEngine(e *aether.Engine) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reg, ok := c.registered[e]
+	if !ok {
+		return ErrNotRegistered
+	}
+	if reg.pool != nil {
+		return fmt.Errorf("%w: 引擎属于引擎池 %q,请使用 RemovePool", ErrNotRegistered, reg.name)
+	}
+	delete(c.registered, e)
+
+	engines := c.engines[reg.name]
+	for i, other := range engines {
+		if other == e {
+			engines = append(engines[:i:i], engines[i+1:]...)
+			break
+		}
+	}
+	c.engines[reg.name] = engines
+	c.deleteLabel(reg.name)
+	return nil
+}
+
+// RemovePool 注销通过 AddPool 注册的引擎池及其所有引擎
+//
+// 同时删除该标签下的所有指标。引擎池未注册时返回 ErrNotRegistered
+func (c *Collector) RemovePool(p *aether.Pool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, registered := range c.pools {
+		if registered != p {
+			continue
+		}
+		delete(c.pools, name)
+
+		engines := c.engines[name][:0]
+		for _, e := range c.engines[name] {
+			if c.registered[e].pool == p {
+				delete(c.registered, e)
+			} else {
+				engines = append(engines, e)
+			}
+		}
+		c.engines[name] = engines
+		c.deleteLabel(name)
+		return nil
+	}
+	return ErrNotRegistered
+}
+
+// deleteLabel 在标签下没有引擎时删除其执行指标,调用方必须持有 c.mu 的写锁
+func (c *Collector) deleteLabel(name string) {
+	if len(c.engines[name]) > 0 {
+		return
+	}
+	delete(c.engines, name)
+	labels := prometheus.Labels{"engine": name}
+	c.evals.DeletePartialMatch(labels)
+	c.latency.DeletePartialMatch(labels)
+	c.steps.DeletePartialMatch(labels)
+}
+
+// observe 为引擎注册执行观察者,注册被注销后不再记录
+func (c *Collector) observe(e *aether.Engine, reg *registration) {
+	e.AddEvalObserver(func(ev aether.EvalEvent) {
+		c.mu.RLock()
+		active := c.registered[e] == reg
+		c.mu.RUnlock()
+		if active {
+			c.record(reg.name, ev)
+		}
+	})
+}
+
+// record 记录一次执行事件
+func (c *Collector) record(name string, ev aether.EvalEvent) {
+	outcome := "success"
+	if ev.Err != nil {
+		outcome = "error"
+	}
+	c.evals.WithLabelValues(name, outcome, ev.Code.String()).Inc()
+	c.latency.WithLabelValues(name).Observe(ev.Duration.Seconds())
+	if ev.Steps >= 0 {
+		c.steps.WithLabelValues(name).Observe(float64(ev.Steps))
+	}
+}
+
+// Describe 实现 prometheus.Collector 接口
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.evals.Describe(ch)
+	c.latency.Describe(ch)
+	c.steps.Describe(ch)
+	ch <- c.cacheHits
+	ch <- c.cacheMisses
+	ch <- c.cacheSize
+	ch <- c.cacheEvicted
+	ch <- c.cacheBytes
+	ch <- c.traceEntries
+	ch <- c.traceBuffer
+	ch <- c.traceFull
+	ch <- c.traceDropped
+	ch <- c.poolSize
+	ch <- c.poolInUse
+	ch <- c.poolAcquired
+	ch <- c.poolWaits
+}
+
+// Collect 实现 prometheus.Collector 接口
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.evals.Collect(ch)
+	c.latency.Collect(ch)
+	c.steps.Collect(ch)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for name, engines := range c.engines {
+		c.collectEngines(ch, name, engines)
+	}
+	for name, p := range c.pools {
+		stats := p.Stats()
+		ch <- prometheus.MustNewConstMetric(c.poolSize, prometheus.GaugeValue, float64(stats.Size), name)
+		ch <- prometheus.MustNewConstMetric(c.poolInUse, prometheus.GaugeValue, float64(stats.InUse), name)
+		ch <- prometheus.MustNewConstMetric(c.poolAcquired, prometheus.CounterValue, float64(stats.Acquired), name)
+		ch <- prometheus.MustNewConstMetric(c.poolWaits, prometheus.CounterValue, float64(stats.Waits), name)
+	}
+}
+
+// collectEngines 汇总同一标签下所有引擎的缓存与追踪统计
+//
+// 已关闭的引擎会被跳过
+func (c *Collector) collectEngines(ch chan<- prometheus.Metric, name string, engines []*aether.Engine) {
+	var hits, misses, size, evictions, bytes int
+	var entries, buffer, full, dropped int
+	for _, e := range engines {
+		if cs, err := e.CacheStats(); err == nil {
+			hits += cs.Hits
+			misses += cs.Misses
+			size += cs.Size
+			evictions += cs.Evictions
+			bytes += cs.Bytes
+		}
+		if ts, err := e.TraceStats(); err == nil {
+			entries += ts.TotalEntries
+			buffer += ts.BufferSize
+			if ts.BufferFull {
+				full++
+			}
+			// 缓冲区最多保留 BufferSize 条,超出的部分已被丢弃
+			if ts.BufferSize > 0 && ts.TotalEntries > ts.BufferSize {
+				dropped += ts.TotalEntries - ts.BufferSize
+			}
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.cacheHits, prometheus.CounterValue, float64(hits), name)
+	ch <- prometheus.MustNewConstMetric(c.cacheMisses, prometheus.CounterValue, float64(misses), name)
+	ch <- prometheus.MustNewConstMetric(c.cacheSize, prometheus.GaugeValue, float64(size), name)
+	ch <- prometheus.MustNewConstMetric(c.cacheEvicted, prometheus.CounterValue, float64(evictions), name)
+	ch <- prometheus.MustNewConstMetric(c.cacheBytes, prometheus.GaugeValue, float64(bytes), name)
+	ch <- prometheus.MustNewConstMetric(c.traceEntries, prometheus.GaugeValue, float64(entries), name)
+	ch <- prometheus.MustNewConstMetric(c.traceBuffer, prometheus.GaugeValue, float64(buffer), name)
+	ch <- prometheus.MustNewConstMetric(c.traceFull, prometheus.GaugeValue, float64(full), name)
+	ch <- prometheus.MustNewConstMetric(c.traceDropped, prometheus.GaugeValue, float64(dropped), name)
+}
@@ -0,0 +1,224 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+// TestCollectorEngine 测试单个引擎的指标
+func TestCollectorEngine(t *testing.T) {
+	engine := aether.New()
+	defer engine.Close()
+
+	c := NewCollector()
+	if err := c.AddEngine("rules", engine); err != nil {
+		t.Fatal(err)
+	}
+
+	code := "Set X 10\n(X + 20)"
+	for i := 0; i < 2; i++ {
+		if _, err := engine.Eval(code); err != nil {
+			t.Fatalf("Eval 失败: %v", err)
+		}
+	}
+	if _, err := engine.Eval("UNDEFINED_VAR"); err == nil {
+		t.Fatal("期望错误,得到 nil")
+	}
+
+	expected := `
+# HELP aether_evaluations_total Total number of Eval calls by outcome and error code.
+# TYPE aether_evaluations_total counter
+aether_evaluations_total{code="runtime_error",engine="rules",outcome="error"} 1
+aether_evaluations_total{code="success",engine="rules",outcome="success"} 2
+# HELP aether_cache_hits_total Total number of AST cache hits.
+# TYPE aether_cache_hits_total counter
+aether_cache_hits_total{engine="rules"} 1
+# HELP aether_cache_misses_total Total number of AST cache misses.
+# TYPE aether_cache_misses_total counter
+aether_cache_misses_total{engine="rules"} 2
+# HELP aether_cache_entries Number of entries in the AST cache.
+# TYPE aether_cache_entries gauge
+aether_cache_entries{engine="rules"} 2
+# HELP aether_trace_dropped_entries Number of trace entries counted by the engine but no longer retained in the buffer since it was last cleared.
+# TYPE aether_trace_dropped_entries gauge
+aether_trace_dropped_entries{engine="rules"} 0
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"aether_evaluations_total", "aether_cache_hits_total",
+		"aether_cache_misses_total", "aether_cache_entries",
+		"aether_trace_dropped_entries")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if n := testutil.CollectAndCount(c, "aether_evaluation_duration_seconds"); n != 1 {
+		t.Errorf("期望 1 个耗时直方图,得到 %d", n)
+	}
+	// 原生库不报告步数,不应记录样本
+	if n := testutil.CollectAndCount(c, "aether_evaluation_steps"); n != 0 {
+		t.Errorf("原生库不报告步数时期望没有步数直方图,得到 %d", n)
+	}
+}
+
+// TestCollectorSteps 测试只有报告了步数的执行事件记录到步数直方图
+func TestCollectorSteps(t *testing.T) {
+	c := NewCollector()
+	c.record("rules", aether.EvalEvent{Code: aether.CodeSuccess, Steps: 150})
+	c.record("rules", aether.EvalEvent{Code: aether.CodeSuccess, Steps: -1})
+
+	expected := `
+# HELP aether_evaluation_steps Execution steps consumed by Eval calls, for engines that report them.
+# TYPE aether_evaluation_steps histogram
+aether_evaluation_steps_bucket{engine="rules",le="10"} 0
+aether_evaluation_steps_bucket{engine="rules",le="100"} 0
+aether_evaluation_steps_bucket{engine="rules",le="1000"} 1
+aether_evaluation_steps_bucket{engine="rules",le="10000"} 1
+aether_evaluation_steps_bucket{engine="rules",le="100000"} 1
+aether_evaluation_steps_bucket{engine="rules",le="1e+06"} 1
+aether_evaluation_steps_bucket{engine="rules",le="1e+07"} 1
+aether_evaluation_steps_bucket{engine="rules",le="+Inf"} 1
+aether_evaluation_steps_sum{engine="rules"} 150
+aether_evaluation_steps_count{engine="rules"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "aether_evaluation_steps"); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestCollectorDuplicate 测试重复注册同一个引擎时返回错误,且每次执行只计数一次
+func TestCollectorDuplicate(t *testing.T) {
+	pool := aether.NewPool(2, nil)
+	defer pool.Close()
+
+	c := NewCollector()
+	if err := c.AddPool("workers", pool); err != nil {
+		t.Fatal(err)
+	}
+	member := pool.Engines()[0]
+	if err := c.AddEngine("single", member); !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("注册池中的引擎期望 ErrAlreadyRegistered,得到 %v", err)
+	}
+	if err := c.AddPool("other", pool); !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("重复注册引擎池期望 ErrAlreadyRegistered,得到 %v", err)
+	}
+
+	engine := aether.New()
+	defer engine.Close()
+	if err := c.AddEngine("single", engine); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddEngine("again", engine); !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("重复注册引擎期望 ErrAlreadyRegistered,得到 %v", err)
+	}
+
+	if _, err := member.Eval("1"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+# HELP aether_evaluations_total Total number of Eval calls by outcome and error code.
+# TYPE aether_evaluations_total counter
+aether_evaluations_total{code="success",engine="workers",outcome="success"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "aether_evaluations_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestCollectorPool 测试引擎池的指标
+func TestCollectorPool(t *testing.T) {
+	pool := aether.NewPool(3, nil)
+	defer pool.Close()
+
+	c := NewCollector()
+	if err := c.AddPool("workers", pool); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pool.Eval("(1 + 2)"); err != nil {
+		t.Fatalf("Eval 失败: %v", err)
+	}
+
+	expected := `
+# HELP aether_evaluations_total Total number of Eval calls by outcome and error code.
+# TYPE aether_evaluations_total counter
+aether_evaluations_total{code="success",engine="workers",outcome="success"} 1
+# HELP aether_pool_engines Number of engines in the pool.
+# TYPE aether_pool_engines gauge
+aether_pool_engines{engine="workers"} 3
+# HELP aether_pool_engines_in_use Number of engines currently acquired from the pool.
+# TYPE aether_pool_engines_in_use gauge
+aether_pool_engines_in_use{engine="workers"} 0
+# HELP aether_pool_acquired_total Total number of engines acquired from the pool.
+# TYPE aether_pool_acquired_total counter
+aether_pool_acquired_total{engine="workers"} 1
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"aether_evaluations_total", "aether_pool_engines",
+		"aether_pool_engines_in_use", "aether_pool_acquired_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// TestCollectorRemove 测试注销引擎与引擎池后不再导出其指标,也不再记录执行
+func TestCollectorRemove(t *testing.T) {
+	engine := aether.New()
+	defer engine.Close()
+	pool := aether.NewPool(2, nil)
+	defer pool.Close()
+
+	c := NewCollector()
+	if err := c.AddEngine("rules", engine); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddPool("workers", pool); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Eval("1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.RemoveEngine(pool.Engines()[0]); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("注销池中的引擎期望 ErrNotRegistered,得到 %v", err)
+	}
+	if err := c.RemoveEngine(engine); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RemoveEngine(engine); !errors.Is(err, ErrNotRegistered) {
+		t.Errorf("重复注销期望 ErrNotRegistered,得到 %v", err)
+	}
+	if err := c.RemovePool(pool); err != nil {
+		t.Fatal(err)
+	}
+
+	// 注销后的执行不再计数
+	if _, err := engine.Eval("1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Eval("1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(c); n != 0 {
+		t.Errorf("注销后期望没有指标,得到 %d", n)
+	}
+
+	// 注销后可以重新注册
+	if err := c.AddEngine("rules", engine); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Eval("1"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+# HELP aether_evaluations_total Total number of Eval calls by outcome and error code.
+# TYPE aether_evaluations_total counter
+aether_evaluations_total{code="success",engine="rules",outcome="success"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "aether_evaluations_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,257 @@
+package aether
+
+// 通过 aether.h 中的 C 函数调用原生库,链接方式见 link_static.go 与 dynamic.go
+
+/*
+#include <stdlib.h>
+
+typedef struct AetherHandle AetherHandle;
+
+typedef enum AetherErrorCode {
+    Success = 0,
+    ParseError = 1,
+    RuntimeError = 2,
+    NullPointer = 3,
+    Panic = 4,
+    InvalidJSON = 5,
+    VariableNotFound = 6,
+} AetherErrorCode;
+
+typedef struct AetherLimits {
+    int max_steps;
+    int max_recursion_depth;
+    int max_duration_ms;
+} AetherLimits;
+
+typedef struct AetherCacheStats {
+    int hits;
+    int misses;
+    int size;
+} AetherCacheStats;
+
+AetherHandle* aether_new();
+AetherHandle* aether_new_with_permissions();
+int aether_eval(AetherHandle* handle, const char* code, char** result, char** error);
+const char* aether_version();
+void aether_free(AetherHandle* handle);
+void aether_free_string(char* s);
+
+// 增强的 API
+int aether_set_global(AetherHandle* handle, const char* name, const char* value_json);
+int aether_get_global(AetherHandle* handle, const char* name, char** value_json);
+void aether_reset_env(AetherHandle* handle);
+
+int aether_take_trace(AetherHandle* handle, char** trace_json);
+void aether_clear_trace(AetherHandle* handle);
+int aether_trace_records(AetherHandle* handle, char** trace_json);
+int aether_trace_stats(AetherHandle* handle, char** stats_json);
+
+void aether_set_limits(AetherHandle* handle, const AetherLimits* limits);
+void aether_get_limits(AetherHandle* handle, AetherLimits* limits);
+
+void aether_clear_cache(AetherHandle* handle);
+void aether_cache_stats(AetherHandle* handle, AetherCacheStats* stats);
+
+void aether_set_optimization(AetherHandle* handle, int constant_folding, int dead_code, int tail_recursion);
+*/
+import "C"
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// cStrings 为尚未释放的 C 字符串数量,包括 C.CString 分配的参数与原生库返回的字符串
+var cStrings atomic.Int64
+
+// cStringsInUse 返回尚未释放的 C 字符串数量,供测试检查泄漏
+func cStringsInUse() int64 {
+	return cStrings.Load()
+}
+
+// cString 分配 C 字符串,必须用 freeCString 释放
+func cString(s string) *C.char {
+	cs := C.CString(s)
+	cStrings.Add(1)
+	trackAllocation("string", cs)
+	return cs
+}
+
+func freeCString(s *C.char) {
+	untrackAllocation(s)
+	cStrings.Add(-1)
+	C.free(unsafe.Pointer(s))
+}
+
+// libString 记录原生库返回的字符串,必须用 freeLibString 释放
+func libString(s *C.char) *C.char {
+	if s != nil {
+		cStrings.Add(1)
+		trackAllocation("string", s)
+	}
+	return s
+}
+
+func freeLibString(s *C.char) {
+	if s != nil {
+		untrackAllocation(s)
+		cStrings.Add(-1)
+		C.aether_free_string(s)
+	}
+}
+
+// handle 为原生库的 AetherHandle
+type handle C.AetherHandle
+
+// newHandle 创建原生引擎, permissions 为 true 时允许文件 IO 等操作
+func newHandle(permissions bool) *handle {
+	if permissions {
+		return (*handle)(C.aether_new_with_permissions())
+	}
+	return (*handle)(C.aether_new())
+}
+
+// nativeVersion 返回原生库的版本号
+func nativeVersion() string {
+	return C.GoString(C.aether_version())
+}
+
+func (h *handle) c() *C.AetherHandle {
+	return (*C.AetherHandle)(h)
+}
+
+// eval 执行代码,失败时返回 *Error
+func (h *handle) eval(code string) (string, error) {
+	cCode := cString(code)
+	defer freeCString(cCode)
+
+	var result *C.char
+	var errorMsg *C.char
+
+	status := C.aether_eval(h.c(), cCode, &result, &errorMsg)
+
+	// 无论成功与否,原生库设置的字符串都需要释放
+	result, errorMsg = libString(result), libString(errorMsg)
+	defer freeLibString(result)
+	defer freeLibString(errorMsg)
+
+	if status != C.Success {
+		if errorMsg != nil {
+			return "", &Error{Code: ErrorCode(status), Message: C.GoString(errorMsg)}
+		}
+		return "", &Error{Code: ErrorCode(status), Message: "未知错误"}
+	}
+
+	if result != nil {
+		return C.GoString(result), nil
+	}
+
+	return "", nil
+}
+
+func (h *handle) setGlobal(name, valueJSON string) ErrorCode {
+	cName := cString(name)
+	defer freeCString(cName)
+
+	cValue := cString(valueJSON)
+	defer freeCString(cValue)
+
+	return ErrorCode(C.aether_set_global(h.c(), cName, cValue))
+}
+
+func (h *handle) getGlobal(name string) (string, ErrorCode) {
+	cName := cString(name)
+	defer freeCString(cName)
+
+	var valueJSON *C.char
+	status := C.aether_get_global(h.c(), cName, &valueJSON)
+	return takeString(valueJSON, status)
+}
+
+func (h *handle) resetEnv() {
+	C.aether_reset_env(h.c())
+}
+
+func (h *handle) takeTrace() (string, ErrorCode) {
+	var traceJSON *C.char
+	status := C.aether_take_trace(h.c(), &traceJSON)
+	return takeString(traceJSON, status)
+}
+
+func (h *handle) traceRecords() (string, ErrorCode) {
+	var traceJSON *C.char
+	status := C.aether_trace_records(h.c(), &traceJSON)
+	return takeString(traceJSON, status)
+}
+
+func (h *handle) traceStats() (string, ErrorCode) {
+	var statsJSON *C.char
+	status := C.aether_trace_stats(h.c(), &statsJSON)
+	return takeString(statsJSON, status)
+}
+
+func (h *handle) clearTrace() {
+	C.aether_clear_trace(h.c())
+}
+
+func (h *handle) setLimits(limits Limits) {
+	cLimits := C.AetherLimits{
+		max_steps:           C.int(limits.MaxSteps),
+		max_recursion_depth: C.int(limits.MaxRecursionDepth),
+		max_duration_ms:     C.int(limits.MaxDurationMs),
+	}
+	C.aether_set_limits(h.c(), &cLimits)
+}
+
+func (h *handle) getLimits() Limits {
+	var cLimits C.AetherLimits
+	C.aether_get_limits(h.c(), &cLimits)
+
+	return Limits{
+		MaxSteps:          int(cLimits.max_steps),
+		MaxRecursionDepth: int(cLimits.max_recursion_depth),
+		MaxDurationMs:     int(cLimits.max_duration_ms),
+	}
+}
+
+func (h *handle) clearCache() {
+	C.aether_clear_cache(h.c())
+}
+
+func (h *handle) cacheStats() CacheStats {
+	var cStats C.AetherCacheStats
+	C.aether_cache_stats(h.c(), &cStats)
+
+	return CacheStats{
+		Hits:   int(cStats.hits),
+		Misses: int(cStats.misses),
+		Size:   int(cStats.size),
+	}
+}
+
+func (h *handle) setOptimization(opt Optimization) {
+	C.aether_set_optimization(h.c(),
+		C.int(boolToInt(opt.ConstantFolding)),
+		C.int(boolToInt(opt.DeadCodeElimination)),
+		C.int(boolToInt(opt.TailRecursion)))
+}
+
+func (h *handle) free() {
+	C.aether_free(h.c())
+}
+
+// takeString 复制并释放原生库返回的字符串
+//
+// 失败时 s 通常为 nil,不为 nil 时同样释放
+func takeString(s *C.char, status C.int) (string, ErrorCode) {
+	s = libString(s)
+	defer freeLibString(s)
+
+	if status != C.Success {
+		return "", ErrorCode(status)
+	}
+	if s == nil {
+		return "", CodeSuccess
+	}
+	return C.GoString(s), CodeSuccess
+}
@@ -0,0 +1,49 @@
+package aether
+
+import "errors"
+
+// ErrOptimizationNotSet 在没有通过 ApplyOptimization 或 SetOptimization 设置优化选项时
+// 由 GetOptimization 返回;原生库无法读取其默认配置
+var ErrOptimizationNotSet = errors.New("aether: 未设置优化选项,原生库的默认配置无法读取")
+
+// Optimization 表示引擎的优化选项
+type Optimization struct {
+	// ConstantFolding 启用常量折叠
+	ConstantFolding bool `json:"constant_folding"`
+	// DeadCodeElimination 启用死代码消除
+	DeadCodeElimination bool `json:"dead_code_elimination"`
+	// TailRecursion 启用尾递归优化
+	TailRecursion bool `json:"tail_recursion"`
+}
+
+// 预设的优化配置
+var (
+	// OptNone 关闭所有优化,便于调试
+	OptNone = Optimization{}
+	// OptDefault 启用常量折叠与死代码消除
+	OptDefault = Optimization{ConstantFolding: true, DeadCodeElimination: true}
+	// OptAggressive 启用所有优化,包括会改变递归深度统计的尾递归优化
+	OptAggressive = Optimization{ConstantFolding: true, DeadCodeElimination: true, TailRecursion: true}
+)
+
+// Profile 返回与配置相同的预设名称("none"、"default"、"aggressive"),都不匹配时返回 "custom"
+func (o Optimization) Profile() string {
+	switch o {
+	case OptNone:
+		return "none"
+	case OptDefault:
+		return "default"
+	case OptAggressive:
+		return "aggressive"
+	default:
+		return "custom"
+	}
+}
+
+// boolToInt 将 bool 转换为原生库使用的 0/1
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
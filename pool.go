@@ -0,0 +1,318 @@
+package aether
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrPoolClosed 在引擎池关闭后获取引擎时返回,错误代码与 ErrClosed 相同为 CodeNullPointer
+var ErrPoolClosed = &Error{Code: CodeNullPointer, Message: "引擎池已关闭"}
+
+// ErrNotLent 在归还不属于此池或未被借出的引擎时返回
+var ErrNotLent = errors.New("aether: 引擎不属于此引擎池或未被借出")
+
+// PoolStats 表示引擎池的使用情况
+type PoolStats struct {
+	// Size 为池中引擎总数
+	Size int
+	// InUse 为当前被借出的引擎数
+	InUse int
+	// Idle 为当前空闲的引擎数
+	Idle int
+	// Acquired 为累计借出次数
+	Acquired uint64
+	// Waits 为因无空闲引擎而需要等待的借出次数
+	Waits uint64
+}
+
+// Pool 是固定大小的引擎池
+//
+// 单个 Engine 的所有调用都是串行的,需要并行执行脚本时可以使用 Pool:
+//
+//	pool := aether.NewPool(runtime.NumCPU(), nil)
+//	defer pool.Close()
+//
+//	result, err := pool.Eval("(1 + 2)")
+//
+// Pool 实现了 Evaluator: SetGlobal、SetExecutionLimits 等修改状态的方法作用于池中
+// 所有引擎, TraceRecords、CacheStats 等查询方法汇总所有引擎的结果。
+//
+// Pool 是线程安全的
+type Pool struct {
+	engines []*Engine
+	idle    chan *Engine
+
+	// lent 记录池中每个引擎是否被借出,由 lentMu 保护
+	lentMu sync.Mutex
+	lent   map[*Engine]bool
+
+	inUse    atomic.Int64
+	acquired atomic.Uint64
+	waits    atomic.Uint64
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewPool 创建包含 size 个引擎的池
+//
+// newEngine 用于创建池中的引擎,为 nil 时使用 New()
+func NewPool(size int, newEngine func() *Engine) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	if newEngine == nil {
+		newEngine = New
+	}
+
+	p := &Pool{
+		engines: make([]*Engine, size),
+		idle:    make(chan *Engine, size),
+		lent:    make(map[*Engine]bool, size),
+	}
+	for i := range p.engines {
+		e := newEngine()
+		p.engines[i] = e
+		p.lent[e] = false
+		p.idle <- e
+	}
+	return p
+}
+
+// Acquire 从池中借出一个引擎,无空闲引擎时阻塞直到 ctx 结束
+//
+// 使用完毕后必须调用 Release 归还
+func (p *Pool) Acquire(ctx context.Context) (*Engine, error) {
+	p.mu.RLock()
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		return nil, ErrPoolClosed
+	}
+
+	var e *Engine
+	select {
+	case e = <-p.idle:
+	default:
+		p.waits.Add(1)
+		select {
+		case e = <-p.idle:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	p.lentMu.Lock()
+	p.lent[e] = true
+	p.lentMu.Unlock()
+
+	p.inUse.Add(1)
+	p.acquired.Add(1)
+	return e, nil
+}
+
+// Release 将引擎归还到池中
+//
+// 引擎不属于此池或已经归还时返回 ErrNotLent,引擎不会被放回池中
+func (p *Pool) Release(e *Engine) error {
+	p.lentMu.Lock()
+	if lent, ok := p.lent[e]; !ok || !lent {
+		p.lentMu.Unlock()
+		return ErrNotLent
+	}
+	p.lent[e] = false
+	p.lentMu.Unlock()
+
+	p.inUse.Add(-1)
+	p.idle <- e
+	return nil
+}
+
+// Eval 借出一个引擎执行代码后立即归还
+func (p *Pool) Eval(code string) (string, error) {
+	e, err := p.Acquire(context.Background())
+	if err != nil {
+		return "", err
+	}
+	defer p.Release(e)
+
+	return e.Eval(code)
+}
+
+// Engines 返回池中的所有引擎
+//
+// 返回的引擎可能正被其他 goroutine 使用,仅用于观测(统计、监控等)
+func (p *Pool) Engines() []*Engine {
+	engines := make([]*Engine, len(p.engines))
+	copy(engines, p.engines)
+	return engines
+}
+
+// Stats 返回引擎池的使用情况
+func (p *Pool) Stats() PoolStats {
+	inUse := int(p.inUse.Load())
+	return PoolStats{
+		Size:     len(p.engines),
+		InUse:    inUse,
+		Idle:     len(p.engines) - inUse,
+		Acquired: p.acquired.Load(),
+		Waits:    p.waits.Load(),
+	}
+}
+
+// Close 关闭池中的所有引擎
+//
+// 可以安全地多次调用 Close()
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+
+	for _, e := range p.engines {
+		e.Close()
+	}
+}
+
+// each 对池中的每个引擎调用 fn,返回第一个错误
+func (p *Pool) each(fn func(e *Engine) error) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+	var first error
+	for _, e := range p.engines {
+		if err := fn(e); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// SetGlobal 在池中的所有引擎上设置变量
+//
+// 脚本中用 Set 修改的变量只存在于执行它的引擎中,需要所有脚本可见的值应通过此方法设置
+func (p *Pool) SetGlobal(name string, value interface{}) error {
+	return p.each(func(e *Engine) error { return e.SetGlobal(name, value) })
+}
+
+// GetGlobal 借出一个引擎读取变量
+//
+// 通过 Pool.SetGlobal 设置的变量在所有引擎中相同
+func (p *Pool) GetGlobal(name string) (interface{}, error) {
+	e, err := p.Acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer p.Release(e)
+
+	return e.GetGlobal(name)
+}
+
+// ResetEnv 重置池中所有引擎的运行时环境
+func (p *Pool) ResetEnv() error {
+	return p.each((*Engine).ResetEnv)
+}
+
+// TakeTrace 返回并清空池中所有引擎的追踪条目,按引擎顺序排列
+func (p *Pool) TakeTrace() ([]string, error) {
+	var traces []string
+	err := p.each(func(e *Engine) error {
+		t, err := e.TakeTrace()
+		traces = append(traces, t...)
+		return err
+	})
+	return traces, err
+}
+
+// TraceRecords 返回池中所有引擎的结构化追踪条目,按引擎顺序排列
+func (p *Pool) TraceRecords() ([]TraceEntry, error) {
+	var entries []TraceEntry
+	err := p.each(func(e *Engine) error {
+		r, err := e.TraceRecords()
+		entries = append(entries, r...)
+		return err
+	})
+	return entries, err
+}
+
+// TraceStats 返回池中所有引擎追踪统计的总和
+func (p *Pool) TraceStats() (*TraceStats, error) {
+	total := &TraceStats{ByLevel: map[string]int{}, ByCategory: map[string]int{}}
+	err := p.each(func(e *Engine) error {
+		s, err := e.TraceStats()
+		if err != nil {
+			return err
+		}
+		total.TotalEntries += s.TotalEntries
+		total.BufferSize += s.BufferSize
+		total.BufferFull = total.BufferFull || s.BufferFull
+		for k, v := range s.ByLevel {
+			total.ByLevel[k] += v
+		}
+		for k, v := range s.ByCategory {
+			total.ByCategory[k] += v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return total, nil
+}
+
+// ClearTrace 清空池中所有引擎的追踪缓冲区
+func (p *Pool) ClearTrace() error {
+	return p.each((*Engine).ClearTrace)
+}
+
+// SetExecutionLimits 为池中的所有引擎设置执行限制
+func (p *Pool) SetExecutionLimits(limits Limits) error {
+	return p.each(func(e *Engine) error { return e.SetExecutionLimits(limits) })
+}
+
+// GetExecutionLimits 返回池中第一个引擎的执行限制
+//
+// 通过 Pool.SetExecutionLimits 设置的限制在所有引擎中相同
+func (p *Pool) GetExecutionLimits() (*Limits, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	return p.engines[0].GetExecutionLimits()
+}
+
+// ClearCache 清空池中所有引擎的缓存
+func (p *Pool) ClearCache() error {
+	return p.each((*Engine).ClearCache)
+}
+
+// CacheStats 返回池中所有引擎缓存统计的总和
+func (p *Pool) CacheStats() (*CacheStats, error) {
+	total := &CacheStats{}
+	err := p.each(func(e *Engine) error {
+		s, err := e.CacheStats()
+		if err != nil {
+			return err
+		}
+		total.Hits += s.Hits
+		total.Misses += s.Misses
+		total.Size += s.Size
+		total.Evictions += s.Evictions
+		total.Bytes += s.Bytes
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return total, nil
+}
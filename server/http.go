@@ -0,0 +1,153 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+// ServeHTTP 实现 http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == "/eval":
+		s.handle(w, r, func(ctx context.Context, body []byte) (int, interface{}, error) {
+			var req EvalRequest
+			if err := decode(body, &req); err != nil {
+				return 0, nil, err
+			}
+			return evalStatus(s.Eval(ctx, req))
+		})
+	case path == "/programs":
+		s.handle(w, r, func(ctx context.Context, body []byte) (int, interface{}, error) {
+			var p Program
+			if err := decode(body, &p); err != nil {
+				return 0, nil, err
+			}
+			created, err := s.RegisterProgram(p)
+			if err != nil {
+				return 0, nil, err
+			}
+			status := http.StatusOK
+			if created {
+				status = http.StatusCreated
+			}
+			return status, map[string]string{"name": p.Name}, nil
+		})
+	case strings.HasPrefix(path, "/programs/") && strings.HasSuffix(path, "/run"):
+		name := strings.TrimSuffix(strings.TrimPrefix(path, "/programs/"), "/run")
+		s.handle(w, r, func(ctx context.Context, body []byte) (int, interface{}, error) {
+			var req RunRequest
+			if err := decode(body, &req); err != nil {
+				return 0, nil, err
+			}
+			return evalStatus(s.RunProgram(ctx, name, req))
+		})
+	default:
+		writeError(w, http.StatusNotFound, "not_found", "未知的路径: "+r.URL.Path)
+	}
+}
+
+// handle 检查方法与请求体大小后调用 fn 并输出 JSON 响应
+func (s *Server) handle(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, body []byte) (int, interface{}, error)) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "只支持 POST")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.cfg.MaxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "too_large", fmt.Sprintf("请求体超过 %d 字节", s.cfg.MaxBodyBytes))
+			return
+		}
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	status, v, err := fn(r.Context(), body)
+	if err != nil {
+		status, code := errorStatus(err)
+		writeError(w, status, code, err.Error())
+		return
+	}
+	writeJSON(w, status, v)
+}
+
+// decode 解析 JSON 请求体,不接受未知字段,防止客户端以为设置了服务端固定的选项
+func decode(body []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return &requestError{fmt.Errorf("无效的请求: %w", err)}
+	}
+	if dec.More() {
+		return &requestError{errors.New("无效的请求: 请求体只能包含一个 JSON 对象")}
+	}
+	return nil
+}
+
+// requestError 表示请求体不合法
+type requestError struct{ err error }
+
+func (e *requestError) Error() string { return e.err.Error() }
+func (e *requestError) Unwrap() error { return e.err }
+
+// evalStatus 根据脚本的错误代码选择 HTTP 状态码
+//
+// 解析、运行时等脚本错误返回 422,原生库内部错误返回 500
+func evalStatus(resp *EvalResponse, err error) (int, interface{}, error) {
+	if err != nil {
+		return 0, nil, err
+	}
+	if resp.Error == nil {
+		return http.StatusOK, resp, nil
+	}
+	switch resp.Error.Code {
+	case aether.CodePanic.String(), aether.CodeNullPointer.String():
+		return http.StatusInternalServerError, resp, nil
+	default:
+		return http.StatusUnprocessableEntity, resp, nil
+	}
+}
+
+// errorStatus 返回请求错误对应的 HTTP 状态码与错误代码
+func errorStatus(err error) (int, string) {
+	var reqErr *requestError
+	switch {
+	case errors.As(err, &reqErr), errors.Is(err, ErrInvalidName):
+		return http.StatusBadRequest, "bad_request"
+	case errors.Is(err, ErrCodeTooLarge):
+		return http.StatusRequestEntityTooLarge, "too_large"
+	case errors.Is(err, ErrProgramNotFound):
+		return http.StatusNotFound, "not_found"
+	case errors.Is(err, ErrTooManyPrograms):
+		return http.StatusInsufficientStorage, "too_many_programs"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled), errors.Is(err, aether.ErrPoolClosed):
+		return http.StatusServiceUnavailable, "unavailable"
+	default:
+		return http.StatusInternalServerError, "internal"
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]*ErrorBody{"error": {Code: code, Message: message}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
@@ -0,0 +1,356 @@
+// Package server 通过 HTTP 对外提供 Aether 脚本执行服务
+//
+// 基本用法:
+//
+//	srv, err := server.New(server.Config{PoolSize: runtime.NumCPU()})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer srv.Close()
+//
+//	http.Handle("/aether/", http.StripPrefix("/aether", srv))
+//
+// Server 持有一个引擎池,每个请求借出一个引擎,执行前重置变量与追踪缓冲区,
+// 因此请求之间不共享状态。IO 权限由 Config.AllowIO 决定,客户端无法更改;
+// 请求中的执行限制只能比 Config.Limits 更严格。
+//
+// 接口:
+//
+//	POST /eval                 {"code", "globals", "limits"} → EvalResponse
+//	POST /programs             {"name", "code"} 注册命名脚本
+//	POST /programs/{name}/run  {"globals", "limits"} → EvalResponse
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+// 默认配置
+const (
+	DefaultMaxBodyBytes = 1 << 20
+	DefaultMaxCodeBytes = 64 << 10
+	DefaultMaxPrograms  = 1000
+)
+
+var (
+	// ErrProgramNotFound 在运行未注册的脚本时返回
+	ErrProgramNotFound = errors.New("server: 脚本未注册")
+	// ErrTooManyPrograms 在注册的脚本数达到 Config.MaxPrograms 时返回
+	ErrTooManyPrograms = errors.New("server: 注册的脚本过多")
+	// ErrInvalidName 在脚本名称不合法时返回
+	ErrInvalidName = errors.New("server: 脚本名称只能包含字母、数字、_、- 与 .,且不超过 128 个字符")
+	// ErrCodeTooLarge 在脚本超过 Config.MaxCodeBytes 时返回
+	ErrCodeTooLarge = errors.New("server: 脚本过大")
+)
+
+// namePattern 为合法的脚本名称
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+
+// Config 为服务配置
+type Config struct {
+	// PoolSize 为引擎池大小,小于 1 时为 1
+	PoolSize int
+	// AllowIO 为 true 时使用 NewWithPermissions 创建引擎
+	AllowIO bool
+	// Limits 为默认的执行限制,同时是请求能够设置的上限。
+	// 为 0 的字段使用引擎的默认限制
+	Limits aether.Limits
+	// MaxBodyBytes 为 HTTP 请求体的最大字节数,0 表示 DefaultMaxBodyBytes
+	MaxBodyBytes int64
+	// MaxCodeBytes 为脚本的最大字节数,0 表示 DefaultMaxCodeBytes
+	MaxCodeBytes int
+	// MaxPrograms 为最多能注册的脚本数,0 表示 DefaultMaxPrograms
+	MaxPrograms int
+}
+
+// Limits 为请求中的执行限制,0 表示使用服务的默认值
+type Limits struct {
+	MaxSteps  int `json:"max_steps,omitempty"`
+	MaxDepth  int `json:"max_depth,omitempty"`
+	TimeoutMs int `json:"timeout_ms,omitempty"`
+}
+
+// EvalRequest 为 POST /eval 的请求
+type EvalRequest struct {
+	Code    string                 `json:"code"`
+	Globals map[string]interface{} `json:"globals,omitempty"`
+	Limits  *Limits                `json:"limits,omitempty"`
+}
+
+// RunRequest 为 POST /programs/{name}/run 的请求
+type RunRequest struct {
+	Globals map[string]interface{} `json:"globals,omitempty"`
+	Limits  *Limits                `json:"limits,omitempty"`
+}
+
+// Program 为 POST /programs 的请求
+type Program struct {
+	Name string `json:"name"`
+	Code string `json:"code"`
+}
+
+// EvalResponse 为一次执行的结果
+type EvalResponse struct {
+	// Result 为脚本的返回值,出错时为空
+	Result string `json:"result"`
+	// Error 为脚本返回的错误,成功时为 nil
+	Error *ErrorBody `json:"error,omitempty"`
+	// Trace 为结构化的追踪条目
+	Trace []aether.TraceEntry `json:"trace"`
+	Usage Usage               `json:"usage"`
+}
+
+// ErrorBody 为响应中的错误
+type ErrorBody struct {
+	// Code 为 aether.ErrorCode 的名称,如 "parse_error";
+	// 请求本身有误时为 "bad_request"、"not_found" 等
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Usage 描述一次执行使用的资源
+type Usage struct {
+	// DurationMs 为执行耗时,单位为毫秒
+	DurationMs float64 `json:"duration_ms"`
+	// WaitMs 为等待空闲引擎的时间,单位为毫秒
+	WaitMs float64 `json:"wait_ms"`
+	// Limits 为本次执行实际使用的限制,-1 表示不限制
+	Limits Limits `json:"limits"`
+	// Deadline 为 true 时 Limits.TimeoutMs 由 ctx 的截止时间得出
+	Deadline bool `json:"deadline,omitempty"`
+}
+
+// Server 为脚本执行服务,实现了 http.Handler
+//
+// Server 是线程安全的
+type Server struct {
+	cfg    Config
+	pool   *aether.Pool
+	limits aether.Limits
+
+	mu       sync.RWMutex
+	programs map[string]string
+}
+
+// New 按配置创建服务及其引擎池
+//
+// 库无法加载或与本绑定的 ABI 不兼容时返回错误;库版本不在兼容表中时只警告,与 aether.New 相同
+func New(cfg Config) (*Server, error) {
+	if err := aether.CheckABI(); err != nil && !errors.Is(err, aether.ErrUnknownLibraryVersion) {
+		return nil, err
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if cfg.MaxCodeBytes <= 0 {
+		cfg.MaxCodeBytes = DefaultMaxCodeBytes
+	}
+	if cfg.MaxPrograms <= 0 {
+		cfg.MaxPrograms = DefaultMaxPrograms
+	}
+
+	newEngine := aether.New
+	if cfg.AllowIO {
+		newEngine = aether.NewWithPermissions
+	}
+	s := &Server{
+		cfg:      cfg,
+		pool:     aether.NewPool(cfg.PoolSize, newEngine),
+		programs: make(map[string]string),
+	}
+
+	// 未设置的字段使用引擎的默认限制;无法读取默认限制时不限制
+	defaults := aether.Limits{MaxSteps: -1, MaxRecursionDepth: -1, MaxDurationMs: -1}
+	if l, err := s.pool.GetExecutionLimits(); err == nil {
+		defaults = *l
+	}
+	s.limits = cfg.Limits
+	if s.limits.MaxSteps == 0 {
+		s.limits.MaxSteps = defaults.MaxSteps
+	}
+	if s.limits.MaxRecursionDepth == 0 {
+		s.limits.MaxRecursionDepth = defaults.MaxRecursionDepth
+	}
+	if s.limits.MaxDurationMs == 0 {
+		s.limits.MaxDurationMs = defaults.MaxDurationMs
+	}
+	return s, nil
+}
+
+// Pool 返回服务使用的引擎池,可用于 metrics.Collector 等观测
+func (s *Server) Pool() *aether.Pool {
+	return s.pool
+}
+
+// Close 关闭引擎池
+func (s *Server) Close() {
+	s.pool.Close()
+}
+
+// Eval 借出一个引擎执行请求中的代码
+//
+// 脚本的错误记录在 EvalResponse.Error 中;只有请求不合法或无法借出引擎时返回错误。
+// ctx 有截止时间时执行时间不会超过剩余时间
+func (s *Server) Eval(ctx context.Context, req EvalRequest) (*EvalResponse, error) {
+	if len(req.Code) > s.cfg.MaxCodeBytes {
+		return nil, ErrCodeTooLarge
+	}
+	return s.run(ctx, req.Code, req.Globals, req.Limits)
+}
+
+// RegisterProgram 注册或替换命名脚本,返回是否为新注册
+func (s *Server) RegisterProgram(p Program) (created bool, err error) {
+	if !namePattern.MatchString(p.Name) {
+		return false, ErrInvalidName
+	}
+	if len(p.Code) > s.cfg.MaxCodeBytes {
+		return false, ErrCodeTooLarge
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.programs[p.Name]
+	if !exists && len(s.programs) >= s.cfg.MaxPrograms {
+		return false, ErrTooManyPrograms
+	}
+	s.programs[p.Name] = p.Code
+	return !exists, nil
+}
+
+// Programs 返回已注册的脚本名称,按名称排序
+func (s *Server) Programs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.programs))
+	for name := range s.programs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunProgram 执行已注册的脚本
+func (s *Server) RunProgram(ctx context.Context, name string, req RunRequest) (*EvalResponse, error) {
+	s.mu.RLock()
+	code, ok := s.programs[name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrProgramNotFound
+	}
+	return s.run(ctx, code, req.Globals, req.Limits)
+}
+
+// run 在借出的引擎中执行代码
+func (s *Server) run(ctx context.Context, code string, globals map[string]interface{}, req *Limits) (*EvalResponse, error) {
+	start := time.Now()
+	e, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.pool.Release(e)
+	wait := time.Since(start)
+
+	// 借出引擎后再计算,使等待引擎的时间不计入执行时间限制
+	limits, deadline := s.effectiveLimits(ctx, req)
+
+	resp := &EvalResponse{Trace: []aether.TraceEntry{}}
+	resp.Usage.WaitMs = milliseconds(wait)
+	resp.Usage.Limits = Limits{MaxSteps: limits.MaxSteps, MaxDepth: limits.MaxRecursionDepth, TimeoutMs: limits.MaxDurationMs}
+	resp.Usage.Deadline = deadline
+
+	if err := s.prepare(e, globals, limits); err != nil {
+		resp.Error = errorBody(err)
+		return resp, nil
+	}
+
+	start = time.Now()
+	result, err := e.Eval(code)
+	resp.Usage.DurationMs = milliseconds(time.Since(start))
+	if err != nil {
+		resp.Error = errorBody(err)
+	} else {
+		resp.Result = result
+	}
+
+	if records, err := e.TraceRecords(); err == nil && records != nil {
+		resp.Trace = records
+	}
+	return resp, nil
+}
+
+// prepare 清除上一个请求留下的状态并设置本次请求的变量与限制
+func (s *Server) prepare(e *aether.Engine, globals map[string]interface{}, limits aether.Limits) error {
+	if err := e.ResetEnv(); err != nil {
+		return err
+	}
+	if err := e.ClearTrace(); err != nil {
+		return err
+	}
+	if err := e.SetExecutionLimits(limits); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(globals))
+	for name := range globals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := e.SetGlobal(name, globals[name]); err != nil {
+			return fmt.Errorf("无法设置变量 %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// effectiveLimits 合并服务限制、请求限制与 ctx 的截止时间,取其中更严格的值;
+// deadline 表示执行时间限制是否由截止时间得出
+func (s *Server) effectiveLimits(ctx context.Context, req *Limits) (limits aether.Limits, deadline bool) {
+	limits = s.limits
+	if req != nil {
+		limits.MaxSteps = tighter(limits.MaxSteps, req.MaxSteps)
+		limits.MaxRecursionDepth = tighter(limits.MaxRecursionDepth, req.MaxDepth)
+		limits.MaxDurationMs = tighter(limits.MaxDurationMs, req.TimeoutMs)
+	}
+	if d, ok := ctx.Deadline(); ok {
+		ms := int(time.Until(d) / time.Millisecond)
+		if ms < 1 {
+			ms = 1
+		}
+		if tighter(limits.MaxDurationMs, ms) != limits.MaxDurationMs {
+			limits.MaxDurationMs = ms
+			deadline = true
+		}
+	}
+	return limits, deadline
+}
+
+// tighter 返回两个限制中更严格的一个;limit 小于 0 表示不限制,requested 不大于 0 表示未设置
+func tighter(limit, requested int) int {
+	if requested <= 0 {
+		return limit
+	}
+	if limit < 0 || requested < limit {
+		return requested
+	}
+	return limit
+}
+
+// errorBody 把 Aether 错误转换为响应中的错误
+func errorBody(err error) *ErrorBody {
+	return &ErrorBody{Code: aether.CodeOf(err).String(), Message: err.Error()}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,288 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+// post 向 handler 发送 POST 请求并解析 JSON 响应
+func post(t *testing.T, h http.Handler, path, body string, v interface{}) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("%s: Content-Type 为 %q", path, ct)
+	}
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s: 无法解析响应 %q: %v", path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+// errorCode 返回错误响应中的错误代码
+func errorCode(t *testing.T, h http.Handler, path, body string) (int, string) {
+	t.Helper()
+	var resp struct {
+		Error *ErrorBody `json:"error"`
+	}
+	status := post(t, h, path, body, &resp)
+	if resp.Error == nil {
+		t.Fatalf("%s: 期望错误响应,状态码 %d", path, status)
+	}
+	return status, resp.Error.Code
+}
+
+// newServer 创建服务,失败时终止测试
+func newServer(t *testing.T, cfg Config) *Server {
+	t.Helper()
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New 失败: %v", err)
+	}
+	return srv
+}
+
+// TestEval 测试 POST /eval 返回结果、追踪与用量
+func TestEval(t *testing.T) {
+	srv := newServer(t, Config{PoolSize: 1})
+	defer srv.Close()
+
+	var resp EvalResponse
+	status := post(t, srv, "/eval", `{"code": "Set X (A + 1)\nTRACE_INFO(\"calc\", X)\nX", "globals": {"A": 41}}`, &resp)
+	if status != http.StatusOK {
+		t.Fatalf("期望 200,得到 %d: %+v", status, resp.Error)
+	}
+	if resp.Result != "42" {
+		t.Errorf("期望结果 42,得到 %q", resp.Result)
+	}
+	if len(resp.Trace) != 1 || resp.Trace[0].Category != "calc" || resp.Trace[0].Values[0] != "42" {
+		t.Errorf("追踪条目不正确: %+v", resp.Trace)
+	}
+	if resp.Usage.DurationMs <= 0 {
+		t.Errorf("期望记录耗时,得到 %v", resp.Usage.DurationMs)
+	}
+}
+
+// TestEvalIsolation 测试请求之间不共享变量与追踪条目
+func TestEvalIsolation(t *testing.T) {
+	srv := newServer(t, Config{PoolSize: 1})
+	defer srv.Close()
+
+	post(t, srv, "/eval", `{"code": "Set LEAK 1\nTRACE_INFO(\"a\", LEAK)\nLEAK"}`, nil)
+
+	var resp EvalResponse
+	status := post(t, srv, "/eval", `{"code": "LEAK"}`, &resp)
+	if status != http.StatusUnprocessableEntity {
+		t.Fatalf("期望 422,得到 %d", status)
+	}
+	if resp.Error == nil || resp.Error.Code != aether.CodeRuntimeError.String() {
+		t.Errorf("期望 runtime_error,得到 %+v", resp.Error)
+	}
+	if len(resp.Trace) != 0 {
+		t.Errorf("期望没有上一个请求的追踪条目,得到 %+v", resp.Trace)
+	}
+}
+
+// TestEvalRejectsUnknownFields 测试客户端不能通过请求设置服务端选项
+func TestEvalRejectsUnknownFields(t *testing.T) {
+	srv := newServer(t, Config{})
+	defer srv.Close()
+
+	tests := []string{
+		`{"code": "1", "allow_io": true}`,
+		`{"code": "1", "permissions": true}`,
+		`{"code": 1}`,
+		`not json`,
+		`{"code": "1"} {"code": "2"}`,
+	}
+	for _, body := range tests {
+		if status, code := errorCode(t, srv, "/eval", body); status != http.StatusBadRequest || code != "bad_request" {
+			t.Errorf("%s: 期望 400 bad_request,得到 %d %s", body, status, code)
+		}
+	}
+}
+
+// TestSizeLimits 测试请求体与脚本的大小限制
+func TestSizeLimits(t *testing.T) {
+	srv := newServer(t, Config{MaxBodyBytes: 100, MaxCodeBytes: 10})
+	defer srv.Close()
+
+	big := `{"code": "` + strings.Repeat("1", 200) + `"}`
+	if status, code := errorCode(t, srv, "/eval", big); status != http.StatusRequestEntityTooLarge || code != "too_large" {
+		t.Errorf("请求体过大: 期望 413 too_large,得到 %d %s", status, code)
+	}
+
+	long := `{"code": "(1 + 2 + 3 + 4)"}`
+	if status, code := errorCode(t, srv, "/eval", long); status != http.StatusRequestEntityTooLarge || code != "too_large" {
+		t.Errorf("脚本过大: 期望 413 too_large,得到 %d %s", status, code)
+	}
+	if status, code := errorCode(t, srv, "/programs", `{"name": "p", "code": "(1 + 2 + 3 + 4)"}`); status != http.StatusRequestEntityTooLarge || code != "too_large" {
+		t.Errorf("注册过大的脚本: 期望 413 too_large,得到 %d %s", status, code)
+	}
+}
+
+// TestPrograms 测试注册与执行命名脚本
+func TestPrograms(t *testing.T) {
+	srv := newServer(t, Config{PoolSize: 2, MaxPrograms: 2})
+	defer srv.Close()
+
+	if status := post(t, srv, "/programs", `{"name": "double", "code": "(A * 2)"}`, nil); status != http.StatusCreated {
+		t.Errorf("首次注册期望 201,得到 %d", status)
+	}
+	if status := post(t, srv, "/programs", `{"name": "double", "code": "(A * 3)"}`, nil); status != http.StatusOK {
+		t.Errorf("替换期望 200,得到 %d", status)
+	}
+
+	var resp EvalResponse
+	if status := post(t, srv, "/programs/double/run", `{"globals": {"A": 5}}`, &resp); status != http.StatusOK {
+		t.Fatalf("期望 200,得到 %d: %+v", status, resp.Error)
+	}
+	if resp.Result != "15" {
+		t.Errorf("期望替换后的脚本返回 15,得到 %q", resp.Result)
+	}
+
+	if status, code := errorCode(t, srv, "/programs/missing/run", `{}`); status != http.StatusNotFound || code != "not_found" {
+		t.Errorf("未注册的脚本: 期望 404 not_found,得到 %d %s", status, code)
+	}
+	if status, code := errorCode(t, srv, "/programs", `{"name": "../etc", "code": "1"}`); status != http.StatusBadRequest {
+		t.Errorf("非法名称: 期望 400,得到 %d %s", status, code)
+	}
+
+	post(t, srv, "/programs", `{"name": "second", "code": "2"}`, nil)
+	if status, code := errorCode(t, srv, "/programs", `{"name": "third", "code": "3"}`); status != http.StatusInsufficientStorage || code != "too_many_programs" {
+		t.Errorf("超过数量: 期望 507 too_many_programs,得到 %d %s", status, code)
+	}
+
+	if got := srv.Programs(); len(got) != 2 || got[0] != "double" || got[1] != "second" {
+		t.Errorf("期望 [double second],得到 %v", got)
+	}
+}
+
+// TestRouting 测试未知路径与方法
+func TestRouting(t *testing.T) {
+	srv := newServer(t, Config{})
+	defer srv.Close()
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/eval", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("GET /eval: 期望 405 与 Allow: POST,得到 %d %q", rec.Code, rec.Header().Get("Allow"))
+	}
+
+	if status, code := errorCode(t, srv, "/unknown", `{}`); status != http.StatusNotFound || code != "not_found" {
+		t.Errorf("未知路径: 期望 404,得到 %d %s", status, code)
+	}
+
+	// 挂载在前缀下
+	mux := http.NewServeMux()
+	mux.Handle("/aether/", http.StripPrefix("/aether", srv))
+	var resp EvalResponse
+	if status := post(t, mux, "/aether/eval", `{"code": "(1 + 2)"}`, &resp); status != http.StatusOK || resp.Result != "3" {
+		t.Errorf("前缀下的 /eval: 得到 %d %q", status, resp.Result)
+	}
+}
+
+// TestClosedServer 测试关闭后的请求返回 503
+func TestClosedServer(t *testing.T) {
+	srv := newServer(t, Config{})
+	srv.Close()
+
+	if status, code := errorCode(t, srv, "/eval", `{"code": "1"}`); status != http.StatusServiceUnavailable || code != "unavailable" {
+		t.Errorf("期望 503 unavailable,得到 %d %s", status, code)
+	}
+}
+
+// TestEffectiveLimits 测试请求限制只能比服务限制更严格
+func TestEffectiveLimits(t *testing.T) {
+	srv := newServer(t, Config{Limits: aether.Limits{MaxSteps: 1000, MaxRecursionDepth: -1, MaxDurationMs: 5000}})
+	defer srv.Close()
+
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		req  *Limits
+		want aether.Limits
+	}{
+		{"默认", nil, aether.Limits{MaxSteps: 1000, MaxRecursionDepth: -1, MaxDurationMs: 5000}},
+		{"更严格", &Limits{MaxSteps: 10, MaxDepth: 5, TimeoutMs: 100}, aether.Limits{MaxSteps: 10, MaxRecursionDepth: 5, MaxDurationMs: 100}},
+		{"更宽松", &Limits{MaxSteps: 1000000, TimeoutMs: 60000}, aether.Limits{MaxSteps: 1000, MaxRecursionDepth: -1, MaxDurationMs: 5000}},
+		{"负数", &Limits{MaxSteps: -1}, aether.Limits{MaxSteps: 1000, MaxRecursionDepth: -1, MaxDurationMs: 5000}},
+	}
+	for _, tt := range tests {
+		if got, deadline := srv.effectiveLimits(ctx, tt.req); got != tt.want || deadline {
+			t.Errorf("%s: 期望 %+v,得到 %+v", tt.name, tt.want, got)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	if got, deadline := srv.effectiveLimits(ctx, nil); got.MaxDurationMs <= 0 || got.MaxDurationMs > 200 || !deadline {
+		t.Errorf("期望截止时间把执行时间限制在 200ms 内,得到 %d", got.MaxDurationMs)
+	}
+
+	var resp EvalResponse
+	post(t, srv, "/eval", `{"code": "1", "limits": {"max_steps": 1000000}}`, &resp)
+	if resp.Usage.Limits.MaxSteps != 1000 {
+		t.Errorf("期望响应中的步数限制为 1000,得到 %d", resp.Usage.Limits.MaxSteps)
+	}
+}
+
+// TestPartialLimits 测试 Config.Limits 中为 0 的字段使用引擎的默认限制
+func TestPartialLimits(t *testing.T) {
+	srv := newServer(t, Config{Limits: aether.Limits{MaxDurationMs: 5000}})
+	defer srv.Close()
+
+	defaults, err := srv.Pool().GetExecutionLimits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := aether.Limits{MaxSteps: defaults.MaxSteps, MaxRecursionDepth: defaults.MaxRecursionDepth, MaxDurationMs: 5000}
+	if srv.limits != want {
+		t.Errorf("期望 %+v,得到 %+v", want, srv.limits)
+	}
+
+	resp, err := srv.Eval(context.Background(), EvalRequest{Code: "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error != nil || resp.Result != "42" {
+		t.Errorf("期望结果 42,得到 %q %+v", resp.Result, resp.Error)
+	}
+}
+
+// TestDeadlineAfterWait 测试等待空闲引擎的时间不计入执行时间限制
+func TestDeadlineAfterWait(t *testing.T) {
+	srv := newServer(t, Config{PoolSize: 1})
+	defer srv.Close()
+
+	e, err := srv.Pool().Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		srv.Pool().Release(e)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	resp, err := srv.Eval(ctx, EvalRequest{Code: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Usage.WaitMs < 150 || !resp.Usage.Deadline {
+		t.Fatalf("期望等待空闲引擎,得到 %+v", resp.Usage)
+	}
+	if got := resp.Usage.Limits.TimeoutMs; got <= 0 || got > 500-150 {
+		t.Errorf("期望执行时间限制扣除等待时间,得到 %dms", got)
+	}
+}
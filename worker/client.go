@@ -0,0 +1,578 @@
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+// 默认配置
+const (
+	DefaultCommand        = "aether"
+	DefaultStartTimeout   = 10 * time.Second
+	DefaultHealthInterval = 5 * time.Second
+	DefaultHealthTimeout  = 2 * time.Second
+)
+
+var (
+	// ErrWorkerExited 表示调用期间 worker 进程意外退出(例如原生库崩溃),错误代码为 CodePanic
+	ErrWorkerExited = &aether.Error{Code: aether.CodePanic, Message: "worker 进程意外退出"}
+
+	// ErrWorkerTimeout 表示 worker 没有在 Config.CallTimeout 内响应,进程已被终止
+	ErrWorkerTimeout = &aether.Error{Code: aether.CodeRuntimeError, Message: "worker 没有在限定时间内响应,已终止"}
+)
+
+// Config 配置 worker 子进程
+type Config struct {
+	// Command 为 worker 可执行文件,为空时使用 PATH 中的 aether
+	Command string
+	// Args 为传给 Command 的参数,为 nil 时为 ["worker"]
+	Args []string
+	// AllowIO 为 true 时追加 -allow-io 参数
+	AllowIO bool
+	// Env 为子进程的环境变量,为 nil 时继承当前进程
+	Env []string
+	// Stderr 接收子进程的标准错误输出以及脚本的打印输出,为 nil 时使用 os.Stderr
+	Stderr io.Writer
+
+	// CallTimeout 为单次调用的最长时间,超时后终止 worker,0 表示不限制
+	CallTimeout time.Duration
+	// StartTimeout 为启动后等待 worker 响应 ping 的最长时间,0 时使用 DefaultStartTimeout
+	StartTimeout time.Duration
+	// HealthInterval 为空闲时健康检查的间隔,0 时使用 DefaultHealthInterval,负数表示不检查
+	HealthInterval time.Duration
+	// HealthTimeout 为健康检查等待 ping 响应的最长时间,0 时使用 DefaultHealthTimeout
+	HealthTimeout time.Duration
+}
+
+// Client 通过 worker 子进程执行脚本,实现 aether.Evaluator
+//
+// worker 退出或超时后,下一次调用(或健康检查)会启动新的 worker,
+// 并重新应用通过 SetGlobal 设置的变量与 SetExecutionLimits 设置的限制;
+// 脚本中定义的变量、追踪条目与缓存不会保留。
+// 调用按顺序在同一个 worker 中执行,需要并发时创建多个 Client。
+// 此类型是线程安全的
+type Client struct {
+	cfg Config
+
+	mu       sync.Mutex
+	proc     *process
+	nextID   int64
+	started  bool
+	restarts int
+	closed   bool
+
+	globals map[string]json.RawMessage
+	limits  *aether.Limits
+
+	quit chan struct{}
+}
+
+// process 为一个运行中的 worker
+type process struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	enc       *json.Encoder
+	responses chan *response
+
+	killOnce sync.Once
+	killed   chan struct{}
+
+	done chan struct{} // 进程退出后关闭
+	err  error         // 进程的退出状态,done 关闭后可读
+}
+
+// NewClient 启动 worker 并返回客户端
+//
+// worker 无法启动或没有响应 ping 时返回错误
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.Command == "" {
+		cfg.Command = DefaultCommand
+	}
+	if cfg.Args == nil {
+		cfg.Args = []string{"worker"}
+	}
+	if cfg.Stderr == nil {
+		cfg.Stderr = os.Stderr
+	}
+	if cfg.StartTimeout <= 0 {
+		cfg.StartTimeout = DefaultStartTimeout
+	}
+	if cfg.HealthInterval == 0 {
+		cfg.HealthInterval = DefaultHealthInterval
+	}
+	if cfg.HealthTimeout <= 0 {
+		cfg.HealthTimeout = DefaultHealthTimeout
+	}
+
+	c := &Client{cfg: cfg, quit: make(chan struct{})}
+	c.mu.Lock()
+	err := c.ensureLocked()
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.HealthInterval > 0 {
+		go c.healthLoop()
+	}
+	return c, nil
+}
+
+// Eval 在 worker 中执行代码
+//
+// 此方法是线程安全的
+func (c *Client) Eval(code string) (string, error) {
+	var result string
+	err := c.call("eval", evalParams{Code: code}, &result)
+	return result, err
+}
+
+// SetGlobal 在 worker 中设置全局变量,worker 重启后会重新设置
+//
+// 此方法是线程安全的
+func (c *Client) SetGlobal(name string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("无法将值序列化为 JSON: %w", err)
+	}
+	return c.callRecord("setGlobal", globalParams{Name: name, Value: data}, nil, func() {
+		if c.globals == nil {
+			c.globals = make(map[string]json.RawMessage)
+		}
+		c.globals[name] = data
+	})
+}
+
+// GetGlobal 获取 worker 中变量的值
+//
+// 此方法是线程安全的
+func (c *Client) GetGlobal(name string) (interface{}, error) {
+	var value interface{}
+	err := c.call("getGlobal", globalParams{Name: name}, &value)
+	return value, err
+}
+
+// ResetEnv 重置 worker 的运行时环境,并清除需要在重启后重新设置的变量
+//
+// 此方法是线程安全的
+func (c *Client) ResetEnv() error {
+	c.mu.Lock()
+	c.globals = nil
+	c.mu.Unlock()
+	return c.call("resetEnv", nil, nil)
+}
+
+// TakeTrace 返回 worker 中的所有追踪条目
+//
+// 此方法是线程安全的
+func (c *Client) TakeTrace() ([]string, error) {
+	var trace []string
+	err := c.call("takeTrace", nil, &trace)
+	return trace, err
+}
+
+// TraceRecords 返回 worker 中结构化的追踪条目
+//
+// 此方法是线程安全的
+func (c *Client) TraceRecords() ([]aether.TraceEntry, error) {
+	var records []aether.TraceEntry
+	err := c.call("traceRecords", nil, &records)
+	return records, err
+}
+
+// TraceStats 返回 worker 中的追踪统计
+//
+// 此方法是线程安全的
+func (c *Client) TraceStats() (*aether.TraceStats, error) {
+	var stats aether.TraceStats
+	if err := c.call("traceStats", nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// ClearTrace 清空 worker 中的追踪缓冲区
+//
+// 此方法是线程安全的
+func (c *Client) ClearTrace() error {
+	return c.call("clearTrace", nil, nil)
+}
+
+// SetExecutionLimits 设置 worker 的执行限制,worker 重启后会重新设置
+//
+// 此方法是线程安全的
+func (c *Client) SetExecutionLimits(limits aether.Limits) error {
+	params := limitsParams{MaxSteps: limits.MaxSteps, MaxRecursionDepth: limits.MaxRecursionDepth, MaxDurationMs: limits.MaxDurationMs}
+	return c.callRecord("setExecutionLimits", params, nil, func() {
+		c.limits = &limits
+	})
+}
+
+// GetExecutionLimits 返回 worker 当前的执行限制
+//
+// 此方法是线程安全的
+func (c *Client) GetExecutionLimits() (*aether.Limits, error) {
+	var l limitsParams
+	if err := c.call("getExecutionLimits", nil, &l); err != nil {
+		return nil, err
+	}
+	return &aether.Limits{MaxSteps: l.MaxSteps, MaxRecursionDepth: l.MaxRecursionDepth, MaxDurationMs: l.MaxDurationMs}, nil
+}
+
+// ClearCache 清空 worker 的 AST 缓存
+//
+// 此方法是线程安全的
+func (c *Client) ClearCache() error {
+	return c.call("clearCache", nil, nil)
+}
+
+// CacheStats 返回 worker 的缓存统计
+//
+// 此方法是线程安全的
+func (c *Client) CacheStats() (*aether.CacheStats, error) {
+	var s cacheStatsResult
+	if err := c.call("cacheStats", nil, &s); err != nil {
+		return nil, err
+	}
+	return &aether.CacheStats{Hits: s.Hits, Misses: s.Misses, Size: s.Size, Evictions: s.Evictions, Bytes: s.Bytes}, nil
+}
+
+// Ping 检查 worker 是否能响应,worker 已退出时先启动新的 worker
+//
+// 此方法是线程安全的
+func (c *Client) Ping() error {
+	return c.call("ping", nil, nil)
+}
+
+// PID 返回当前 worker 的进程 ID,没有运行中的 worker 时返回 0
+//
+// 此方法是线程安全的
+func (c *Client) PID() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.proc == nil || c.proc.exited() {
+		return 0
+	}
+	return c.proc.cmd.Process.Pid
+}
+
+// Restarts 返回第一个 worker 之后启动 worker 的次数
+//
+// 此方法是线程安全的
+func (c *Client) Restarts() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.restarts
+}
+
+// Close 停止健康检查并关闭 worker
+//
+// 先关闭 worker 的标准输入等待其退出,1 秒内没有退出时终止进程。
+// 此方法是线程安全的,可以多次调用
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.quit)
+
+	if p := c.proc; p != nil {
+		c.proc = nil
+		p.stdin.Close()
+		select {
+		case <-p.done:
+		case <-time.After(time.Second):
+			p.kill()
+		}
+	}
+}
+
+// call 在 worker 中调用方法,worker 没有运行时先启动
+func (c *Client) call(method string, params, result interface{}) error {
+	return c.callRecord(method, params, result, nil)
+}
+
+// callRecord 与 call 相同,调用成功后在同一次加锁中执行 record
+//
+// record 用于记录重启后需要重新设置的状态;与调用在同一次加锁中执行,
+// 避免在调用与记录之间重启的 worker 缺少这次设置的状态
+func (c *Client) callRecord(method string, params, result interface{}, record func()) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return aether.ErrClosed
+	}
+	if err := c.ensureLocked(); err != nil {
+		return err
+	}
+	if err := c.callLocked(c.proc, method, params, result, c.cfg.CallTimeout); err != nil {
+		return err
+	}
+	if record != nil {
+		record()
+	}
+	return nil
+}
+
+// callLocked 发送请求并等待响应,worker 退出或超时时丢弃该 worker
+func (c *Client) callLocked(p *process, method string, params, result interface{}, timeout time.Duration) error {
+	c.nextID++
+	id := strconv.FormatInt(c.nextID, 10)
+	req := struct {
+		JSONRPC string      `json:"jsonrpc"`
+		ID      int64       `json:"id"`
+		Method  string      `json:"method"`
+		Params  interface{} `json:"params,omitempty"`
+	}{"2.0", c.nextID, method, params}
+
+	if err := p.enc.Encode(req); err != nil {
+		// 写入失败说明 worker 已经退出,等待读取协程得到退出状态
+		select {
+		case <-p.done:
+		case <-time.After(time.Second):
+		}
+		c.discardLocked(p)
+		return fmt.Errorf("%w: %v", ErrWorkerExited, p.exitErr())
+	}
+
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+	for {
+		select {
+		case resp := <-p.responses:
+			if string(resp.ID) == id {
+				return decodeResult(resp, result)
+			}
+		case <-p.done:
+			// 进程退出前可能已经写出了响应
+			if resp := p.pending(id); resp != nil {
+				return decodeResult(resp, result)
+			}
+			c.discardLocked(p)
+			return fmt.Errorf("%w: %v", ErrWorkerExited, p.exitErr())
+		case <-timer:
+			c.discardLocked(p)
+			return ErrWorkerTimeout
+		}
+	}
+}
+
+// ensureLocked 在没有运行中的 worker 时启动新的 worker
+func (c *Client) ensureLocked() error {
+	if c.proc != nil {
+		if !c.proc.exited() {
+			return nil
+		}
+		c.proc = nil
+	}
+
+	p, err := c.start()
+	if err != nil {
+		return err
+	}
+	if c.started {
+		c.restarts++
+	}
+	c.started = true
+	c.proc = p
+	return nil
+}
+
+// start 启动 worker,等待其响应 ping 后重新设置变量与执行限制
+func (c *Client) start() (*process, error) {
+	args := append([]string(nil), c.cfg.Args...)
+	if c.cfg.AllowIO {
+		args = append(args, "-allow-io")
+	}
+	cmd := exec.Command(c.cfg.Command, args...)
+	cmd.Env = c.cfg.Env
+	cmd.Stderr = c.cfg.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("worker: 无法启动 %s: %w", c.cfg.Command, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		stdin.Close()
+		return nil, fmt.Errorf("worker: 无法启动 %s: %w", c.cfg.Command, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("worker: 无法启动 %s: %w", c.cfg.Command, err)
+	}
+
+	p := &process{
+		cmd:       cmd,
+		stdin:     stdin,
+		enc:       json.NewEncoder(stdin),
+		responses: make(chan *response, 1),
+		killed:    make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	p.enc.SetEscapeHTML(false)
+	go p.readLoop(stdout)
+
+	// 以下出错时都要终止并回收进程,否则 worker 会一直等待标准输入
+	if err := c.callLocked(p, "ping", nil, nil, c.cfg.StartTimeout); err != nil {
+		c.discardLocked(p)
+		return nil, fmt.Errorf("worker: %s 没有响应: %w", c.cfg.Command, err)
+	}
+
+	names := make([]string, 0, len(c.globals))
+	for name := range c.globals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := c.callLocked(p, "setGlobal", globalParams{Name: name, Value: c.globals[name]}, nil, c.cfg.StartTimeout); err != nil {
+			c.discardLocked(p)
+			return nil, fmt.Errorf("worker: 重新设置变量 '%s' 失败: %w", name, err)
+		}
+	}
+	if l := c.limits; l != nil {
+		params := limitsParams{MaxSteps: l.MaxSteps, MaxRecursionDepth: l.MaxRecursionDepth, MaxDurationMs: l.MaxDurationMs}
+		if err := c.callLocked(p, "setExecutionLimits", params, nil, c.cfg.StartTimeout); err != nil {
+			c.discardLocked(p)
+			return nil, fmt.Errorf("worker: 重新设置执行限制失败: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// discardLocked 终止 worker,下一次调用时启动新的 worker
+func (c *Client) discardLocked(p *process) {
+	p.kill()
+	if c.proc == p {
+		c.proc = nil
+	}
+}
+
+// healthLoop 定期检查空闲的 worker,没有响应或已退出时重启
+func (c *Client) healthLoop() {
+	t := time.NewTicker(c.cfg.HealthInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-c.quit:
+			return
+		case <-t.C:
+			c.checkHealth()
+		}
+	}
+}
+
+func (c *Client) checkHealth() {
+	// 正在执行调用时跳过,执行过久由 CallTimeout 处理
+	if !c.mu.TryLock() {
+		return
+	}
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	if err := c.ensureLocked(); err != nil {
+		return
+	}
+	if err := c.callLocked(c.proc, "ping", nil, nil, c.cfg.HealthTimeout); err != nil {
+		c.ensureLocked()
+	}
+}
+
+// readLoop 读取 worker 的响应,标准输出关闭后等待进程退出
+func (p *process) readLoop(r io.Reader) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var resp response
+			if json.Unmarshal(line, &resp) == nil && resp.ID != nil {
+				select {
+				case p.responses <- &resp:
+				case <-p.killed:
+				}
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	p.err = p.cmd.Wait()
+	close(p.done)
+}
+
+// pending 返回进程退出前已经读到的 id 对应的响应
+func (p *process) pending(id string) *response {
+	for {
+		select {
+		case resp := <-p.responses:
+			if string(resp.ID) == id {
+				return resp
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// kill 终止进程并等待其退出
+func (p *process) kill() {
+	p.killOnce.Do(func() {
+		close(p.killed)
+		p.cmd.Process.Kill()
+	})
+	<-p.done
+}
+
+func (p *process) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// exitErr 返回进程的退出原因
+func (p *process) exitErr() error {
+	if !p.exited() {
+		return errors.New("标准输出已关闭")
+	}
+	if p.err == nil {
+		return errors.New("exit status 0")
+	}
+	return p.err
+}
+
+// decodeResult 把响应的结果解析到 result 中
+func decodeResult(resp *response, result interface{}) error {
+	if resp.Error != nil {
+		return fromRPCError(resp.Error)
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("worker: 无法解析响应: %w", err)
+	}
+	return nil
+}
+
+var _ aether.Evaluator = (*Client)(nil)
@@ -0,0 +1,282 @@
+// Package worker 在子进程中运行 Aether,原生库崩溃时只有子进程退出
+//
+// aether worker 命令通过标准输入输出使用 JSON-RPC 2.0 通信,每行一条消息。
+// Client 实现了 aether.Evaluator,负责启动、健康检查与重启子进程:
+//
+//	client, err := worker.NewClient(worker.Config{CallTimeout: 5 * time.Second})
+//	if err != nil {
+//	    return err
+//	}
+//	defer client.Close()
+//
+//	result, err := client.Eval("(1 + 2)")
+//
+// 方法与 Evaluator 一一对应,参数为命名参数:
+//
+//	eval                 {"code"}                                            → 结果字符串
+//	setGlobal            {"name", "value"}                                   → null
+//	getGlobal            {"name"}                                            → 变量的值
+//	resetEnv, clearTrace, clearCache                                         → null
+//	takeTrace                                                                → 字符串数组
+//	traceRecords                                                             → TraceEntry 数组
+//	traceStats                                                               → TraceStats
+//	setExecutionLimits   {"max_steps", "max_recursion_depth", "max_duration_ms"} → null
+//	getExecutionLimits                                                       → 同 setExecutionLimits 的参数
+//	cacheStats                                                               → {"hits", "misses", "size", "evictions", "bytes"}
+//	ping                                                                     → {"version", "pid"}
+//
+// Aether 错误的错误代码为 AetherErrorCode(1-6),message 不含 "aether: " 前缀;
+// 其他错误的代码为 -32000。不支持批量请求。
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+// JSON-RPC 2.0 定义的错误代码,以及不是 *aether.Error 的错误使用的代码
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeServerError    = -32000
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("JSON-RPC 错误 %d: %s", e.Code, e.Message)
+}
+
+// 方法的参数与结果
+type (
+	evalParams struct {
+		Code string `json:"code"`
+	}
+	globalParams struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value,omitempty"`
+	}
+	limitsParams struct {
+		MaxSteps          int `json:"max_steps"`
+		MaxRecursionDepth int `json:"max_recursion_depth"`
+		MaxDurationMs     int `json:"max_duration_ms"`
+	}
+	cacheStatsResult struct {
+		Hits      int `json:"hits"`
+		Misses    int `json:"misses"`
+		Size      int `json:"size"`
+		Evictions int `json:"evictions"`
+		Bytes     int `json:"bytes"`
+	}
+	pingResult struct {
+		Version string `json:"version"`
+		PID     int    `json:"pid"`
+	}
+)
+
+// Serve 从 r 读取请求,在 ev 中执行后把响应写入 w,直到 r 结束
+//
+// aether worker 命令以标准输入输出调用 Serve;也可以在其他进程中用任意 Evaluator 提供同样的协议
+func Serve(ev aether.Evaluator, r io.Reader, w io.Writer) error {
+	in := bufio.NewReader(r)
+	out := bufio.NewWriter(w)
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+
+	for {
+		line, err := in.ReadBytes('\n')
+		if len(line) > 0 {
+			if resp := handle(ev, line); resp != nil {
+				if err := enc.Encode(resp); err != nil {
+					return err
+				}
+				if err := out.Flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handle 处理一条消息,通知(没有 id 的请求)返回 nil
+func handle(ev aether.Evaluator, line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		var batch []json.RawMessage
+		if json.Unmarshal(line, &batch) == nil {
+			return errorResponse(nil, &rpcError{Code: codeInvalidRequest, Message: "不支持批量请求"})
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			return nil
+		}
+		return errorResponse(nil, &rpcError{Code: codeParseError, Message: err.Error()})
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, &rpcError{Code: codeInvalidRequest, Message: `jsonrpc 必须为 "2.0" 且 method 不能为空`})
+	}
+
+	result, err := dispatch(ev, req.Method, req.Params)
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		return errorResponse(req.ID, toRPCError(err))
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(req.ID, &rpcError{Code: codeServerError, Message: err.Error()})
+	}
+	return &response{JSONRPC: "2.0", ID: req.ID, Result: data}
+}
+
+// dispatch 调用与方法名对应的 Evaluator 方法
+func dispatch(ev aether.Evaluator, method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "eval":
+		var p evalParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return ev.Eval(p.Code)
+	case "setGlobal":
+		var p globalParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if len(p.Value) > 0 {
+			value = p.Value
+		}
+		return nil, ev.SetGlobal(p.Name, value)
+	case "getGlobal":
+		var p globalParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return ev.GetGlobal(p.Name)
+	case "resetEnv":
+		return nil, ev.ResetEnv()
+	case "takeTrace":
+		return nonNil(ev.TakeTrace())
+	case "traceRecords":
+		return nonNil(ev.TraceRecords())
+	case "traceStats":
+		return ev.TraceStats()
+	case "clearTrace":
+		return nil, ev.ClearTrace()
+	case "setExecutionLimits":
+		var p limitsParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return nil, ev.SetExecutionLimits(aether.Limits{
+			MaxSteps:          p.MaxSteps,
+			MaxRecursionDepth: p.MaxRecursionDepth,
+			MaxDurationMs:     p.MaxDurationMs,
+		})
+	case "getExecutionLimits":
+		l, err := ev.GetExecutionLimits()
+		if err != nil {
+			return nil, err
+		}
+		return limitsParams{MaxSteps: l.MaxSteps, MaxRecursionDepth: l.MaxRecursionDepth, MaxDurationMs: l.MaxDurationMs}, nil
+	case "clearCache":
+		return nil, ev.ClearCache()
+	case "cacheStats":
+		s, err := ev.CacheStats()
+		if err != nil {
+			return nil, err
+		}
+		return cacheStatsResult{Hits: s.Hits, Misses: s.Misses, Size: s.Size, Evictions: s.Evictions, Bytes: s.Bytes}, nil
+	case "ping":
+		return pingResult{Version: aether.Version(), PID: os.Getpid()}, nil
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "未知的方法: " + method}
+	}
+}
+
+// decodeParams 解析命名参数
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return &rpcError{Code: codeInvalidParams, Message: "缺少参数"}
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// nonNil 使空列表编码为 [] 而不是 null
+func nonNil[T any](list []T, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []T{}
+	}
+	return list, nil
+}
+
+// toRPCError 把 Evaluator 返回的错误转换为 JSON-RPC 错误
+func toRPCError(err error) *rpcError {
+	var rerr *rpcError
+	if errors.As(err, &rerr) {
+		return rerr
+	}
+	var aerr *aether.Error
+	if errors.As(err, &aerr) {
+		return &rpcError{Code: int(aerr.Code), Message: aerr.Message}
+	}
+	return &rpcError{Code: codeServerError, Message: err.Error()}
+}
+
+// fromRPCError 把 JSON-RPC 错误还原为 Evaluator 返回的错误
+func fromRPCError(e *rpcError) error {
+	switch {
+	case e.Code > 0:
+		return &aether.Error{Code: aether.ErrorCode(e.Code), Message: e.Message}
+	case e.Code == codeServerError:
+		return errors.New(e.Message)
+	default:
+		return e
+	}
+}
+
+func errorResponse(id json.RawMessage, e *rpcError) *response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &response{JSONRPC: "2.0", ID: id, Error: e}
+}
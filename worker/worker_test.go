@@ -0,0 +1,354 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	aether "github.com/xiaozuhui/aether-go"
+)
+
+// 设置此环境变量时测试程序作为 worker 运行;为 ping-error 时对所有请求返回错误,
+// 并把进程 ID 写入 pidEnv 指定的文件
+const (
+	workerEnv = "AETHER_WORKER_TEST"
+	pidEnv    = "AETHER_WORKER_TEST_PID"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv(workerEnv) == "ping-error" {
+		os.WriteFile(os.Getenv(pidEnv), []byte(strconv.Itoa(os.Getpid())), 0644)
+		dec := json.NewDecoder(os.Stdin)
+		for {
+			var req struct {
+				ID json.RawMessage `json:"id"`
+			}
+			if dec.Decode(&req) != nil {
+				os.Exit(0)
+			}
+			fmt.Printf(`{"jsonrpc":"2.0","id":%s,"error":{"code":%d,"message":"not ready"}}`+"\n", req.ID, codeServerError)
+		}
+	}
+	if os.Getenv(workerEnv) == "1" {
+		engine := aether.New()
+		if err := Serve(crashingEvaluator{engine}, os.Stdin, os.Stdout); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// crashingEvaluator 执行 __crash__ 时退出进程,执行 __hang__ 时不再返回,用来模拟原生库崩溃与卡死
+type crashingEvaluator struct {
+	*aether.Engine
+}
+
+func (e crashingEvaluator) Eval(code string) (string, error) {
+	switch code {
+	case "__crash__":
+		os.Exit(3)
+	case "__hang__":
+		time.Sleep(time.Hour)
+	}
+	return e.Engine.Eval(code)
+}
+
+// newTestClient 以测试程序作为 worker 创建客户端
+func newTestClient(t *testing.T, cfg Config) *Client {
+	t.Helper()
+	cfg.Command = os.Args[0]
+	cfg.Env = append(os.Environ(), workerEnv+"=1")
+	if cfg.HealthInterval == 0 {
+		cfg.HealthInterval = -1
+	}
+	c, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient 失败: %v", err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+// serveLines 在内存中执行 Serve 并返回每行的响应
+func serveLines(t *testing.T, lines ...string) []response {
+	t.Helper()
+	engine := aether.New()
+	defer engine.Close()
+
+	var out bytes.Buffer
+	if err := Serve(engine, strings.NewReader(strings.Join(lines, "\n")), &out); err != nil {
+		t.Fatalf("Serve 失败: %v", err)
+	}
+
+	var responses []response
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var resp response
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+// TestServe 测试请求的处理与 JSON-RPC 错误代码
+func TestServe(t *testing.T) {
+	responses := serveLines(t,
+		`{"jsonrpc":"2.0","id":1,"method":"setGlobal","params":{"name":"A","value":41}}`,
+		`{"jsonrpc":"2.0","method":"clearTrace"}`,
+		`{"jsonrpc":"2.0","id":"two","method":"eval","params":{"code":"(A + 1)"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"eval","params":{"code":"UNDEFINED_VAR"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"missing"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"eval"}`,
+		`{"jsonrpc":"1.0","id":6,"method":"eval"}`,
+		`[{"jsonrpc":"2.0","id":7,"method":"ping"}]`,
+		`{not json`,
+		``,
+		`{"jsonrpc":"2.0","id":8,"method":"takeTrace"}`,
+	)
+
+	tests := []struct {
+		id     string
+		result string
+		code   int
+	}{
+		{`1`, `null`, 0},
+		{`"two"`, `"42"`, 0},
+		{`3`, ``, int(aether.CodeRuntimeError)},
+		{`4`, ``, codeMethodNotFound},
+		{`5`, ``, codeInvalidParams},
+		{`6`, ``, codeInvalidRequest},
+		{`null`, ``, codeInvalidRequest},
+		{`null`, ``, codeParseError},
+		{`8`, `[]`, 0},
+	}
+	if len(responses) != len(tests) {
+		t.Fatalf("期望 %d 条响应(通知与空行没有响应),得到 %d: %+v", len(tests), len(responses), responses)
+	}
+	for i, tt := range tests {
+		resp := responses[i]
+		if string(resp.ID) != tt.id {
+			t.Errorf("第 %d 条: 期望 id %s,得到 %s", i, tt.id, resp.ID)
+		}
+		if tt.code == 0 {
+			if resp.Error != nil || string(resp.Result) != tt.result {
+				t.Errorf("id %s: 期望结果 %s,得到 %s %v", tt.id, tt.result, resp.Result, resp.Error)
+			}
+			continue
+		}
+		if resp.Error == nil || resp.Error.Code != tt.code {
+			t.Errorf("id %s: 期望错误代码 %d,得到 %+v", tt.id, tt.code, resp.Error)
+		}
+	}
+}
+
+// TestErrorConversion 测试错误经过协议后保持错误代码
+func TestErrorConversion(t *testing.T) {
+	err := fromRPCError(toRPCError(&aether.Error{Code: aether.CodeParseError, Message: "意外的符号"}))
+	var aerr *aether.Error
+	if !errors.As(err, &aerr) || aerr.Code != aether.CodeParseError || aerr.Message != "意外的符号" {
+		t.Errorf("期望还原为 parse_error,得到 %v", err)
+	}
+
+	err = fromRPCError(toRPCError(errors.New("变量未找到: X")))
+	if err.Error() != "变量未找到: X" || aether.CodeOf(err) != aether.CodeRuntimeError {
+		t.Errorf("期望还原为普通错误,得到 %v", err)
+	}
+}
+
+// TestClient 测试客户端实现的 Evaluator 方法
+func TestClient(t *testing.T) {
+	c := newTestClient(t, Config{})
+
+	if err := c.SetGlobal("A", 41); err != nil {
+		t.Fatal(err)
+	}
+	result, err := c.Eval("Set X (A + 1)\nTRACE_INFO(\"calc\", X)\nX")
+	if err != nil || result != "42" {
+		t.Fatalf("期望 42,得到 %q %v", result, err)
+	}
+	if v, err := c.GetGlobal("X"); err != nil || v != float64(42) {
+		t.Errorf("期望 X 为 42,得到 %v %v", v, err)
+	}
+
+	records, err := c.TraceRecords()
+	if err != nil || len(records) != 1 || records[0].Category != "calc" {
+		t.Errorf("追踪条目不正确: %v %v", records, err)
+	}
+	stats, err := c.TraceStats()
+	if err != nil || stats.TotalEntries != 1 {
+		t.Errorf("追踪统计不正确: %+v %v", stats, err)
+	}
+	if err := c.ClearTrace(); err != nil {
+		t.Fatal(err)
+	}
+	if trace, err := c.TakeTrace(); err != nil || len(trace) != 0 {
+		t.Errorf("清空后期望没有追踪条目,得到 %v %v", trace, err)
+	}
+
+	limits := aether.Limits{MaxSteps: 1000, MaxRecursionDepth: 50, MaxDurationMs: 2000}
+	if err := c.SetExecutionLimits(limits); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetExecutionLimits(); err != nil || *got != limits {
+		t.Errorf("期望 %+v,得到 %+v %v", limits, got, err)
+	}
+
+	if _, err := c.CacheStats(); err != nil {
+		t.Error(err)
+	}
+	if err := c.ClearCache(); err != nil {
+		t.Error(err)
+	}
+
+	_, err = c.Eval("UNDEFINED_VAR")
+	if aether.CodeOf(err) != aether.CodeRuntimeError {
+		t.Errorf("期望 runtime_error,得到 %v", err)
+	}
+
+	if err := c.ResetEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetGlobal("A"); err == nil {
+		t.Error("重置后期望变量 A 不存在")
+	}
+}
+
+// TestClientCrash 测试 worker 崩溃后返回 panic 错误,并在重启后恢复变量与执行限制
+func TestClientCrash(t *testing.T) {
+	c := newTestClient(t, Config{})
+	pid := c.PID()
+
+	limits := aether.Limits{MaxSteps: 500, MaxRecursionDepth: -1, MaxDurationMs: -1}
+	if err := c.SetGlobal("A", 41); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetExecutionLimits(limits); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := c.Eval("__crash__")
+	if !errors.Is(err, ErrWorkerExited) || aether.CodeOf(err) != aether.CodePanic {
+		t.Fatalf("期望 ErrWorkerExited,得到 %v", err)
+	}
+	if !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("期望错误包含退出状态,得到 %v", err)
+	}
+
+	result, err := c.Eval("(A + 1)")
+	if err != nil || result != "42" {
+		t.Fatalf("重启后期望 42,得到 %q %v", result, err)
+	}
+	if got, err := c.GetExecutionLimits(); err != nil || *got != limits {
+		t.Errorf("重启后期望限制 %+v,得到 %+v %v", limits, got, err)
+	}
+	if c.Restarts() != 1 || c.PID() == pid {
+		t.Errorf("期望重启一次并更换进程,得到 %d 次,PID %d -> %d", c.Restarts(), pid, c.PID())
+	}
+}
+
+// TestClientTimeout 测试调用超时后终止 worker
+func TestClientTimeout(t *testing.T) {
+	c := newTestClient(t, Config{CallTimeout: 500 * time.Millisecond})
+
+	start := time.Now()
+	_, err := c.Eval("__hang__")
+	if !errors.Is(err, ErrWorkerTimeout) {
+		t.Fatalf("期望 ErrWorkerTimeout,得到 %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("超时后等待过久: %v", elapsed)
+	}
+
+	if result, err := c.Eval("(1 + 2)"); err != nil || result != "3" {
+		t.Errorf("重启后期望 3,得到 %q %v", result, err)
+	}
+}
+
+// TestClientHealthCheck 测试健康检查重启被外部终止的 worker
+func TestClientHealthCheck(t *testing.T) {
+	c := newTestClient(t, Config{HealthInterval: 50 * time.Millisecond})
+	pid := c.PID()
+
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proc.Kill(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for c.Restarts() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("健康检查没有重启 worker")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := c.PID(); got == 0 || got == pid {
+		t.Errorf("期望新的 worker 进程,得到 PID %d", got)
+	}
+}
+
+// TestClientClose 测试关闭后的调用返回 ErrClosed
+func TestClientClose(t *testing.T) {
+	c := newTestClient(t, Config{})
+	c.Close()
+	c.Close()
+
+	if _, err := c.Eval("1"); !errors.Is(err, aether.ErrClosed) {
+		t.Errorf("期望 ErrClosed,得到 %v", err)
+	}
+	if c.PID() != 0 {
+		t.Errorf("关闭后期望没有 worker 进程,得到 PID %d", c.PID())
+	}
+}
+
+// TestNewClientError 测试无法启动 worker 时返回错误
+func TestNewClientError(t *testing.T) {
+	_, err := NewClient(Config{Command: "aether-worker-does-not-exist", HealthInterval: -1})
+	if err == nil {
+		t.Fatal("期望启动失败")
+	}
+}
+
+// TestNewClientPingError 测试 worker 对 ping 返回错误时终止并回收进程
+func TestNewClientPingError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("需要用信号 0 检查进程是否存在")
+	}
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	_, err := NewClient(Config{
+		Command:        os.Args[0],
+		Env:            append(os.Environ(), workerEnv+"=ping-error", pidEnv+"="+pidFile),
+		HealthInterval: -1,
+	})
+	if err == nil || !strings.Contains(err.Error(), "not ready") {
+		t.Fatalf("期望 ping 返回的错误,得到 %v", err)
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, _ := strconv.Atoi(string(data))
+	// 进程已被回收时信号 0 返回 ErrProcessDone;没有终止或没有 Wait 时进程(或僵尸进程)仍然存在
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proc.Signal(syscall.Signal(0)); !errors.Is(err, os.ErrProcessDone) {
+		t.Errorf("期望 worker 进程 %d 已退出并被回收,得到 %v", pid, err)
+	}
+}